@@ -0,0 +1,122 @@
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+
+	"nonogram-solver/internal/solver"
+)
+
+// Baseline is a saved benchmark run. The environment fields are informational:
+// comparing runs from different machines is allowed but rarely meaningful.
+type Baseline struct {
+	Created   time.Time `json:"created"`
+	GoVersion string    `json:"go_version"`
+	GOOS      string    `json:"goos"`
+	GOARCH    string    `json:"goarch"`
+	CPUs      int       `json:"cpus"`
+	Samples   []Sample  `json:"puzzles"`
+}
+
+// NewBaseline records samples along with the current environment
+func NewBaseline(samples []Sample) *Baseline {
+	return &Baseline{
+		Created:   time.Now().UTC().Truncate(time.Second),
+		GoVersion: runtime.Version(),
+		GOOS:      runtime.GOOS,
+		GOARCH:    runtime.GOARCH,
+		CPUs:      runtime.GOMAXPROCS(0),
+		Samples:   samples,
+	}
+}
+
+// LoadBaseline reads a baseline written by Save
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline %s: %w", path, err)
+	}
+	return &b, nil
+}
+
+// Save writes the baseline as indented JSON
+func (b *Baseline) Save(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Delta compares one puzzle between a baseline and a new run. Percentages are
+// (current - base) / base * 100, so positive means slower or more allocations.
+type Delta struct {
+	Name      string
+	Base      *Sample // nil when the puzzle is new
+	Current   *Sample // nil when the puzzle was not run
+	TimePct   float64
+	AllocsPct float64
+	Regressed bool
+	Note      string
+}
+
+// Compare matches samples by name, in the order of the current run followed by
+// puzzles only present in the baseline. A puzzle regresses when its time or
+// allocations grow by more than threshold percent, or when it was solved in the
+// baseline and no longer is.
+func Compare(base, current *Baseline, threshold float64) []Delta {
+	baseByName := make(map[string]*Sample, len(base.Samples))
+	for i := range base.Samples {
+		baseByName[base.Samples[i].Name] = &base.Samples[i]
+	}
+
+	deltas := make([]Delta, 0, len(current.Samples))
+	seen := make(map[string]bool, len(current.Samples))
+	for i := range current.Samples {
+		cur := &current.Samples[i]
+		seen[cur.Name] = true
+		d := Delta{Name: cur.Name, Current: cur, Base: baseByName[cur.Name]}
+		if d.Base == nil {
+			d.Note = "new"
+			deltas = append(deltas, d)
+			continue
+		}
+		d.TimePct = percent(d.Base.NsPerOp, cur.NsPerOp)
+		d.AllocsPct = percent(d.Base.AllocsPerOp, cur.AllocsPerOp)
+		switch {
+		case d.Base.Status == solver.Solved.String() && cur.Status != d.Base.Status:
+			d.Regressed = true
+			d.Note = fmt.Sprintf("was %s, now %s", d.Base.Status, cur.Status)
+		case d.TimePct > threshold:
+			d.Regressed = true
+			d.Note = "time"
+		case d.AllocsPct > threshold:
+			d.Regressed = true
+			d.Note = "allocs"
+		}
+		deltas = append(deltas, d)
+	}
+	for i := range base.Samples {
+		if !seen[base.Samples[i].Name] {
+			deltas = append(deltas, Delta{Name: base.Samples[i].Name, Base: &base.Samples[i], Note: "not run"})
+		}
+	}
+	return deltas
+}
+
+func percent(base, current int64) float64 {
+	if base == 0 {
+		if current == 0 {
+			return 0
+		}
+		return 100
+	}
+	return float64(current-base) / float64(base) * 100
+}
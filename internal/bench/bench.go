@@ -0,0 +1,119 @@
+package bench
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"nonogram-solver/internal/factory"
+	"nonogram-solver/internal/puzzle"
+	"nonogram-solver/internal/solver"
+)
+
+// DefaultCorpus is the checked-in corpus, relative to the repository root
+const DefaultCorpus = "testdata/corpus"
+
+// Entry is one corpus puzzle
+type Entry struct {
+	Name   string
+	Puzzle *puzzle.Puzzle
+}
+
+// LoadCorpus reads every puzzle file (.json, .txt, .non) in dir, sorted by name.
+// Entries are named after their file without the extension.
+func LoadCorpus(dir string) ([]Entry, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	for _, file := range files {
+		ext := strings.ToLower(filepath.Ext(file.Name()))
+		if file.IsDir() || (ext != ".json" && ext != ".txt" && ext != ".non") {
+			continue
+		}
+		path := filepath.Join(dir, file.Name())
+		p, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, Entry{Name: strings.TrimSuffix(file.Name(), filepath.Ext(file.Name())), Puzzle: p})
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no puzzles in %s", dir)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
+}
+
+func readFile(path string) (*puzzle.Puzzle, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+	p, err := puzzle.Read(fh, puzzle.DetectFormat(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return p, nil
+}
+
+// Sample is the measurement of one puzzle over several runs
+type Sample struct {
+	Name        string `json:"name"`
+	Runs        int    `json:"runs"`
+	NsPerOp     int64  `json:"ns_per_op"` // median over runs
+	AllocsPerOp int64  `json:"allocs_per_op"`
+	BytesPerOp  int64  `json:"bytes_per_op"`
+	Status      string `json:"status"`
+}
+
+// Run solves the puzzle once from a cold combinations cache of its own: grid
+// creation, combination generation and solving are all included. The shared
+// cache is left alone.
+func Run(ctx context.Context, p *puzzle.Puzzle, opts solver.Options, providers factory.ProviderOptions) (solver.Result, error) {
+	providers.Cache = factory.NewCombinationsCache("", nil)
+	g := p.Grid(providers)
+	return solver.Solve(ctx, &g, opts)
+}
+
+// Measure runs the puzzle runs times and reports the median wall time and the
+// mean allocations per run. Runs stop at the first one that does not solve.
+func Measure(ctx context.Context, name string, p *puzzle.Puzzle, runs int, opts solver.Options, providers factory.ProviderOptions) (Sample, error) {
+	sample := Sample{Name: name}
+	var times []time.Duration
+	var allocs, bytes uint64
+	for sample.Runs < runs {
+		runtime.GC()
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		start := time.Now()
+		result, err := Run(ctx, p, opts, providers)
+		elapsed := time.Since(start)
+		runtime.ReadMemStats(&after)
+		if err != nil {
+			return sample, err
+		}
+
+		sample.Runs++
+		sample.Status = result.Status.String()
+		times = append(times, elapsed)
+		allocs += after.Mallocs - before.Mallocs
+		bytes += after.TotalAlloc - before.TotalAlloc
+		if result.Status != solver.Solved {
+			break
+		}
+	}
+
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+	sample.NsPerOp = times[len(times)/2].Nanoseconds()
+	sample.AllocsPerOp = int64(allocs) / int64(sample.Runs)
+	sample.BytesPerOp = int64(bytes) / int64(sample.Runs)
+	return sample, nil
+}
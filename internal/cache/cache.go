@@ -0,0 +1,136 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultTTL keeps entries for 30 days; published puzzles rarely change
+const DefaultTTL = 30 * 24 * time.Hour
+
+// Cache stores files under Dir by slash-separated key, e.g.
+// "nonograms.org/123.html". An entry expires TTL after it was written.
+type Cache struct {
+	Dir string
+	// TTL of 0 means entries never expire
+	TTL time.Duration
+	// Now defaults to time.Now; tests override it
+	Now func() time.Time
+}
+
+// DefaultDir returns the user's cache directory for the solver:
+// $XDG_CACHE_HOME/nonogram-solver on Linux, or the platform equivalent
+func DefaultDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "nonogram-solver"), nil
+}
+
+// New returns a cache rooted at dir
+func New(dir string, ttl time.Duration) *Cache {
+	return &Cache{Dir: dir, TTL: ttl}
+}
+
+func (c *Cache) now() time.Time {
+	if c.Now != nil {
+		return c.Now()
+	}
+	return time.Now()
+}
+
+func (c *Cache) path(key string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(key))
+	if key == "" || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid cache key %q", key)
+	}
+	return filepath.Join(c.Dir, clean), nil
+}
+
+// Get returns the entry for key unless it is missing or expired
+func (c *Cache) Get(key string) ([]byte, bool) {
+	path, err := c.path(key)
+	if err != nil {
+		return nil, false
+	}
+	info, err := os.Stat(path)
+	if err != nil || c.expired(info) {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// Put stores data for key. The file is written under a temporary name and
+// renamed, so concurrent readers never see a partial entry.
+func (c *Cache) Put(key string, data []byte) error {
+	path, err := c.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	// Expiry is measured from the write, whatever clock the cache uses
+	now := c.now()
+	return os.Chtimes(path, now, now)
+}
+
+func (c *Cache) expired(info fs.FileInfo) bool {
+	return c.TTL > 0 && c.now().Sub(info.ModTime()) > c.TTL
+}
+
+// Prune removes expired entries, or every entry when all is set, and returns
+// how many files it removed. A missing cache directory is not an error.
+func (c *Cache) Prune(all bool) (int, error) {
+	removed := 0
+	err := filepath.WalkDir(c.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == c.Dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if all || c.expired(info) {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+			removed++
+		}
+		return nil
+	})
+	return removed, err
+}
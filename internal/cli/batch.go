@@ -0,0 +1,326 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"nonogram-solver/internal/combinatorics"
+	"nonogram-solver/internal/puzzle"
+	"nonogram-solver/internal/solver"
+)
+
+// maxRangeSize guards against typos such as 1000-120000
+const maxRangeSize = 100000
+
+var idRange = regexp.MustCompile(`^([0-9]+)-([0-9]+)$`)
+
+// batchJob is one puzzle to solve: an ID or URI to fetch or a file to read
+type batchJob struct {
+	source string
+	file   bool
+	load   func(ctx context.Context) (*puzzle.Puzzle, error)
+}
+
+// batchRecord is one row of the batch summary
+type batchRecord struct {
+	Source  string  `json:"source"`
+	Width   int     `json:"width"`
+	Height  int     `json:"height"`
+	Colors  int     `json:"colors"`
+	Status  string  `json:"status"`
+	WallMS  float64 `json:"wall_ms"`
+	AllocMB float64 `json:"alloc_mb"`
+	Passes  int     `json:"passes"`
+	Guesses int     `json:"guesses"`
+	Title   string  `json:"title,omitempty"`
+	URL     string  `json:"url,omitempty"`
+	Error   string  `json:"error,omitempty"`
+}
+
+var batchColumns = []string{"source", "width", "height", "colors", "status", "wall_ms", "alloc_mb", "passes", "guesses", "title", "url", "error"}
+
+func (r batchRecord) csvRow() []string {
+	return []string{
+		r.Source,
+		strconv.Itoa(r.Width),
+		strconv.Itoa(r.Height),
+		strconv.Itoa(r.Colors),
+		r.Status,
+		strconv.FormatFloat(r.WallMS, 'f', 3, 64),
+		strconv.FormatFloat(r.AllocMB, 'f', 3, 64),
+		strconv.Itoa(r.Passes),
+		strconv.Itoa(r.Guesses),
+		r.Title,
+		r.URL,
+		r.Error,
+	}
+}
+
+func runBatch(ctx context.Context, e *env, args []string) int {
+	fs := newFlagSet(e, "batch", "[<id>|<from>-<to>|<file>|<dir>]...")
+	var out outputFlags
+	var sf solverFlags
+	var ff fetchFlags
+	ids := fs.String("ids", "", "file listing puzzle IDs or ranges, one per line")
+	idsRange := fs.String("range", "", "inclusive ID range, e.g. 1000-1200")
+	dir := fs.String("dir", "", "directory of puzzle files (.json, .txt, .non)")
+	jobs := fs.Int("jobs", runtime.GOMAXPROCS(0), "number of puzzles solved concurrently; alloc_mb is only exact with 1")
+	out.register(fs, "csv", "csv or json")
+	sf.register(fs)
+	ff.register(fs)
+	positional, code, ok := parseFlags(fs, args)
+	if !ok {
+		return code
+	}
+	if *jobs <= 0 {
+		return e.errorf("--jobs must be positive")
+	}
+	if out.format != "csv" && out.format != "json" {
+		return e.errorf("unknown output format %q (want csv or json)", out.format)
+	}
+
+	var sources []string
+	if *ids != "" {
+		listed, err := readIDList(*ids)
+		if err != nil {
+			return e.errorf("%v", err)
+		}
+		sources = append(sources, listed...)
+	}
+	if *idsRange != "" {
+		sources = append(sources, *idsRange)
+	}
+	if *dir != "" {
+		sources = append(sources, *dir)
+	}
+	sources = append(sources, positional...)
+	if len(sources) == 0 {
+		fs.Usage()
+		return ExitError
+	}
+
+	ff.apply(e)
+	batch, err := expandSources(e, sources)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+
+	records := solveBatch(ctx, e, batch, *jobs, &sf)
+
+	w, closeOut, err := out.open(e)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	if err := writeBatch(w, records, out.format); err != nil {
+		closeOut()
+		return e.errorf("%v", err)
+	}
+	if err := closeOut(); err != nil {
+		return e.errorf("%v", err)
+	}
+	return batchExitCode(records)
+}
+
+// readIDList reads IDs and ranges from a file, skipping blank lines and # comments
+func readIDList(path string) ([]string, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	var sources []string
+	scanner := bufio.NewScanner(fh)
+	for scanner.Scan() {
+		text, _, _ := strings.Cut(scanner.Text(), "#")
+		sources = append(sources, strings.Fields(text)...)
+	}
+	return sources, scanner.Err()
+}
+
+// expandSources turns IDs, ranges, puzzle URIs, files and directories into
+// jobs, in order
+func expandSources(e *env, sources []string) ([]batchJob, error) {
+	var jobs []batchJob
+	for _, arg := range sources {
+		if m := idRange.FindStringSubmatch(arg); m != nil {
+			from, _ := strconv.Atoi(m[1])
+			to, _ := strconv.Atoi(m[2])
+			if from > to || to-from >= maxRangeSize {
+				return nil, fmt.Errorf("invalid ID range %q", arg)
+			}
+			for id := from; id <= to; id++ {
+				jobs = append(jobs, sourceJob(e, strconv.Itoa(id), "nonograms.org", strconv.Itoa(id)))
+			}
+			continue
+		}
+		scheme, path, _ := splitSource(e, arg)
+		if scheme != "file" {
+			jobs = append(jobs, sourceJob(e, arg, scheme, path))
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			jobs = append(jobs, fileJob(e, path))
+			continue
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			switch strings.ToLower(filepath.Ext(entry.Name())) {
+			case ".json", ".txt", ".non", ".text":
+				if !entry.IsDir() {
+					jobs = append(jobs, fileJob(e, filepath.Join(path, entry.Name())))
+				}
+			}
+		}
+	}
+	return jobs, nil
+}
+
+// sourceJob fetches id from the source registered for scheme
+func sourceJob(e *env, name, scheme, id string) batchJob {
+	src, _ := e.sources.Lookup(scheme)
+	return batchJob{source: name, load: func(ctx context.Context) (*puzzle.Puzzle, error) { return src.Fetch(ctx, id) }}
+}
+
+func fileJob(e *env, path string) batchJob {
+	job := sourceJob(e, path, "file", path)
+	job.file = true
+	return job
+}
+
+// solveBatch solves the jobs with a bounded pool and returns records in job
+// order. All puzzles share one semaphore for line work, sized by --workers.
+func solveBatch(ctx context.Context, e *env, batch []batchJob, jobs int, sf *solverFlags) []batchRecord {
+	records := make([]batchRecord, len(batch))
+	sem := combinatorics.NewSemaphore(sf.workers)
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				records[i] = solveJob(ctx, e, batch[i], sf, sem)
+				if sf.verbose {
+					fmt.Fprintf(e.stderr, "%s: %s\n", records[i].Source, records[i].Status)
+				}
+			}
+		}()
+	}
+	for i := range batch {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return records
+}
+
+func solveJob(ctx context.Context, e *env, job batchJob, sf *solverFlags, sem *combinatorics.Semaphore) batchRecord {
+	record := batchRecord{Source: job.source}
+	fail := func(err error) batchRecord {
+		record.Status = "error"
+		record.Error = err.Error()
+		return record
+	}
+
+	p, err := job.load(ctx)
+	if err != nil {
+		return fail(err)
+	}
+	record.Width, record.Height, record.Colors = p.Width, p.Height, len(p.Colors)
+	if p.Meta != nil {
+		record.Title, record.URL = p.Meta.Title, p.Meta.URL
+	}
+
+	ctx, cancel := sf.context(ctx)
+	defer cancel()
+	opts, providers := sf.options(e)
+	// Batch progress is reported per puzzle, not per pass
+	opts.Logf = nil
+	opts.Semaphore, providers.Semaphore = sem, sem
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	g := p.Grid(providers)
+	result, err := solver.Solve(ctx, &g, opts)
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+	if err != nil {
+		return fail(err)
+	}
+
+	record.Status = result.Status.String()
+	record.WallMS = float64(elapsed.Microseconds()) / 1000
+	record.AllocMB = float64(after.TotalAlloc-before.TotalAlloc) / 1024 / 1024
+	record.Passes = result.Passes
+	record.Guesses = result.Guesses
+	if result.Reason != nil {
+		record.Error = result.Reason.Error()
+	}
+	return record
+}
+
+func writeBatch(w io.Writer, records []batchRecord, format string) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(batchColumns); err != nil {
+		return err
+	}
+	for _, record := range records {
+		if err := cw.Write(record.csvRow()); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// batchExitCode reports the worst outcome: any error, then any timeout, then any
+// puzzle left unsolved
+func batchExitCode(records []batchRecord) int {
+	rank := map[int]int{ExitOK: 0, ExitUnsolvable: 1, ExitTimeout: 2, ExitError: 3}
+	code := ExitOK
+	for _, record := range records {
+		var c int
+		switch record.Status {
+		case "error":
+			c = ExitError
+		case solver.Solved.String():
+			c = ExitOK
+		case solver.Timeout.String():
+			c = ExitTimeout
+		default:
+			c = ExitUnsolvable
+		}
+		if rank[c] > rank[code] {
+			code = c
+		}
+	}
+	return code
+}
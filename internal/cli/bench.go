@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"nonogram-solver/internal/bench"
+	"nonogram-solver/internal/solver"
+)
+
+func runBench(ctx context.Context, e *env, args []string) int {
+	fs := newFlagSet(e, "bench", "[<id>|<file>|<dir>]...")
+	var sf solverFlags
+	var ff fetchFlags
+	runs := fs.Int("runs", 5, "timed solves per puzzle")
+	corpus := fs.String("corpus", bench.DefaultCorpus, "puzzle directory used when no puzzles are given")
+	save := fs.String("save", "", "write the results as a JSON baseline to this file")
+	baseline := fs.String("baseline", "", "compare against a JSON baseline written by --save")
+	threshold := fs.Float64("threshold", 10, "percent increase in time or allocations that counts as a regression")
+	sf.register(fs)
+	ff.register(fs)
+	positional, code, ok := parseFlags(fs, args)
+	if !ok {
+		return code
+	}
+	if *runs <= 0 {
+		return e.errorf("--runs must be positive")
+	}
+
+	ff.apply(e)
+	entries, err := benchEntries(ctx, e, *corpus, positional)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	var base *bench.Baseline
+	if *baseline != "" {
+		if base, err = bench.LoadBaseline(*baseline); err != nil {
+			return e.errorf("%v", err)
+		}
+	}
+
+	ctx, cancel := sf.context(ctx)
+	defer cancel()
+
+	samples := make([]bench.Sample, 0, len(entries))
+	code = ExitOK
+	for _, entry := range entries {
+		opts, providers := sf.options(e)
+		sample, err := bench.Measure(ctx, entry.Name, entry.Puzzle, *runs, opts, providers)
+		if err != nil {
+			return e.errorf("%s: %v", entry.Name, err)
+		}
+		samples = append(samples, sample)
+		if sf.verbose {
+			fmt.Fprintf(e.stderr, "%s: %s\n", entry.Name, sample.Status)
+		}
+		if sample.Status == solver.Timeout.String() {
+			code = ExitTimeout
+			break
+		}
+	}
+
+	current := bench.NewBaseline(samples)
+	if *save != "" {
+		if err := current.Save(*save); err != nil {
+			return e.errorf("%v", err)
+		}
+	}
+
+	if base == nil {
+		writeSamples(e.stdout, samples)
+		return code
+	}
+	deltas := bench.Compare(base, current, *threshold)
+	if writeDeltas(e.stdout, deltas) && code == ExitOK {
+		code = ExitRegression
+	}
+	return code
+}
+
+// benchEntries loads the given puzzles, or the corpus when none are given
+func benchEntries(ctx context.Context, e *env, corpus string, sources []string) ([]bench.Entry, error) {
+	if len(sources) == 0 {
+		return bench.LoadCorpus(corpus)
+	}
+	jobs, err := expandSources(e, sources)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]bench.Entry, 0, len(jobs))
+	for _, job := range jobs {
+		p, err := job.load(ctx)
+		if err != nil {
+			return nil, err
+		}
+		name := job.source
+		if job.file {
+			name = strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+		}
+		entries = append(entries, bench.Entry{Name: name, Puzzle: p})
+	}
+	return entries, nil
+}
+
+func writeSamples(w io.Writer, samples []bench.Sample) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "puzzle\truns\ttime/op\tallocs/op\tMB/op\tstatus\t")
+	for _, s := range samples {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%d\t%.2f\t%s\t\n",
+			s.Name, s.Runs, formatNs(s.NsPerOp), s.AllocsPerOp, float64(s.BytesPerOp)/1024/1024, s.Status)
+	}
+	tw.Flush()
+}
+
+// writeDeltas prints the comparison and reports whether anything regressed
+func writeDeltas(w io.Writer, deltas []bench.Delta) bool {
+	regressed := false
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "puzzle\told time/op\tnew time/op\tdelta\told allocs/op\tnew allocs/op\tdelta\tnote\t")
+	for _, d := range deltas {
+		oldTime, newTime, oldAllocs, newAllocs := "-", "-", "-", "-"
+		timePct, allocsPct := "", ""
+		if d.Base != nil {
+			oldTime, oldAllocs = formatNs(d.Base.NsPerOp), fmt.Sprint(d.Base.AllocsPerOp)
+		}
+		if d.Current != nil {
+			newTime, newAllocs = formatNs(d.Current.NsPerOp), fmt.Sprint(d.Current.AllocsPerOp)
+		}
+		if d.Base != nil && d.Current != nil {
+			timePct, allocsPct = fmt.Sprintf("%+.1f%%", d.TimePct), fmt.Sprintf("%+.1f%%", d.AllocsPct)
+		}
+		note := d.Note
+		if d.Regressed {
+			regressed = true
+			note = "REGRESSION " + note
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n", d.Name, oldTime, newTime, timePct, oldAllocs, newAllocs, allocsPct, note)
+	}
+	tw.Flush()
+	return regressed
+}
+
+func formatNs(ns int64) string {
+	switch {
+	case ns >= 1e9:
+		return fmt.Sprintf("%.2fs", float64(ns)/1e9)
+	case ns >= 1e6:
+		return fmt.Sprintf("%.2fms", float64(ns)/1e6)
+	default:
+		return fmt.Sprintf("%.1fµs", float64(ns)/1e3)
+	}
+}
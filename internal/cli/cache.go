@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+)
+
+func runCache(ctx context.Context, e *env, args []string) int {
+	if len(args) == 0 || args[0] != "prune" {
+		fmt.Fprintln(e.stderr, "usage: nonogram-solver cache prune [flags]")
+		return ExitError
+	}
+	fs := newFlagSet(e, "cache prune", "")
+	var cf cacheFlags
+	cf.register(fs)
+	all := fs.Bool("all", false, "remove every entry, not only expired ones")
+	positional, code, ok := parseFlags(fs, args[1:])
+	if !ok {
+		return code
+	}
+	if len(positional) > 0 {
+		fs.Usage()
+		return ExitError
+	}
+
+	c, err := cf.open()
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	removed, err := c.Prune(*all)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	fmt.Fprintf(e.stdout, "removed %d cached file(s) from %s\n", removed, c.Dir)
+	return ExitOK
+}
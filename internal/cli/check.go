@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"nonogram-solver/internal/solver"
+)
+
+func runCheck(ctx context.Context, e *env, args []string) int {
+	fs := newFlagSet(e, "check", "[<id>|<file>|-]")
+	var in inputFlags
+	var sf solverFlags
+	in.register(fs)
+	sf.register(fs)
+	positional, code, ok := parseFlags(fs, args)
+	if !ok {
+		return code
+	}
+
+	p, err := in.load(ctx, e, positional)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+
+	ctx, cancel := sf.context(ctx)
+	defer cancel()
+	opts, providers := sf.options(e)
+	g := p.Grid(providers)
+
+	count, result, err := solver.CountSolutions(ctx, &g, 2, opts)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	switch {
+	case result.Status == solver.Timeout:
+		fmt.Fprintln(e.stdout, "timeout")
+		return ExitTimeout
+	case count == 1:
+		fmt.Fprintln(e.stdout, "unique")
+		return ExitOK
+	case count > 1:
+		fmt.Fprintln(e.stdout, "multiple")
+		return ExitUnsolvable
+	default:
+		fmt.Fprintln(e.stdout, "none")
+		if result.Reason != nil {
+			fmt.Fprintf(e.stderr, "%v\n", result.Reason)
+		}
+		return ExitUnsolvable
+	}
+}
@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+
+	"nonogram-solver/internal/source"
+)
+
+// Exit codes shared by every subcommand
+const (
+	ExitOK         = 0 // solved, or the command succeeded
+	ExitError      = 1 // bad input, I/O or network failure
+	ExitUnsolvable = 2 // contradiction, stalled, or not uniquely solvable
+	ExitTimeout    = 3 // the --timeout expired
+	ExitRegression = 4 // bench found a regression against its baseline
+)
+
+// env carries the process streams so commands can be run from tests
+type env struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	// sources resolves "scheme:id" puzzle URIs
+	sources *source.Registry
+}
+
+func (e *env) errorf(format string, args ...any) int {
+	fmt.Fprintf(e.stderr, "error: "+format+"\n", args...)
+	return ExitError
+}
+
+type command struct {
+	summary string
+	run     func(ctx context.Context, e *env, args []string) int
+}
+
+var commands = map[string]command{
+	"solve":    {"solve a puzzle and print the result", runSolve},
+	"fetch":    {"download a puzzle from nonograms.org or another source", runFetch},
+	"convert":  {"convert a puzzle between formats", runConvert},
+	"check":    {"check whether a puzzle has a unique solution", runCheck},
+	"cache":    {"prune the download cache", runCache},
+	"bench":    {"time the solver over a corpus and compare against a baseline", runBench},
+	"batch":    {"solve many puzzles and write a CSV or JSON summary", runBatch},
+	"serve":    {"serve the solver over HTTP", runServe},
+	"explain":  {"log why each cell of a puzzle was deduced", runExplain},
+	"generate": {"generate a random puzzle with a unique solution", runGenerate},
+	"mirror":   {"download a range of nonograms.org puzzles into a local corpus", runMirror},
+	"play":     {"play a puzzle in the terminal with solver hints", runPlay},
+	"rate":     {"rate a puzzle by the weakest techniques that solve it", runRate},
+	"render":   {"draw a puzzle's solution or solver state", runRender},
+}
+
+var numericID = regexp.MustCompile(`^[0-9]+$`)
+
+// Run executes the command line and returns the process exit code
+func Run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	e := &env{stdin: stdin, stdout: stdout, stderr: stderr, sources: source.Default()}
+	e.sources.Register("file", source.File{Stdin: stdin})
+	if len(args) == 0 {
+		usage(stderr)
+		return ExitError
+	}
+
+	name, rest := args[0], args[1:]
+	if name == "help" || name == "-h" || name == "--help" {
+		usage(stdout)
+		return ExitOK
+	}
+	// A bare ID keeps the original "nonogram-solver <id>" invocation working
+	if numericID.MatchString(name) {
+		name, rest = "solve", args
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n\n", name)
+		usage(stderr)
+		return ExitError
+	}
+	return cmd.run(ctx, e, rest)
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: nonogram-solver <command> [flags] [<id>|<scheme>:<id>|<file>|-]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-8s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "puzzle URIs: nonograms.org:<id>, webpbn:<id>, file:<path>")
+	fmt.Fprintln(w, "exit codes: 0 solved, 1 error, 2 unsolvable/contradiction, 3 timeout, 4 bench regression")
+	fmt.Fprintln(w, "run 'nonogram-solver <command> -h' for the command's flags")
+}
+
+// newFlagSet creates a subcommand flag set that reports errors on stderr
+func newFlagSet(e *env, name, positional string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(e.stderr, "usage: nonogram-solver %s [flags] %s\n", name, positional)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses flags that may appear before or after positional arguments.
+// ok is false when parsing failed or help was requested; code is then the exit code.
+func parseFlags(fs *flag.FlagSet, args []string) (positional []string, code int, ok bool) {
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, ExitOK, false
+			}
+			return nil, ExitError, false
+		}
+		if fs.NArg() == 0 {
+			return positional, ExitOK, true
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"context"
+
+	"nonogram-solver/internal/puzzle"
+)
+
+func runFetch(ctx context.Context, e *env, args []string) int {
+	fs := newFlagSet(e, "fetch", "<id>|<scheme>:<id>")
+	var out outputFlags
+	var ff fetchFlags
+	out.register(fs, "json", "json or text")
+	ff.register(fs)
+	positional, code, ok := parseFlags(fs, args)
+	if !ok {
+		return code
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return ExitError
+	}
+	ff.apply(e)
+	scheme, id, _ := splitSource(e, positional[0])
+	if scheme == "file" {
+		fs.Usage()
+		return ExitError
+	}
+
+	src, _ := e.sources.Lookup(scheme)
+	p, err := src.Fetch(ctx, id)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	return writePuzzle(e, p, &out)
+}
+
+func runConvert(ctx context.Context, e *env, args []string) int {
+	fs := newFlagSet(e, "convert", "[<id>|<file>|-]")
+	var in inputFlags
+	var out outputFlags
+	in.register(fs)
+	out.register(fs, "", "json or text (default: from --out extension, text for stdout)")
+	positional, code, ok := parseFlags(fs, args)
+	if !ok {
+		return code
+	}
+
+	p, err := in.load(ctx, e, positional)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	if out.format == "" {
+		out.format = string(puzzle.FormatText)
+		if out.out != "" && out.out != "-" {
+			out.format = string(puzzle.DetectFormat(out.out))
+		}
+	}
+	return writePuzzle(e, p, &out)
+}
+
+func writePuzzle(e *env, p *puzzle.Puzzle, out *outputFlags) int {
+	format, err := puzzle.ParseFormat(out.format)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	w, closeOut, err := out.open(e)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	if err := puzzle.Write(w, p, format); err != nil {
+		closeOut()
+		return e.errorf("%v", err)
+	}
+	if err := closeOut(); err != nil {
+		return e.errorf("%v", err)
+	}
+	return ExitOK
+}
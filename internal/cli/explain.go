@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"nonogram-solver/internal/explain"
+	"nonogram-solver/internal/solver"
+)
+
+func runExplain(ctx context.Context, e *env, args []string) int {
+	fs := newFlagSet(e, "explain", "[<id>|<file>|-]")
+	var in inputFlags
+	var out outputFlags
+	var sf solverFlags
+	in.register(fs)
+	out.register(fs, "text", "text or json")
+	sf.register(fs)
+	positional, code, ok := parseFlags(fs, args)
+	if !ok {
+		return code
+	}
+	if out.format != "text" && out.format != "json" {
+		return e.errorf("unknown output format %q (want text or json)", out.format)
+	}
+
+	p, err := in.load(ctx, e, positional)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+
+	ctx, cancel := sf.context(ctx)
+	defer cancel()
+	opts, providers := sf.options(e)
+	recorder := explain.NewRecorder(p)
+	opts.Events = recorder.Event
+	g := p.Grid(providers)
+	result, err := solver.Solve(ctx, &g, opts)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+
+	w, closeOut, err := out.open(e)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	if out.format == "json" {
+		err = explain.WriteJSON(w, recorder.Entries())
+	} else {
+		err = explain.Write(w, p, recorder.Entries())
+	}
+	if err != nil {
+		closeOut()
+		return e.errorf("%v", err)
+	}
+	if err := closeOut(); err != nil {
+		return e.errorf("%v", err)
+	}
+
+	if result.Status != solver.Solved {
+		fmt.Fprintf(e.stderr, "%s", result.Status)
+		if result.Reason != nil {
+			fmt.Fprintf(e.stderr, ": %v", result.Reason)
+		}
+		fmt.Fprintln(e.stderr)
+	}
+	return exitCode(result.Status)
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"nonogram-solver/internal/generate"
+	"nonogram-solver/internal/solver"
+)
+
+func runGenerate(ctx context.Context, e *env, args []string) int {
+	fs := newFlagSet(e, "generate", "")
+	var out outputFlags
+	var sf solverFlags
+	var opts generate.Options
+	var technique string
+	fs.IntVar(&opts.Width, "width", 10, "puzzle width")
+	fs.IntVar(&opts.Height, "height", 10, "puzzle height")
+	fs.IntVar(&opts.Colors, "colors", 1, fmt.Sprintf("number of colors, 1 to %d", len(generate.Palette)))
+	fs.Float64Var(&opts.Density, "density", 0.55, "fraction of cells filled at the start")
+	fs.Int64Var(&opts.Seed, "seed", 0, "random seed (default: from the clock, printed to stderr)")
+	fs.StringVar(&technique, "technique", "line", "hardest technique a solver may need: overlap, line, cross-reference, probe or backtrack")
+	fs.IntVar(&opts.MaxFlips, "max-flips", 0, "cells to change before giving up (default: four per cell)")
+	out.register(fs, "text", "text or json")
+	sf.register(fs)
+	positional, code, ok := parseFlags(fs, args)
+	if !ok {
+		return code
+	}
+	if len(positional) != 0 {
+		fs.Usage()
+		return ExitError
+	}
+
+	var err error
+	if opts.Technique, err = solver.ParseTechnique(technique); err != nil {
+		return e.errorf("%v", err)
+	}
+	seeded := false
+	fs.Visit(func(f *flag.Flag) { seeded = seeded || f.Name == "seed" })
+	if !seeded {
+		opts.Seed = time.Now().UnixNano()
+		fmt.Fprintf(e.stderr, "seed %d\n", opts.Seed)
+	}
+
+	ctx, cancel := sf.context(ctx)
+	defer cancel()
+	opts.Solver, opts.Providers = sf.options(e)
+	result, err := generate.Generate(ctx, opts)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return e.errorf("timed out generating a puzzle")
+	case errors.Is(err, generate.ErrGaveUp):
+		fmt.Fprintf(e.stderr, "%v; try another seed or a lower density\n", err)
+		return ExitUnsolvable
+	case err != nil:
+		return e.errorf("%v", err)
+	}
+	if sf.stats {
+		fmt.Fprintf(e.stderr, "%d flips, technique %s, %d passes\n", result.Flips, result.Rating.Technique, result.Rating.Passes)
+	}
+	return writePuzzle(e, result.Puzzle, &out)
+}
@@ -0,0 +1,183 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"nonogram-solver/internal/cache"
+	network "nonogram-solver/internal/network"
+	"nonogram-solver/internal/puzzle"
+	"nonogram-solver/internal/source"
+)
+
+// inputFlags selects where a puzzle comes from: a nonograms.org ID, a puzzle
+// URI, a file, or stdin ("-")
+type inputFlags struct {
+	id     string
+	file   string
+	format string
+	net    fetchFlags
+}
+
+func (f *inputFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.id, "id", "", "nonograms.org puzzle ID")
+	fs.StringVar(&f.file, "file", "", "puzzle file, or - for stdin")
+	fs.StringVar(&f.format, "in-format", "", "input format: json or text (default: from file extension, json for stdin)")
+	f.net.register(fs)
+}
+
+// load reads the puzzle named by the flags or by a single positional argument:
+// digits are an ID, "scheme:id" is a puzzle URI, "-" is stdin, anything else is
+// a file path.
+func (f *inputFlags) load(ctx context.Context, e *env, positional []string) (*puzzle.Puzzle, error) {
+	f.net.apply(e)
+	id, file := f.id, f.file
+	switch {
+	case len(positional) > 1:
+		return nil, fmt.Errorf("expected one puzzle source, got %d arguments", len(positional))
+	case len(positional) == 1 && (id != "" || file != ""):
+		return nil, fmt.Errorf("puzzle given both as argument and as --id/--file")
+	case len(positional) == 1:
+		return f.fetch(ctx, e, positional[0])
+	case id != "" && file != "":
+		return nil, fmt.Errorf("--id and --file are mutually exclusive")
+	case id != "":
+		return f.fetch(ctx, e, "nonograms.org:"+id)
+	case file != "":
+		return f.fetch(ctx, e, "file:"+file)
+	}
+	return nil, fmt.Errorf("no puzzle given: pass an ID, a URI, a file or - for stdin")
+}
+
+// fetch loads one puzzle argument through the source registry. Files honor
+// --in-format.
+func (f *inputFlags) fetch(ctx context.Context, e *env, arg string) (*puzzle.Puzzle, error) {
+	scheme, id, _ := splitSource(e, arg)
+	if scheme != "file" {
+		src, _ := e.sources.Lookup(scheme)
+		return src.Fetch(ctx, id)
+	}
+	var format puzzle.Format
+	if f.format != "" {
+		parsed, err := puzzle.ParseFormat(f.format)
+		if err != nil {
+			return nil, err
+		}
+		format = parsed
+	}
+	return source.File{Format: format, Stdin: e.stdin}.Fetch(ctx, id)
+}
+
+// splitSource resolves a puzzle argument to a registered scheme and ID: digits
+// are nonograms.org IDs and anything that is not a URI is a file. ok reports
+// whether arg was a URI.
+func splitSource(e *env, arg string) (scheme, id string, ok bool) {
+	if numericID.MatchString(arg) {
+		return "nonograms.org", arg, false
+	}
+	if scheme, id, ok := e.sources.Split(arg); ok {
+		return scheme, id, true
+	}
+	return "file", arg, false
+}
+
+// fetchFlags tune downloads from nonograms.org and the local cache of them
+type fetchFlags struct {
+	timeout   time.Duration
+	retries   int
+	userAgent string
+	cache     cacheFlags
+	noCache   bool
+	refresh   bool
+	strict    bool
+}
+
+func (f *fetchFlags) register(fs *flag.FlagSet) {
+	f.registerNetwork(fs)
+	f.cache.register(fs)
+	fs.BoolVar(&f.noCache, "no-cache", false, "neither read nor write the download cache")
+	fs.BoolVar(&f.refresh, "refresh", false, "download again even when cached, updating the cache")
+}
+
+// registerNetwork registers the flags that shape requests, without the cache
+func (f *fetchFlags) registerNetwork(fs *flag.FlagSet) {
+	fs.DurationVar(&f.timeout, "fetch-timeout", 10*time.Second, "timeout for each download attempt")
+	fs.IntVar(&f.retries, "retries", 2, "retries after transient download failures (5xx responses, timeouts)")
+	fs.StringVar(&f.userAgent, "user-agent", network.DefaultUserAgent, "User-Agent header for downloads")
+	fs.BoolVar(&f.strict, "strict-decode", false, "reject pages whose puzzle data is malformed instead of skipping bad parts")
+}
+
+func (f *fetchFlags) options() network.FetchOptions {
+	return network.FetchOptions{
+		Timeout:   f.timeout,
+		Retries:   f.retries,
+		UserAgent: f.userAgent,
+		Strict:    f.strict,
+	}
+}
+
+// source returns a nonograms.org source configured by the flags. Without a
+// usable cache directory downloads are simply not cached.
+func (f *fetchFlags) source() network.Source {
+	src := network.Source{Options: f.options(), Refresh: f.refresh}
+	if !f.noCache {
+		src.Cache, _ = f.cache.open()
+	}
+	return src
+}
+
+// apply registers the flags' nonograms.org source and a webpbn source sharing
+// their request options
+func (f *fetchFlags) apply(e *env) {
+	e.sources.Register("nonograms.org", f.source())
+	e.sources.Register("webpbn", source.WebPBN{Options: f.options()})
+}
+
+// cacheFlags locate the download cache
+type cacheFlags struct {
+	dir string
+	ttl time.Duration
+}
+
+func (f *cacheFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.dir, "cache-dir", "", "download cache directory (default: the user cache directory)")
+	fs.DurationVar(&f.ttl, "cache-ttl", cache.DefaultTTL, "how long cached downloads stay fresh (0: forever)")
+}
+
+func (f *cacheFlags) open() (*cache.Cache, error) {
+	dir := f.dir
+	if dir == "" {
+		var err error
+		if dir, err = cache.DefaultDir(); err != nil {
+			return nil, err
+		}
+	}
+	return cache.New(dir, f.ttl), nil
+}
+
+// outputFlags selects where results are written
+type outputFlags struct {
+	out    string
+	format string
+}
+
+func (f *outputFlags) register(fs *flag.FlagSet, defaultFormat, formats string) {
+	fs.StringVar(&f.out, "out", "", "output file (default: stdout)")
+	fs.StringVar(&f.format, "format", defaultFormat, "output format: "+formats)
+}
+
+// open returns the output writer and a function that must be called to close it
+func (f *outputFlags) open(e *env) (io.Writer, func() error, error) {
+	if f.out == "" || f.out == "-" {
+		return e.stdout, func() error { return nil }, nil
+	}
+	fh, err := os.Create(f.out)
+	if err != nil {
+		return nil, nil, err
+	}
+	return fh, fh.Close, nil
+}
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"nonogram-solver/internal/mirror"
+	"nonogram-solver/internal/puzzle"
+)
+
+func runMirror(ctx context.Context, e *env, args []string) int {
+	fs := newFlagSet(e, "mirror", "")
+	var ff fetchFlags
+	from := fs.Int("from", 0, "first puzzle ID")
+	to := fs.Int("to", 0, "last puzzle ID (inclusive)")
+	dir := fs.String("out", "", "directory for the puzzles, the manifest and the failures")
+	format := fs.String("format", "text", "puzzle file format: text or json")
+	workers := fs.Int("workers", 4, "concurrent downloads")
+	rate := fs.Float64("rate", 2, "downloads per second across all workers (0: unlimited)")
+	burst := fs.Int("burst", 4, "downloads allowed at once before --rate applies")
+	skipFailed := fs.Bool("skip-failed", false, "do not retry IDs that failed in an earlier run")
+	verbose := fs.Bool("v", false, "print each ID's outcome to stderr")
+	ff.registerNetwork(fs)
+	positional, code, ok := parseFlags(fs, args)
+	if !ok {
+		return code
+	}
+	if len(positional) > 0 || *dir == "" || *from <= 0 || *to < *from {
+		fs.Usage()
+		return ExitError
+	}
+	if *to-*from >= maxRangeSize {
+		return e.errorf("invalid ID range %d-%d", *from, *to)
+	}
+	parsed, err := puzzle.ParseFormat(*format)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+
+	opts := mirror.Options{
+		From:       *from,
+		To:         *to,
+		Dir:        *dir,
+		Format:     parsed,
+		Workers:    *workers,
+		Rate:       *rate,
+		Burst:      *burst,
+		SkipFailed: *skipFailed,
+		Network:    ff.options(),
+	}
+	if *verbose {
+		opts.Progress = func(r mirror.Record) {
+			if r.Error != "" {
+				fmt.Fprintf(e.stderr, "%s: %s\n", r.ID, r.Error)
+			} else {
+				fmt.Fprintf(e.stderr, "%s: %dx%d, %d color(s) from %s\n", r.ID, r.Width, r.Height, r.Colors, r.URL)
+			}
+		}
+	}
+
+	summary, err := mirror.Run(ctx, opts)
+	fmt.Fprintf(e.stdout, "mirrored %d, skipped %d, failed %d", summary.Mirrored, summary.Skipped, summary.Failed)
+	if summary.Failed > 0 {
+		fmt.Fprintf(e.stdout, " (see %s)", filepath.Join(*dir, mirror.FailuresFile))
+	}
+	fmt.Fprintln(e.stdout)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return ExitTimeout
+	case err != nil:
+		return e.errorf("%v", err)
+	}
+	return ExitOK
+}
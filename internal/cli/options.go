@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"runtime"
+	"time"
+
+	"nonogram-solver/internal/combinatorics"
+	"nonogram-solver/internal/factory"
+	"nonogram-solver/internal/solver"
+)
+
+// solverFlags are the options shared by commands that run the solver
+type solverFlags struct {
+	workers  int
+	timeout  time.Duration
+	verbose  bool
+	stats    bool
+	noSearch bool
+}
+
+func (f *solverFlags) register(fs *flag.FlagSet) {
+	fs.IntVar(&f.workers, "workers", runtime.GOMAXPROCS(0), "number of lines solved in parallel")
+	fs.DurationVar(&f.timeout, "timeout", 0, "give up after this long, e.g. 30s (0 = no limit)")
+	fs.BoolVar(&f.verbose, "v", false, "log solver progress to stderr")
+	fs.BoolVar(&f.stats, "stats", false, "print timing and memory statistics to stderr")
+	fs.BoolVar(&f.noSearch, "no-search", false, "stop when line propagation stalls instead of backtracking")
+}
+
+// context applies the timeout, if any
+func (f *solverFlags) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if f.timeout > 0 {
+		return context.WithTimeout(ctx, f.timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// options builds solver options and the matching provider options; both share
+// one semaphore so line workers and nested generation stay within --workers.
+func (f *solverFlags) options(e *env) (solver.Options, factory.ProviderOptions) {
+	sem := combinatorics.NewSemaphore(f.workers)
+	opts := solver.Options{
+		Semaphore:       sem,
+		Search:          !f.noSearch,
+		MaxCombinations: solver.DefaultMaxCombinations,
+	}
+	if f.verbose {
+		opts.Logf = func(format string, args ...any) {
+			fmt.Fprintf(e.stderr, format+"\n", args...)
+		}
+	}
+	return opts, factory.ProviderOptions{Semaphore: sem, MaxCombinations: solver.DefaultMaxCombinations}
+}
+
+// exitCode maps a solve status to the process exit code
+func exitCode(status solver.Status) int {
+	switch status {
+	case solver.Solved:
+		return ExitOK
+	case solver.Timeout:
+		return ExitTimeout
+	default:
+		return ExitUnsolvable
+	}
+}
+
+// memStats measures allocation between two points
+type memStats struct {
+	before runtime.MemStats
+}
+
+func startMemStats() *memStats {
+	m := &memStats{}
+	runtime.ReadMemStats(&m.before)
+	return m
+}
+
+// report returns the live heap growth and the total allocated bytes since start, in MB
+func (m *memStats) report() (allocated, total float64) {
+	runtime.GC()
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+	return float64(int64(after.Alloc)-int64(m.before.Alloc)) / 1024 / 1024,
+		float64(after.TotalAlloc-m.before.TotalAlloc) / 1024 / 1024
+}
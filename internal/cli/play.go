@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"context"
+	"os"
+
+	"nonogram-solver/internal/play"
+)
+
+func runPlay(ctx context.Context, e *env, args []string) int {
+	fs := newFlagSet(e, "play", "<id>|<file>")
+	var in inputFlags
+	var sf solverFlags
+	in.register(fs)
+	sf.register(fs)
+	positional, code, ok := parseFlags(fs, args)
+	if !ok {
+		return code
+	}
+
+	// The terminal is stdin, so the puzzle cannot come from there
+	if in.file == "-" || (len(positional) == 1 && positional[0] == "-") {
+		return e.errorf("play reads keys from stdin; pass an ID or a file")
+	}
+	terminal, ok := e.stdin.(*os.File)
+	if !ok {
+		return e.errorf("play needs a terminal")
+	}
+	if info, err := terminal.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return e.errorf("play needs a terminal")
+	}
+
+	p, err := in.load(ctx, e, positional)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	solveCtx, cancel := sf.context(ctx)
+	opts, providers := sf.options(e)
+	facts, err := play.SolverFacts(solveCtx, p, opts, providers)
+	cancel()
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+
+	if err := play.Run(ctx, terminal, e.stdout, play.NewGame(p, facts)); err != nil {
+		return e.errorf("%v", err)
+	}
+	return ExitOK
+}
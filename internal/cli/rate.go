@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"nonogram-solver/internal/solver"
+)
+
+// ratingJSON is the JSON form of a solver.Rating
+type ratingJSON struct {
+	ID        string `json:"id,omitempty"`
+	Technique string `json:"technique"`
+	Status    string `json:"status"`
+	Reason    string `json:"reason,omitempty"`
+	Passes    int    `json:"passes"`
+	Probes    int    `json:"probes"`
+	Depth     int    `json:"depth"`
+	Guesses   int    `json:"guesses"`
+	Unique    bool   `json:"unique"`
+}
+
+func runRate(ctx context.Context, e *env, args []string) int {
+	fs := newFlagSet(e, "rate", "[<id>|<file>|-]")
+	var in inputFlags
+	var out outputFlags
+	var sf solverFlags
+	in.register(fs)
+	out.register(fs, "text", "text or json")
+	sf.register(fs)
+	positional, code, ok := parseFlags(fs, args)
+	if !ok {
+		return code
+	}
+	if out.format != "text" && out.format != "json" {
+		return e.errorf("unknown output format %q (want text or json)", out.format)
+	}
+
+	p, err := in.load(ctx, e, positional)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+
+	ctx, cancel := sf.context(ctx)
+	defer cancel()
+	opts, providers := sf.options(e)
+	g := p.Grid(providers)
+	rating, err := solver.Rate(ctx, &g, opts)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+
+	w, closeOut, err := out.open(e)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	if err := writeRating(w, p.ID, rating, out.format); err != nil {
+		closeOut()
+		return e.errorf("%v", err)
+	}
+	if err := closeOut(); err != nil {
+		return e.errorf("%v", err)
+	}
+	return exitCode(rating.Status)
+}
+
+func writeRating(w io.Writer, id string, rating solver.Rating, format string) error {
+	record := ratingJSON{
+		ID:        id,
+		Technique: rating.Technique.String(),
+		Status:    rating.Status.String(),
+		Passes:    rating.Passes,
+		Probes:    rating.Probes,
+		Depth:     rating.Depth,
+		Guesses:   rating.Guesses,
+		Unique:    rating.Unique,
+	}
+	if rating.Reason != nil {
+		record.Reason = rating.Reason.Error()
+	}
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(record)
+	}
+
+	fmt.Fprintf(w, "technique: %s\n", record.Technique)
+	fmt.Fprintf(w, "status:    %s\n", record.Status)
+	if record.Reason != "" {
+		fmt.Fprintf(w, "reason:    %s\n", record.Reason)
+	}
+	fmt.Fprintf(w, "passes:    %d\n", record.Passes)
+	fmt.Fprintf(w, "probes:    %d\n", record.Probes)
+	fmt.Fprintf(w, "depth:     %d\n", record.Depth)
+	fmt.Fprintf(w, "guesses:   %d\n", record.Guesses)
+	_, err := fmt.Fprintf(w, "unique:    %v\n", record.Unique)
+	return err
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"context"
+
+	"nonogram-solver/internal/puzzle"
+	"nonogram-solver/internal/render"
+)
+
+func runRender(ctx context.Context, e *env, args []string) int {
+	fs := newFlagSet(e, "render", "[<id>|<file>|-]")
+	var in inputFlags
+	var out outputFlags
+	var sf solverFlags
+	clues := fs.Bool("clues", false, "print row clues beside the grid and column clues below it")
+	noSolve := fs.Bool("no-solve", false, "draw unknown cells instead of solving a puzzle that has no stored solution")
+	in.register(fs)
+	out.register(fs, "ansi", "text or ansi")
+	sf.register(fs)
+	positional, code, ok := parseFlags(fs, args)
+	if !ok {
+		return code
+	}
+
+	style, err := render.ParseStyle(out.format)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	p, err := in.load(ctx, e, positional)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+
+	// Prefer the stored solution; otherwise draw whatever the solver deduces
+	cells, code := p.Solution, ExitOK
+	switch {
+	case cells != nil:
+	case *noSolve:
+		cells = make([][]int, p.Height)
+		for r := range cells {
+			cells[r] = make([]int, p.Width)
+			for c := range cells[r] {
+				cells[r][c] = -1
+			}
+		}
+	default:
+		g, _, solveCode := solvePuzzle(ctx, e, p, &sf)
+		if solveCode == ExitError {
+			return solveCode
+		}
+		cells, _ = puzzle.Cells(g)
+		code = solveCode
+	}
+
+	w, closeOut, err := out.open(e)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	if err := render.Cells(w, p, cells, render.Options{Style: style, Clues: *clues}); err != nil {
+		closeOut()
+		return e.errorf("%v", err)
+	}
+	if err := closeOut(); err != nil {
+		return e.errorf("%v", err)
+	}
+	return code
+}
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+	"time"
+
+	"nonogram-solver/internal/server"
+)
+
+func runServe(ctx context.Context, e *env, args []string) int {
+	fs := newFlagSet(e, "serve", "")
+	addr := fs.String("addr", ":8080", "listen address")
+	timeout := fs.Duration("timeout", server.DefaultTimeout, "longest time a request may run")
+	maxConcurrent := fs.Int("max-concurrent", runtime.GOMAXPROCS(0), "requests solved at once; others wait")
+	workers := fs.Int("workers", runtime.GOMAXPROCS(0), "lines solved in parallel across all requests")
+	maxJobs := fs.Int("max-jobs", server.DefaultMaxJobs, "asynchronous jobs kept at once, running or finished")
+	jobTTL := fs.Duration("job-ttl", server.DefaultJobTTL, "how long finished jobs and their events are kept")
+	noSearch := fs.Bool("no-search", false, "stop /solve when line propagation stalls instead of backtracking")
+	verbose := fs.Bool("v", false, "log requests to stderr")
+	var ff fetchFlags
+	ff.register(fs)
+	positional, code, ok := parseFlags(fs, args)
+	if !ok {
+		return code
+	}
+	if len(positional) > 0 {
+		fs.Usage()
+		return ExitError
+	}
+
+	opts := server.Options{
+		Timeout:       *timeout,
+		MaxConcurrent: *maxConcurrent,
+		Workers:       *workers,
+		MaxJobs:       *maxJobs,
+		JobTTL:        *jobTTL,
+		Search:        !*noSearch,
+		Fetch:         ff.source().Fetch,
+	}
+	if *verbose {
+		opts.Logf = func(format string, args ...any) {
+			fmt.Fprintf(e.stderr, format+"\n", args...)
+		}
+	}
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           server.New(opts).Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	fmt.Fprintf(e.stderr, "listening on %s\n", *addr)
+
+	select {
+	case err := <-errc:
+		return e.errorf("%v", err)
+	case <-ctx.Done():
+	}
+	shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdown); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return e.errorf("%v", err)
+	}
+	return ExitOK
+}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"nonogram-solver/internal/puzzle"
+	"nonogram-solver/internal/render"
+	"nonogram-solver/internal/solver"
+	"nonogram-solver/internal/types"
+)
+
+func runSolve(ctx context.Context, e *env, args []string) int {
+	fs := newFlagSet(e, "solve", "[<id>|<file>|-]")
+	var in inputFlags
+	var out outputFlags
+	var sf solverFlags
+	in.register(fs)
+	out.register(fs, "text", "text, ansi or json")
+	sf.register(fs)
+	positional, code, ok := parseFlags(fs, args)
+	if !ok {
+		return code
+	}
+
+	p, err := in.load(ctx, e, positional)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	g, result, code := solvePuzzle(ctx, e, p, &sf)
+	if code == ExitError {
+		return code
+	}
+
+	w, closeOut, err := out.open(e)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	if err := writeSolution(w, p, g, result, out.format); err != nil {
+		closeOut()
+		return e.errorf("%v", err)
+	}
+	if err := closeOut(); err != nil {
+		return e.errorf("%v", err)
+	}
+
+	if result.Status != solver.Solved {
+		fmt.Fprintf(e.stderr, "%s", result.Status)
+		if result.Reason != nil {
+			fmt.Fprintf(e.stderr, ": %v", result.Reason)
+		}
+		fmt.Fprintln(e.stderr)
+	}
+	return code
+}
+
+// solvePuzzle builds and solves the puzzle's grid, printing --stats on stderr.
+// The returned code is ExitError when the puzzle was rejected.
+func solvePuzzle(ctx context.Context, e *env, p *puzzle.Puzzle, sf *solverFlags) (*types.Grid, solver.Result, int) {
+	ctx, cancel := sf.context(ctx)
+	defer cancel()
+	opts, providers := sf.options(e)
+
+	mem := startMemStats()
+	start := time.Now()
+	g := p.Grid(providers)
+	created := time.Since(start)
+
+	start = time.Now()
+	result, err := solver.Solve(ctx, &g, opts)
+	if err != nil {
+		return nil, result, e.errorf("%v", err)
+	}
+	solved := time.Since(start)
+
+	if sf.stats {
+		allocated, total := mem.report()
+		fmt.Fprintf(e.stderr, "Grid created %dx%d\n", g.Width(), g.Height())
+		fmt.Fprintf(e.stderr, "Grid creation completed in %v\n", created)
+		fmt.Fprintf(e.stderr, "Solve completed in %v (%s, %d passes, %d guesses)\n",
+			solved, result.Status, result.Passes, result.Guesses)
+		fmt.Fprintf(e.stderr, "Memory usage: %.2f MB (allocated), %.2f MB (total allocated)\n", allocated, total)
+	}
+	return &g, result, exitCode(result.Status)
+}
+
+func writeSolution(w io.Writer, p *puzzle.Puzzle, g *types.Grid, result solver.Result, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		report := solver.NewReport(p.ID, g, result)
+		if !p.Meta.IsZero() {
+			report.Meta = p.Meta
+		}
+		return enc.Encode(report)
+	default:
+		style, err := render.ParseStyle(format)
+		if err != nil {
+			return fmt.Errorf("unknown output format %q (want text, ansi or json)", format)
+		}
+		cells, _ := puzzle.Cells(g)
+		return render.Cells(w, p, cells, render.Options{Style: style})
+	}
+}
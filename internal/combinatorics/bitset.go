@@ -1,6 +1,9 @@
 package combinatorics
 
-import "math/big"
+import (
+	"math/big"
+	"math/bits"
+)
 
 // Bitset is a wrapper around math/big.Int for bit operations
 type Bitset struct {
@@ -11,3 +14,11 @@ type Bitset struct {
 func NewBitset(value *big.Int) *Bitset {
 	return &Bitset{Int: value}
 }
+
+// BitsetBytes estimates the heap footprint of one cached Bitset covering size
+// cells, including the slice slot that references it.
+func BitsetBytes(size int) int64 {
+	words := int64((size + bits.UintSize - 1) / bits.UintSize)
+	// slice slot + Bitset wrapper + big.Int header + backing words
+	return 8 + 8 + 32 + words*8
+}
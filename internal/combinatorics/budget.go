@@ -0,0 +1,121 @@
+package combinatorics
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+)
+
+// ErrBudgetExceeded is returned when a line's combinations would not fit in the
+// memory budget even after evicting every other cached slice. Callers should fall
+// back to a line strategy that does not enumerate combinations.
+var ErrBudgetExceeded = errors.New("combinations exceed memory budget")
+
+// MemoryBudget is a byte budget shared by all providers on a grid. Providers
+// reserve bytes for each cached slice; when the budget is full the least recently
+// used slices are evicted, and their owners regenerate them on the next Get.
+type MemoryBudget struct {
+	limit   int64
+	used    int64
+	order   *list.List // front = most recently used
+	entries map[any]*list.Element
+	mu      sync.Mutex
+
+	// OnExceeded, if set, is called with the requested size whenever a
+	// reservation cannot fit in the budget at all.
+	OnExceeded func(requested int64)
+}
+
+type budgetEntry struct {
+	key   any
+	bytes int64
+	evict func()
+}
+
+// NewMemoryBudget creates a budget of limit bytes. A limit <= 0 means unlimited.
+func NewMemoryBudget(limit int64) *MemoryBudget {
+	return &MemoryBudget{
+		limit:   limit,
+		order:   list.New(),
+		entries: make(map[any]*list.Element),
+	}
+}
+
+// Limit returns the budget size in bytes (<= 0 when unlimited)
+func (b *MemoryBudget) Limit() int64 {
+	return b.limit
+}
+
+// Used returns the number of bytes currently reserved
+func (b *MemoryBudget) Used() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.used
+}
+
+// Fits reports whether a reservation of the given size could ever succeed
+func (b *MemoryBudget) Fits(bytes int64) bool {
+	if b.limit <= 0 || bytes <= b.limit {
+		return true
+	}
+	if b.OnExceeded != nil {
+		b.OnExceeded(bytes)
+	}
+	return false
+}
+
+// Reserve records bytes for key, replacing any previous reservation for the same
+// key, and evicts least recently used entries until the budget fits. evict is
+// called (without the budget lock held) if the entry is later evicted. Callers
+// must not hold locks that their own evict callbacks acquire.
+func (b *MemoryBudget) Reserve(key any, bytes int64, evict func()) error {
+	if !b.Fits(bytes) {
+		return ErrBudgetExceeded
+	}
+
+	b.mu.Lock()
+	if elem, ok := b.entries[key]; ok {
+		b.used -= elem.Value.(*budgetEntry).bytes
+		b.order.Remove(elem)
+		delete(b.entries, key)
+	}
+
+	var victims []*budgetEntry
+	for b.limit > 0 && b.used+bytes > b.limit && b.order.Len() > 0 {
+		elem := b.order.Back()
+		entry := elem.Value.(*budgetEntry)
+		b.order.Remove(elem)
+		delete(b.entries, entry.key)
+		b.used -= entry.bytes
+		victims = append(victims, entry)
+	}
+
+	b.entries[key] = b.order.PushFront(&budgetEntry{key: key, bytes: bytes, evict: evict})
+	b.used += bytes
+	b.mu.Unlock()
+
+	for _, victim := range victims {
+		victim.evict()
+	}
+	return nil
+}
+
+// Touch marks key as most recently used
+func (b *MemoryBudget) Touch(key any) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if elem, ok := b.entries[key]; ok {
+		b.order.MoveToFront(elem)
+	}
+}
+
+// Release drops the reservation for key without calling its evict callback
+func (b *MemoryBudget) Release(key any) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if elem, ok := b.entries[key]; ok {
+		b.used -= elem.Value.(*budgetEntry).bytes
+		b.order.Remove(elem)
+		delete(b.entries, key)
+	}
+}
@@ -1,7 +1,25 @@
 package combinatorics
 
+import "context"
+
 // CombinationsProvider provides lazy generation and caching of combinations for colors
 type CombinationsProvider interface {
 	// Get returns combinations for the specified color, generating them lazily if needed
 	Get(color int) ([]*Bitset, error)
+
+	// GetContext is Get with cancellation of any generation it triggers
+	GetContext(ctx context.Context, color int) ([]*Bitset, error)
+
+	// CrossReference drops combinations that conflict with the known facts and
+	// reports whether anything was dropped. Facts use the combination bit layout
+	// (leftmost cell -> most significant bit).
+	CrossReference(ctx context.Context, filled map[int]*Bitset, empty *Bitset) (bool, error)
+
+	// Clone returns an independent provider holding the same filtered
+	// combinations, so either can be filtered further without affecting the other
+	Clone() CombinationsProvider
+
+	// Release returns the provider's cached combinations to its memory budget.
+	// The provider stays usable and regenerates them on the next access.
+	Release()
 }
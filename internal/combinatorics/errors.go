@@ -0,0 +1,16 @@
+package combinatorics
+
+import "errors"
+
+var (
+	// ErrInfeasibleLine is returned when the clues cannot be placed in the line at all
+	ErrInfeasibleLine = errors.New("clues do not fit in line")
+
+	// ErrInvalidClue is returned for nonsense input such as non-positive clue
+	// lengths, negative line sizes or unknown colors
+	ErrInvalidClue = errors.New("invalid clue data")
+
+	// ErrEnumerationLimit is returned when a line has more combinations than the
+	// configured enumeration limit
+	ErrEnumerationLimit = errors.New("combinations exceed enumeration limit")
+)
@@ -0,0 +1,52 @@
+package combinatorics
+
+import (
+	"context"
+	"runtime"
+)
+
+// Semaphore bounds the number of goroutines doing CPU work. The solver's worker
+// pool and nested combination generation share one so they do not oversubscribe
+// CPUs. Nested work should use TryAcquire and run inline when no slot is free,
+// since the caller already occupies a slot.
+type Semaphore struct {
+	slots chan struct{}
+}
+
+// NewSemaphore creates a semaphore with n slots; n <= 0 means GOMAXPROCS
+func NewSemaphore(n int) *Semaphore {
+	if n <= 0 {
+		n = runtime.GOMAXPROCS(0)
+	}
+	return &Semaphore{slots: make(chan struct{}, n)}
+}
+
+// Size returns the number of slots
+func (s *Semaphore) Size() int {
+	return cap(s.slots)
+}
+
+// Acquire blocks until a slot is free or ctx is done
+func (s *Semaphore) Acquire(ctx context.Context) error {
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// TryAcquire takes a slot if one is free without blocking
+func (s *Semaphore) TryAcquire() bool {
+	select {
+	case s.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// Release frees a slot taken by Acquire or TryAcquire
+func (s *Semaphore) Release() {
+	<-s.slots
+}
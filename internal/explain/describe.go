@@ -0,0 +1,69 @@
+package explain
+
+import (
+	"fmt"
+	"strings"
+
+	"nonogram-solver/internal/puzzle"
+	"nonogram-solver/internal/solver"
+	"nonogram-solver/internal/types"
+)
+
+// ColorName describes a cell state for messages
+func ColorName(p *puzzle.Puzzle, color int) string {
+	switch {
+	case color == 0:
+		return "empty"
+	case p.IsMonochrome():
+		return "filled"
+	default:
+		return fmt.Sprintf("color %d (%s)", color, p.Colors[color])
+	}
+}
+
+// CellName names a single cell, e.g. "row 3 column 7"
+func CellName(cell solver.Cell) string {
+	return fmt.Sprintf("row %d column %d", cell.Row+1, cell.Col+1)
+}
+
+// LineName names a line, e.g. "column 4"
+func LineName(id types.LineID) string {
+	if id.Direction == types.Column {
+		return fmt.Sprintf("column %d", id.Index+1)
+	}
+	return fmt.Sprintf("row %d", id.Index+1)
+}
+
+// Describe words a line deduction, e.g. "row 7: overlap forces columns 3–5 to
+// be color 2 (#cc0000) and column 8 to be empty". Cells must lie on the line
+// and be ordered along it.
+func Describe(p *puzzle.Puzzle, operation string, id types.LineID, cells []solver.Cell) string {
+	cellName := "column"
+	if id.Direction == types.Column {
+		cellName = "row"
+	}
+	pos := func(cell solver.Cell) int {
+		if id.Direction == types.Column {
+			return cell.Row
+		}
+		return cell.Col
+	}
+
+	// Group consecutive positions of the same state
+	var parts []string
+	for i := 0; i < len(cells); {
+		j := i + 1
+		for j < len(cells) && cells[j].Color == cells[i].Color && pos(cells[j]) == pos(cells[j-1])+1 {
+			j++
+		}
+		from, to := pos(cells[i])+1, pos(cells[j-1])+1
+		span := fmt.Sprintf("%s %d", cellName, from)
+		if to > from {
+			span = fmt.Sprintf("%ss %d–%d", cellName, from, to)
+		}
+		parts = append(parts, fmt.Sprintf("%s to be %s", span, ColorName(p, cells[i].Color)))
+		i = j
+	}
+
+	return fmt.Sprintf("%s: %s forces %s", LineName(id), operation, strings.Join(parts, " and "))
+}
@@ -0,0 +1,141 @@
+package explain
+
+import (
+	"math/big"
+
+	"nonogram-solver/internal/line"
+	"nonogram-solver/internal/puzzle"
+	"nonogram-solver/internal/solver"
+	"nonogram-solver/internal/types"
+)
+
+// Operations that set a cell
+const (
+	Overlap        = "overlap"         // the line's clues alone force the cell
+	CrossReference = "cross-reference" // the clues and the line's known cells force it
+	Probe          = "probe"           // every other state of the cell led to a contradiction
+	Guess          = "guess"           // assumed by the search; later entries depend on it
+)
+
+// Entry records how one cell was set
+type Entry struct {
+	// Step numbers the deductions; cells set by one line operation share a step
+	Step      int
+	Operation string
+	// Line is the line that forced the cell; unset for probes and guesses
+	Line  types.LineID
+	Row   int
+	Col   int
+	Color int // 0 for empty
+	// Combinations counts the line's surviving placements when the cell was
+	// set; nil for probes and guesses
+	Combinations *big.Int
+	// Depth is the search nesting the cell was set at
+	Depth int
+}
+
+// Recorder turns a solver event stream into the deductions on the path to the
+// final grid: cells set in abandoned search branches are dropped on backtrack.
+// Pass its Event method as solver.Options.Events.
+type Recorder struct {
+	puzzle   *puzzle.Puzzle
+	entries  []Entry
+	branches []int               // len(entries) before each open guess
+	failed   map[int]solver.Cell // last abandoned guess at each depth
+	forced   map[types.LineID]map[int]int
+	events   int
+}
+
+// NewRecorder prepares to record a solve of p
+func NewRecorder(p *puzzle.Puzzle) *Recorder {
+	return &Recorder{
+		puzzle: p,
+		failed: make(map[int]solver.Cell),
+		forced: make(map[types.LineID]map[int]int),
+	}
+}
+
+// Event records one solver event
+func (r *Recorder) Event(e solver.Event) {
+	r.events++
+	switch e.Kind {
+	case solver.EventDeduction:
+		for _, cell := range e.Cells {
+			op := CrossReference
+			if r.forcedByClues(e.Line, cell) {
+				op = Overlap
+			}
+			r.entries = append(r.entries, Entry{
+				Step:         r.events,
+				Operation:    op,
+				Line:         e.Line,
+				Row:          cell.Row,
+				Col:          cell.Col,
+				Color:        cell.Color,
+				Combinations: e.Combinations,
+				Depth:        e.Depth,
+			})
+		}
+	case solver.EventGuess:
+		cell := e.Cells[0]
+		op := Guess
+		if failed, ok := r.failed[e.Depth]; ok && e.Candidates == 0 && failed.Row == cell.Row && failed.Col == cell.Col {
+			op = Probe
+		}
+		delete(r.failed, e.Depth)
+		r.branches = append(r.branches, len(r.entries))
+		r.entries = append(r.entries, Entry{Step: r.events, Operation: op, Row: cell.Row, Col: cell.Col, Color: cell.Color, Depth: e.Depth})
+	case solver.EventBacktrack:
+		if len(r.branches) == 0 {
+			return
+		}
+		last := len(r.branches) - 1
+		r.entries = r.entries[:r.branches[last]]
+		r.branches = r.branches[:last]
+		r.failed[e.Depth] = e.Cells[0]
+		// Guesses nested in the abandoned branch are no longer siblings
+		for depth := range r.failed {
+			if depth > e.Depth {
+				delete(r.failed, depth)
+			}
+		}
+	}
+}
+
+// Entries returns the recorded deductions in order, with steps renumbered from 1
+func (r *Recorder) Entries() []Entry {
+	out := make([]Entry, len(r.entries))
+	step, last := 0, -1
+	for i, entry := range r.entries {
+		if entry.Step != last || (i > 0 && entry.Operation != r.entries[i-1].Operation) {
+			step++
+		}
+		last = entry.Step
+		entry.Step = step
+		out[i] = entry
+	}
+	return out
+}
+
+// forcedByClues reports whether the line's clues alone force cell's state
+func (r *Recorder) forcedByClues(id types.LineID, cell solver.Cell) bool {
+	forced, ok := r.forced[id]
+	if !ok {
+		clues, length := r.puzzle.Rows[id.Index], r.puzzle.Width
+		if id.Direction == types.Column {
+			clues, length = r.puzzle.Cols[id.Index], r.puzzle.Height
+		}
+		forced = make(map[int]int)
+		changes, _ := line.Forced(clues, length)
+		for _, change := range changes {
+			forced[change.Pos] = change.Color
+		}
+		r.forced[id] = forced
+	}
+	pos := cell.Col
+	if id.Direction == types.Column {
+		pos = cell.Row
+	}
+	color, ok := forced[pos]
+	return ok && color == cell.Color
+}
@@ -0,0 +1,95 @@
+package explain
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+
+	"nonogram-solver/internal/puzzle"
+	"nonogram-solver/internal/solver"
+)
+
+// Write prints the entries as a numbered solve log, one line per step, followed
+// by a count of cells per operation
+func Write(w io.Writer, p *puzzle.Puzzle, entries []Entry) error {
+	bw := bufio.NewWriter(w)
+	counts := make(map[string]int)
+	for i := 0; i < len(entries); {
+		j := i + 1
+		for j < len(entries) && entries[j].Step == entries[i].Step {
+			j++
+		}
+		fmt.Fprintf(bw, "%4d. %s\n", entries[i].Step, step(p, entries[i:j]))
+		counts[entries[i].Operation] += j - i
+		i = j
+	}
+	fmt.Fprintf(bw, "%d cells: %d overlap, %d cross-reference, %d probe, %d guess\n",
+		len(entries), counts[Overlap], counts[CrossReference], counts[Probe], counts[Guess])
+	return bw.Flush()
+}
+
+// step words the entries of one step
+func step(p *puzzle.Puzzle, entries []Entry) string {
+	first := entries[0]
+	cell := solver.Cell{Row: first.Row, Col: first.Col, Color: first.Color}
+	switch first.Operation {
+	case Probe:
+		return fmt.Sprintf("probe: %s must be %s, every other state contradicts", CellName(cell), ColorName(p, cell.Color))
+	case Guess:
+		return fmt.Sprintf("guess: %s is %s (depth %d; later steps assume it)", CellName(cell), ColorName(p, cell.Color), first.Depth)
+	}
+	cells := make([]solver.Cell, len(entries))
+	for i, entry := range entries {
+		cells[i] = solver.Cell{Row: entry.Row, Col: entry.Col, Color: entry.Color}
+	}
+	text := Describe(p, first.Operation, first.Line, cells)
+	if first.Combinations != nil {
+		noun := "combinations"
+		if first.Combinations.IsInt64() && first.Combinations.Int64() == 1 {
+			noun = "combination"
+		}
+		text += fmt.Sprintf(" (%s %s left)", first.Combinations, noun)
+	}
+	return text
+}
+
+// entryJSON is the wire form of an Entry
+type entryJSON struct {
+	Step         int       `json:"step"`
+	Operation    string    `json:"operation"`
+	Line         *lineJSON `json:"line,omitempty"`
+	Row          int       `json:"row"`
+	Col          int       `json:"col"`
+	Color        int       `json:"color"`
+	Combinations *big.Int  `json:"combinations,omitempty"`
+	Depth        int       `json:"depth"`
+}
+
+type lineJSON struct {
+	Direction string `json:"direction"`
+	Index     int    `json:"index"`
+}
+
+// WriteJSON writes the entries as an indented JSON array
+func WriteJSON(w io.Writer, entries []Entry) error {
+	out := make([]entryJSON, len(entries))
+	for i, entry := range entries {
+		out[i] = entryJSON{
+			Step:         entry.Step,
+			Operation:    entry.Operation,
+			Row:          entry.Row,
+			Col:          entry.Col,
+			Color:        entry.Color,
+			Combinations: entry.Combinations,
+			Depth:        entry.Depth,
+		}
+		if entry.Operation == Overlap || entry.Operation == CrossReference {
+			out[i].Line = &lineJSON{Direction: entry.Line.Direction.String(), Index: entry.Line.Index}
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
@@ -0,0 +1,403 @@
+package factory
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"sync"
+
+	"nonogram-solver/internal/combinatorics"
+	"nonogram-solver/internal/types"
+)
+
+// ArrangementsProviderImpl implements combinatorics.CombinationsProvider on top of
+// whole-line arrangements. Per-color combinations are projections of the surviving
+// arrangements, so CrossReference eliminates placements for all colors jointly and
+// the masks returned for different colors never overlap.
+type ArrangementsProviderImpl struct {
+	clues         []types.ClueItem
+	size          int
+	generated     bool
+	arrangements  []map[int]*big.Int
+	combosByColor map[int][]*types.Bitset
+	version       int
+	filled        map[int]*types.Bitset
+	empty         *types.Bitset
+	budget        *combinatorics.MemoryBudget
+	cache         *CombinationsCache
+	palette       map[int]string
+	limit         int64
+	err           error
+	mu            sync.RWMutex
+}
+
+// NewArrangementsProvider creates a new lazy whole-line arrangements provider
+func NewArrangementsProvider(clues []types.ClueItem, size int) *ArrangementsProviderImpl {
+	return NewArrangementsProviderWithOptions(clues, size, ProviderOptions{})
+}
+
+// NewArrangementsProviderWithOptions creates a whole-line arrangements provider
+// whose cache is accounted against opts.Budget as a single entry. Arrangement
+// enumeration is sequential, so opts.Semaphore is not used.
+func NewArrangementsProviderWithOptions(clues []types.ClueItem, size int, opts ProviderOptions) *ArrangementsProviderImpl {
+	return &ArrangementsProviderImpl{
+		clues:         clues,
+		size:          size,
+		combosByColor: make(map[int][]*types.Bitset),
+		budget:        opts.Budget,
+		cache:         opts.Cache,
+		palette:       opts.Palette,
+		limit:         opts.MaxCombinations,
+		err:           ValidateClues(clues, size),
+	}
+}
+
+// Get returns the distinct projections of the surviving arrangements onto the
+// specified color, in descending numeric order.
+func (ap *ArrangementsProviderImpl) Get(color int) ([]*types.Bitset, error) {
+	return ap.GetContext(context.Background(), color)
+}
+
+// GetContext is Get with cancellation. Errors wrap combinatorics.ErrInvalidClue,
+// ErrInfeasibleLine (including when facts eliminated every arrangement),
+// ErrEnumerationLimit or ErrBudgetExceeded; cancellation returns ctx.Err().
+func (ap *ArrangementsProviderImpl) GetContext(ctx context.Context, color int) ([]*types.Bitset, error) {
+	if err := checkColor(ap.palette, color); err != nil {
+		return nil, err
+	}
+
+	ap.mu.RLock()
+	if combos, ok := ap.combosByColor[color]; ok {
+		ap.mu.RUnlock()
+		if ap.budget != nil {
+			ap.budget.Touch(ap)
+		}
+		return nonEmpty(combos)
+	}
+	ap.mu.RUnlock()
+
+	if err := ap.checkEnumeration(); err != nil {
+		return nil, err
+	}
+
+	ap.mu.Lock()
+	if combos, ok := ap.combosByColor[color]; ok {
+		ap.mu.Unlock()
+		return nonEmpty(combos)
+	}
+	if err := ap.ensureGenerated(ctx); err != nil {
+		ap.mu.Unlock()
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	combos := make([]*types.Bitset, 0)
+	for _, masks := range ap.arrangements {
+		mask, ok := masks[color]
+		if !ok {
+			mask = big.NewInt(0)
+		}
+		key := mask.Text(16)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		combos = append(combos, types.NewBitset(mask))
+	}
+	sort.Slice(combos, func(i, j int) bool {
+		return combos[i].Cmp(combos[j].Int) > 0
+	})
+
+	ap.combosByColor[color] = combos
+	version, bytes := ap.snapshotUsage()
+	ap.mu.Unlock()
+
+	if err := ap.reserve(version, bytes); err != nil {
+		return nil, err
+	}
+	return nonEmpty(combos)
+}
+
+// CrossReference drops arrangements whose projections conflict with the known
+// facts, which removes the corresponding masks from every color at once. When no
+// arrangement survives the error wraps combinatorics.ErrInfeasibleLine.
+func (ap *ArrangementsProviderImpl) CrossReference(ctx context.Context, filled map[int]*types.Bitset, empty *types.Bitset) (bool, error) {
+	if err := ap.checkEnumeration(); err != nil {
+		return false, err
+	}
+
+	ap.mu.Lock()
+	// Generate against the previous facts so the drop below is reported
+	if err := ap.ensureGenerated(ctx); err != nil {
+		ap.mu.Unlock()
+		return false, err
+	}
+	ap.filled = filled
+	ap.empty = empty
+
+	kept := make([]map[int]*big.Int, 0, len(ap.arrangements))
+	for _, masks := range ap.arrangements {
+		if arrangementFits(masks, filled, empty) {
+			kept = append(kept, masks)
+		}
+	}
+	if len(kept) == len(ap.arrangements) {
+		version, bytes := ap.snapshotUsage()
+		ap.mu.Unlock()
+		return false, ap.reserve(version, bytes)
+	}
+
+	ap.arrangements = kept
+	ap.combosByColor = make(map[int][]*types.Bitset)
+	version, bytes := ap.snapshotUsage()
+	ap.mu.Unlock()
+
+	if err := ap.reserve(version, bytes); err != nil {
+		return true, err
+	}
+	if len(kept) == 0 {
+		return true, errNoCombinations
+	}
+	return true, nil
+}
+
+// Clone copies the surviving arrangements, their projections and the facts
+// they were filtered against. When the budget cannot hold the copy, it
+// regenerates and refilters on the next access.
+func (ap *ArrangementsProviderImpl) Clone() combinatorics.CombinationsProvider {
+	out := &ArrangementsProviderImpl{
+		clues:         ap.clues,
+		size:          ap.size,
+		combosByColor: make(map[int][]*types.Bitset),
+		budget:        ap.budget,
+		cache:         ap.cache,
+		palette:       ap.palette,
+		limit:         ap.limit,
+		err:           ap.err,
+	}
+	ap.mu.RLock()
+	out.generated, out.filled, out.empty = ap.generated, ap.filled, ap.empty
+	if ap.generated {
+		out.arrangements = append([]map[int]*big.Int(nil), ap.arrangements...)
+	}
+	for color, combos := range ap.combosByColor {
+		out.combosByColor[color] = combos
+	}
+	ap.mu.RUnlock()
+
+	if !out.generated {
+		return out
+	}
+	out.mu.Lock()
+	version, bytes := out.snapshotUsage()
+	out.mu.Unlock()
+	if err := out.reserve(version, bytes); err != nil {
+		out.generated, out.arrangements = false, nil
+		out.combosByColor = make(map[int][]*types.Bitset)
+	}
+	return out
+}
+
+// Release drops the arrangements, their projections and the line's budget
+// reservation. Facts seen so far are kept, so regenerated arrangements are
+// filtered against them.
+func (ap *ArrangementsProviderImpl) Release() {
+	ap.mu.Lock()
+	ap.version++
+	ap.generated = false
+	ap.arrangements = nil
+	ap.combosByColor = make(map[int][]*types.Bitset)
+	ap.mu.Unlock()
+
+	if ap.budget != nil {
+		ap.budget.Release(ap)
+	}
+}
+
+// ensureGenerated enumerates and projects all arrangements once, keeping only
+// those that agree with the latest facts. Callers must hold the write lock.
+func (ap *ArrangementsProviderImpl) ensureGenerated(ctx context.Context) error {
+	if ap.generated {
+		return nil
+	}
+	all, err := cacheOrShared(ap.cache).LineArrangements(ctx, ap.clues, ap.size)
+	if err != nil {
+		return err
+	}
+	ap.arrangements = ap.arrangements[:0]
+	for _, starts := range all {
+		masks := ProjectArrangement(ap.clues, ap.size, starts)
+		if ap.filled == nil || arrangementFits(masks, ap.filled, ap.empty) {
+			ap.arrangements = append(ap.arrangements, masks)
+		}
+	}
+	ap.generated = true
+	return nil
+}
+
+// checkEnumeration refuses to enumerate a line with invalid clues, or whose
+// arrangements exceed the enumeration limit or could never fit in the budget.
+func (ap *ArrangementsProviderImpl) checkEnumeration() error {
+	if ap.err != nil {
+		return ap.err
+	}
+	ap.mu.RLock()
+	generated := ap.generated
+	ap.mu.RUnlock()
+	if generated {
+		return nil
+	}
+	count := placementCount(ap.size-minLineLength(ap.clues), len(ap.clues))
+	if err := checkEnumerationLimit(count, ap.limit); err != nil {
+		return err
+	}
+	if ap.budget != nil && !ap.budget.Fits(bytesFor(count, ap.arrangementBytes())) {
+		return combinatorics.ErrBudgetExceeded
+	}
+	return nil
+}
+
+// arrangementBytes estimates the footprint of one projected arrangement
+func (ap *ArrangementsProviderImpl) arrangementBytes() int64 {
+	colors := make(map[int]bool)
+	for _, clue := range ap.clues {
+		colors[clue.ColorID] = true
+	}
+	// slice slot + map header, then a key/value slot and a big.Int per color
+	return 8 + 48 + int64(len(colors))*(16+combinatorics.BitsetBytes(ap.size))
+}
+
+// snapshotUsage bumps the cache version and returns it with the current
+// footprint. Callers must hold the write lock.
+func (ap *ArrangementsProviderImpl) snapshotUsage() (int, int64) {
+	ap.version++
+	bytes := int64(len(ap.arrangements)) * ap.arrangementBytes()
+	for _, combos := range ap.combosByColor {
+		bytes += int64(len(combos)) * combinatorics.BitsetBytes(ap.size)
+	}
+	return ap.version, bytes
+}
+
+// reserve accounts the whole line against the budget. Eviction drops the
+// arrangements; they are regenerated and refiltered on the next access.
+func (ap *ArrangementsProviderImpl) reserve(version int, bytes int64) error {
+	if ap.budget == nil {
+		return nil
+	}
+	return ap.budget.Reserve(ap, bytes, func() {
+		ap.mu.Lock()
+		defer ap.mu.Unlock()
+		if ap.version == version {
+			ap.generated = false
+			ap.arrangements = nil
+			ap.combosByColor = make(map[int][]*types.Bitset)
+		}
+	})
+}
+
+// arrangementFits reports whether a projected arrangement covers every filled
+// fact with the right color and leaves every empty fact uncovered.
+func arrangementFits(masks map[int]*big.Int, filled map[int]*types.Bitset, empty *types.Bitset) bool {
+	scratch := new(big.Int)
+	for color, known := range filled {
+		if known.Sign() == 0 {
+			continue
+		}
+		mask, ok := masks[color]
+		if !ok || scratch.AndNot(known.Int, mask).Sign() != 0 {
+			return false
+		}
+	}
+	if empty != nil {
+		for _, mask := range masks {
+			if scratch.And(mask, empty.Int).Sign() != 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// GenerateLineArrangements enumerates every placement of the full multi-color
+// clue line. Each arrangement holds one start position per clue, in clue order.
+// Adjacent clues of the same color need at least one empty cell between them,
+// while clues of different colors may touch. Arrangements are produced in
+// ascending lexicographic order of their start positions (leftmost first).
+func GenerateLineArrangements(clues []types.ClueItem, size int) [][]int {
+	result, _ := GenerateLineArrangementsContext(context.Background(), clues, size)
+	return result
+}
+
+// GenerateLineArrangementsContext is GenerateLineArrangements with cancellation.
+// It returns ctx.Err() as soon as the enumeration notices cancellation.
+func GenerateLineArrangementsContext(ctx context.Context, clues []types.ClueItem, size int) ([][]int, error) {
+	if size <= 0 {
+		return [][]int{}, nil
+	}
+	if len(clues) == 0 {
+		return [][]int{{}}, nil
+	}
+
+	n := len(clues)
+
+	// gapAfter[i]: minimal empty cells required between clue i and clue i+1
+	gapAfter := make([]int, n)
+	for i := 0; i < n-1; i++ {
+		if clues[i].ColorID == clues[i+1].ColorID {
+			gapAfter[i] = 1
+		}
+	}
+
+	// tailMin[i]: minimal cells needed from the start of clue i to the line end
+	tailMin := make([]int, n+1)
+	for i := n - 1; i >= 0; i-- {
+		tailMin[i] = clues[i].Clue + gapAfter[i] + tailMin[i+1]
+	}
+	if tailMin[0] > size {
+		return [][]int{}, nil
+	}
+
+	result := make([][]int, 0)
+	starts := make([]int, n)
+	steps := 0
+
+	var dfs func(i, minStart int) bool
+	dfs = func(i, minStart int) bool {
+		steps++
+		if steps%cancelCheckInterval == 0 && ctx.Err() != nil {
+			return false
+		}
+		if i == n {
+			result = append(result, append([]int(nil), starts...))
+			return true
+		}
+		maxStart := size - tailMin[i]
+		for s := minStart; s <= maxStart; s++ {
+			starts[i] = s
+			if !dfs(i+1, s+clues[i].Clue+gapAfter[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	if !dfs(0, 0) {
+		return nil, ctx.Err()
+	}
+
+	return result, nil
+}
+
+// ProjectArrangement converts a full-line arrangement into one mask per color,
+// using the same bit layout as GenerateColorCombinations.
+func ProjectArrangement(clues []types.ClueItem, size int, starts []int) map[int]*big.Int {
+	masks := make(map[int]*big.Int)
+	for i, clue := range clues {
+		mask, ok := masks[clue.ColorID]
+		if !ok {
+			mask = big.NewInt(0)
+			masks[clue.ColorID] = mask
+		}
+		mask.Or(mask, runMask(size, starts[i], clue.Clue))
+	}
+	return masks
+}
@@ -0,0 +1,55 @@
+package factory
+
+import (
+	"fmt"
+	"math/big"
+
+	"nonogram-solver/internal/combinatorics"
+	"nonogram-solver/internal/types"
+)
+
+// ValidateClues checks a line's clues before any enumeration. It returns an error
+// wrapping combinatorics.ErrInvalidClue for nonsense input and
+// combinatorics.ErrInfeasibleLine when the clues cannot fit in size cells.
+func ValidateClues(clues []types.ClueItem, size int) error {
+	if size < 0 {
+		return fmt.Errorf("%w: negative line size %d", combinatorics.ErrInvalidClue, size)
+	}
+	for i, clue := range clues {
+		if clue.Clue <= 0 {
+			return fmt.Errorf("%w: clue %d has length %d", combinatorics.ErrInvalidClue, i+1, clue.Clue)
+		}
+		if clue.ColorID <= 0 {
+			return fmt.Errorf("%w: clue %d has color %d", combinatorics.ErrInvalidClue, i+1, clue.ColorID)
+		}
+	}
+	if required := minLineLength(clues); required > size {
+		return fmt.Errorf("%w: clues need %d cells, line has %d", combinatorics.ErrInfeasibleLine, required, size)
+	}
+	return nil
+}
+
+// checkColor rejects colors <= 0 and, when a palette is given, colors outside
+// it. A color the line's clues do not use is valid: its only combination is
+// the all-empty mask.
+func checkColor(palette map[int]string, color int) error {
+	if color <= 0 {
+		return fmt.Errorf("%w: color %d", combinatorics.ErrInvalidClue, color)
+	}
+	if _, ok := palette[color]; palette != nil && !ok {
+		return fmt.Errorf("%w: color %d is not in the palette", combinatorics.ErrInvalidClue, color)
+	}
+	return nil
+}
+
+// checkEnumerationLimit rejects lines with more than limit combinations.
+// A limit <= 0 disables the check.
+func checkEnumerationLimit(count *big.Int, limit int64) error {
+	if limit > 0 && count.Cmp(big.NewInt(limit)) > 0 {
+		return fmt.Errorf("%w: %d combinations, limit %d", combinatorics.ErrEnumerationLimit, count, limit)
+	}
+	return nil
+}
+
+// errNoCombinations reports a line whose combinations were all eliminated by facts
+var errNoCombinations = fmt.Errorf("%w: no combination agrees with the known facts", combinatorics.ErrInfeasibleLine)
@@ -0,0 +1,430 @@
+package factory
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"nonogram-solver/internal/combinatorics"
+	"nonogram-solver/internal/types"
+)
+
+// DefaultCacheBytes bounds the in-memory part of the shared combinations cache
+const DefaultCacheBytes = 256 << 20
+
+const (
+	cacheMagic            = "NGC1"
+	cacheKindColor   byte = 'c'
+	cacheKindArrange byte = 'a'
+)
+
+// CacheKey is the content address of a generated line: a SHA-256 over the kind,
+// size, color and clue sequence.
+type CacheKey [sha256.Size]byte
+
+// String returns the hex form of the key, which is also its on-disk file name
+func (k CacheKey) String() string {
+	return hex.EncodeToString(k[:])
+}
+
+// CacheStats holds the counters of a CombinationsCache
+type CacheStats struct {
+	Hits       int64 // served from memory
+	DiskHits   int64 // served from the on-disk store
+	Misses     int64 // generated from scratch
+	DiskWrites int64 // entries persisted to disk
+	DiskErrors int64 // failed reads or writes; the cache is best-effort
+}
+
+// CombinationsCache is a content-addressed cache of generated combinations shared
+// by all providers in-process. Lines with identical clues and size reuse the same
+// slices, which callers must treat as read-only. An optional directory persists
+// entries in a compact binary format so batch runs can reuse work across processes.
+type CombinationsCache struct {
+	dir     string
+	budget  *combinatorics.MemoryBudget
+	colors  map[CacheKey][]*big.Int
+	arrange map[CacheKey][][]int
+	mu      sync.RWMutex
+
+	hits, diskHits, misses, diskWrites, diskErrors atomic.Int64
+}
+
+var (
+	sharedCache   = NewCombinationsCache("", combinatorics.NewMemoryBudget(DefaultCacheBytes))
+	sharedCacheMu sync.RWMutex
+)
+
+// SharedCache returns the cache used by combinations providers whose options
+// set no Cache
+func SharedCache() *CombinationsCache {
+	sharedCacheMu.RLock()
+	defer sharedCacheMu.RUnlock()
+	return sharedCache
+}
+
+// SetSharedCache replaces the cache used by providers without their own.
+// A nil cache disables caching.
+func SetSharedCache(cache *CombinationsCache) {
+	sharedCacheMu.Lock()
+	defer sharedCacheMu.Unlock()
+	sharedCache = cache
+}
+
+// cacheOrShared returns c, or the shared cache when c is nil
+func cacheOrShared(c *CombinationsCache) *CombinationsCache {
+	if c == nil {
+		return SharedCache()
+	}
+	return c
+}
+
+// NewCombinationsCache creates a cache. dir enables the on-disk store when non-empty;
+// budget bounds the in-memory entries and may be nil for unlimited.
+func NewCombinationsCache(dir string, budget *combinatorics.MemoryBudget) *CombinationsCache {
+	return &CombinationsCache{
+		dir:     dir,
+		budget:  budget,
+		colors:  make(map[CacheKey][]*big.Int),
+		arrange: make(map[CacheKey][][]int),
+	}
+}
+
+// Stats returns a snapshot of the cache counters
+func (c *CombinationsCache) Stats() CacheStats {
+	return CacheStats{
+		Hits:       c.hits.Load(),
+		DiskHits:   c.diskHits.Load(),
+		Misses:     c.misses.Load(),
+		DiskWrites: c.diskWrites.Load(),
+		DiskErrors: c.diskErrors.Load(),
+	}
+}
+
+// ColorCombinations returns GenerateColorCombinationsContext(ctx, clues, size, color, sem),
+// generating it only on a cache miss. Cancelled generations are not cached.
+func (c *CombinationsCache) ColorCombinations(ctx context.Context, clues []types.ClueItem, size, color int, sem *combinatorics.Semaphore) ([]*big.Int, error) {
+	if c == nil {
+		return GenerateColorCombinationsContext(ctx, clues, size, color, sem)
+	}
+	key := cacheKeyFor(cacheKindColor, clues, size, color)
+
+	c.mu.RLock()
+	combos, ok := c.colors[key]
+	c.mu.RUnlock()
+	if ok {
+		c.hits.Add(1)
+		c.touch(key)
+		return combos, nil
+	}
+
+	if combos, ok := c.readColors(key, size); ok {
+		c.diskHits.Add(1)
+		c.storeColors(key, size, combos)
+		return combos, nil
+	}
+
+	c.misses.Add(1)
+	combos, err := GenerateColorCombinationsContext(ctx, clues, size, color, sem)
+	if err != nil {
+		return nil, err
+	}
+	c.storeColors(key, size, combos)
+	c.writeColors(key, size, combos)
+	return combos, nil
+}
+
+// LineArrangements returns GenerateLineArrangementsContext(ctx, clues, size),
+// generating it only on a cache miss. Cancelled generations are not cached.
+func (c *CombinationsCache) LineArrangements(ctx context.Context, clues []types.ClueItem, size int) ([][]int, error) {
+	if c == nil {
+		return GenerateLineArrangementsContext(ctx, clues, size)
+	}
+	key := cacheKeyFor(cacheKindArrange, clues, size, 0)
+
+	c.mu.RLock()
+	arrangements, ok := c.arrange[key]
+	c.mu.RUnlock()
+	if ok {
+		c.hits.Add(1)
+		c.touch(key)
+		return arrangements, nil
+	}
+
+	if arrangements, ok := c.readArrangements(key, len(clues)); ok {
+		c.diskHits.Add(1)
+		c.storeArrangements(key, arrangements)
+		return arrangements, nil
+	}
+
+	c.misses.Add(1)
+	arrangements, err := GenerateLineArrangementsContext(ctx, clues, size)
+	if err != nil {
+		return nil, err
+	}
+	c.storeArrangements(key, arrangements)
+	c.writeArrangements(key, arrangements)
+	return arrangements, nil
+}
+
+// cacheKeyFor hashes the canonical form of a line
+func cacheKeyFor(kind byte, clues []types.ClueItem, size, color int) CacheKey {
+	h := sha256.New()
+	var buf [binary.MaxVarintLen64]byte
+	put := func(v int) {
+		n := binary.PutVarint(buf[:], int64(v))
+		h.Write(buf[:n])
+	}
+	h.Write([]byte{kind})
+	put(size)
+	put(color)
+	put(len(clues))
+	for _, clue := range clues {
+		put(clue.ColorID)
+		put(clue.Clue)
+	}
+	var key CacheKey
+	copy(key[:], h.Sum(nil))
+	return key
+}
+
+func (c *CombinationsCache) touch(key CacheKey) {
+	if c.budget != nil {
+		c.budget.Touch(key)
+	}
+}
+
+func (c *CombinationsCache) storeColors(key CacheKey, size int, combos []*big.Int) {
+	c.mu.Lock()
+	c.colors[key] = combos
+	c.mu.Unlock()
+	c.reserve(key, int64(len(combos))*combinatorics.BitsetBytes(size))
+}
+
+func (c *CombinationsCache) storeArrangements(key CacheKey, arrangements [][]int) {
+	c.mu.Lock()
+	c.arrange[key] = arrangements
+	c.mu.Unlock()
+	var bytes int64
+	for _, starts := range arrangements {
+		bytes += 24 + int64(len(starts))*8
+	}
+	c.reserve(key, bytes)
+}
+
+// reserve accounts an entry against the cache budget. Entries too large for the
+// budget are dropped right away; they are still returned to the caller.
+func (c *CombinationsCache) reserve(key CacheKey, bytes int64) {
+	if c.budget == nil {
+		return
+	}
+	drop := func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		delete(c.colors, key)
+		delete(c.arrange, key)
+	}
+	if err := c.budget.Reserve(key, bytes, drop); err != nil {
+		drop()
+	}
+}
+
+func (c *CombinationsCache) path(key CacheKey) string {
+	return filepath.Join(c.dir, key.String()+".bin")
+}
+
+// On-disk layout: magic, kind byte, then uvarint fields.
+//   color entries:       size, count, count * ceil(size/8) big-endian mask bytes
+//   arrangement entries: clue count, count, count * clue count uvarint starts
+
+func (c *CombinationsCache) writeColors(key CacheKey, size int, combos []*big.Int) {
+	if c.dir == "" {
+		return
+	}
+	var buf bytes.Buffer
+	buf.WriteString(cacheMagic)
+	buf.WriteByte(cacheKindColor)
+	buf.Write(binary.AppendUvarint(nil, uint64(size)))
+	buf.Write(binary.AppendUvarint(nil, uint64(len(combos))))
+	width := (size + 7) / 8
+	scratch := make([]byte, width)
+	for _, combo := range combos {
+		buf.Write(combo.FillBytes(scratch))
+	}
+	c.writeFile(key, buf.Bytes())
+}
+
+func (c *CombinationsCache) writeArrangements(key CacheKey, arrangements [][]int) {
+	if c.dir == "" {
+		return
+	}
+	clueCount := 0
+	if len(arrangements) > 0 {
+		clueCount = len(arrangements[0])
+	}
+	var buf bytes.Buffer
+	buf.WriteString(cacheMagic)
+	buf.WriteByte(cacheKindArrange)
+	buf.Write(binary.AppendUvarint(nil, uint64(clueCount)))
+	buf.Write(binary.AppendUvarint(nil, uint64(len(arrangements))))
+	for _, starts := range arrangements {
+		for _, s := range starts {
+			buf.Write(binary.AppendUvarint(nil, uint64(s)))
+		}
+	}
+	c.writeFile(key, buf.Bytes())
+}
+
+// writeFile stores data atomically so concurrent processes never see partial entries
+func (c *CombinationsCache) writeFile(key CacheKey, data []byte) {
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		c.diskErrors.Add(1)
+		return
+	}
+	tmp, err := os.CreateTemp(c.dir, key.String()+".*.tmp")
+	if err != nil {
+		c.diskErrors.Add(1)
+		return
+	}
+	_, writeErr := tmp.Write(data)
+	closeErr := tmp.Close()
+	if writeErr != nil || closeErr != nil {
+		os.Remove(tmp.Name())
+		c.diskErrors.Add(1)
+		return
+	}
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		os.Remove(tmp.Name())
+		c.diskErrors.Add(1)
+		return
+	}
+	c.diskWrites.Add(1)
+}
+
+func (c *CombinationsCache) readColors(key CacheKey, size int) ([]*big.Int, bool) {
+	r, ok := c.openEntry(key, cacheKindColor)
+	if !ok {
+		return nil, false
+	}
+	combos, err := decodeColorEntry(r, size)
+	if err != nil {
+		c.diskErrors.Add(1)
+		return nil, false
+	}
+	return combos, true
+}
+
+func (c *CombinationsCache) readArrangements(key CacheKey, clueCount int) ([][]int, bool) {
+	r, ok := c.openEntry(key, cacheKindArrange)
+	if !ok {
+		return nil, false
+	}
+	arrangements, err := decodeArrangementEntry(r, clueCount)
+	if err != nil {
+		c.diskErrors.Add(1)
+		return nil, false
+	}
+	return arrangements, true
+}
+
+// openEntry reads an entry file and checks its header. A missing file is a
+// plain miss, anything else unreadable counts as a disk error.
+func (c *CombinationsCache) openEntry(key CacheKey, kind byte) (*bytes.Reader, bool) {
+	if c.dir == "" {
+		return nil, false
+	}
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			c.diskErrors.Add(1)
+		}
+		return nil, false
+	}
+	if len(data) < len(cacheMagic)+1 || string(data[:len(cacheMagic)]) != cacheMagic || data[len(cacheMagic)] != kind {
+		c.diskErrors.Add(1)
+		return nil, false
+	}
+	return bytes.NewReader(data[len(cacheMagic)+1:]), true
+}
+
+func decodeColorEntry(r *bytes.Reader, size int) ([]*big.Int, error) {
+	storedSize, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if int(storedSize) != size {
+		return nil, fmt.Errorf("cache entry size %d, expected %d", storedSize, size)
+	}
+	count, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	width := (size + 7) / 8
+	if err := checkCount(r, count, width); err != nil {
+		return nil, err
+	}
+	combos := make([]*big.Int, 0, count)
+	raw := make([]byte, width)
+	for i := uint64(0); i < count; i++ {
+		if _, err := io.ReadFull(r, raw); err != nil {
+			return nil, err
+		}
+		combos = append(combos, new(big.Int).SetBytes(raw))
+	}
+	return combos, nil
+}
+
+func decodeArrangementEntry(r *bytes.Reader, clueCount int) ([][]int, error) {
+	storedClues, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	count, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 && int(storedClues) != clueCount {
+		return nil, fmt.Errorf("cache entry has %d clues, expected %d", storedClues, clueCount)
+	}
+	// Each start is a uvarint of at least one byte
+	if err := checkCount(r, count, clueCount); err != nil {
+		return nil, err
+	}
+	arrangements := make([][]int, 0, count)
+	for i := uint64(0); i < count; i++ {
+		starts := make([]int, clueCount)
+		for j := range starts {
+			v, err := binary.ReadUvarint(r)
+			if err != nil {
+				return nil, err
+			}
+			starts[j] = int(v)
+		}
+		arrangements = append(arrangements, starts)
+	}
+	return arrangements, nil
+}
+
+// checkCount rejects an entry whose item count cannot fit in the bytes left,
+// so a corrupt count never sizes an allocation. Items of no bytes are only
+// valid alone: an empty line has one empty mask or arrangement.
+func checkCount(r *bytes.Reader, count uint64, itemBytes int) error {
+	limit := uint64(1)
+	if itemBytes > 0 {
+		limit = uint64(r.Len() / itemBytes)
+	}
+	if count > limit {
+		return fmt.Errorf("cache entry claims %d items in %d bytes", count, r.Len())
+	}
+	return nil
+}
@@ -1,63 +1,346 @@
 package factory
 
 import (
+	"context"
+	"math"
 	"math/big"
-	"runtime"
 	"sync"
 
+	"nonogram-solver/internal/combinatorics"
 	"nonogram-solver/internal/types"
 )
 
+// cancelCheckInterval is how many DFS nodes a generator visits between
+// cancellation checks
+const cancelCheckInterval = 1024
+
+// defaultSemaphore bounds generation goroutines when the caller passes none
+var defaultSemaphore = combinatorics.NewSemaphore(0)
+
 // CombinationsProviderImpl implements combinatorics.CombinationsProvider
 type CombinationsProviderImpl struct {
 	clues         []types.ClueItem
 	size          int
 	generated     map[int]bool
 	combosByColor map[int][]*types.Bitset
+	versions      map[int]int
+	filled        map[int]*types.Bitset
+	empty         *types.Bitset
+	budget        *combinatorics.MemoryBudget
+	sem           *combinatorics.Semaphore
+	cache         *CombinationsCache
+	palette       map[int]string
+	limit         int64
+	err           error
 	mu            sync.RWMutex
 }
 
+// ProviderOptions configures the resources a combinations provider shares with
+// the rest of the grid
+type ProviderOptions struct {
+	// Budget accounts cached slices; nil means unlimited
+	Budget *combinatorics.MemoryBudget
+	// Semaphore bounds nested generation goroutines; nil uses a process-wide default
+	Semaphore *combinatorics.Semaphore
+	// MaxCombinations caps how many combinations a line may enumerate; <= 0 means no cap
+	MaxCombinations int64
+	// Cache holds enumerated combinations across providers; nil uses SharedCache()
+	Cache *CombinationsCache
+	// Palette lists the colors Get accepts; nil accepts any positive color
+	Palette map[int]string
+}
+
+// colorKey identifies one cached color slice in a MemoryBudget
+type colorKey struct {
+	provider *CombinationsProviderImpl
+	color    int
+}
+
 // NewCombinationsProvider creates a new lazy combinations provider
 func NewCombinationsProvider(clues []types.ClueItem, size int) *CombinationsProviderImpl {
+	return NewCombinationsProviderWithOptions(clues, size, ProviderOptions{})
+}
+
+// NewCombinationsProviderWithOptions creates a lazy combinations provider that
+// shares the memory budget and semaphore given in opts
+func NewCombinationsProviderWithOptions(clues []types.ClueItem, size int, opts ProviderOptions) *CombinationsProviderImpl {
 	return &CombinationsProviderImpl{
 		clues:         clues,
 		size:          size,
 		generated:     make(map[int]bool),
 		combosByColor: make(map[int][]*types.Bitset),
+		versions:      make(map[int]int),
+		budget:        opts.Budget,
+		sem:           opts.Semaphore,
+		cache:         opts.Cache,
+		palette:       opts.Palette,
+		limit:         opts.MaxCombinations,
+		err:           ValidateClues(clues, size),
 	}
 }
 
 // Get returns combinations for the specified color, generating them lazily if needed
 func (cp *CombinationsProviderImpl) Get(color int) ([]*types.Bitset, error) {
+	return cp.GetContext(context.Background(), color)
+}
+
+// GetContext is Get with cancellation. Errors wrap combinatorics.ErrInvalidClue,
+// ErrInfeasibleLine (including when facts eliminated every combination),
+// ErrEnumerationLimit or ErrBudgetExceeded; cancellation returns ctx.Err().
+func (cp *CombinationsProviderImpl) GetContext(ctx context.Context, color int) ([]*types.Bitset, error) {
+	if cp.err != nil {
+		return nil, cp.err
+	}
+	if err := checkColor(cp.palette, color); err != nil {
+		return nil, err
+	}
+
 	cp.mu.RLock()
 	if cp.generated[color] {
 		result := cp.combosByColor[color]
 		cp.mu.RUnlock()
-		return result, nil
+		if cp.budget != nil {
+			cp.budget.Touch(colorKey{cp, color})
+		}
+		return nonEmpty(result)
 	}
 	cp.mu.RUnlock()
 
+	estimate := countColorCombinations(cp.clues, cp.size, color)
+	if err := checkEnumerationLimit(estimate, cp.limit); err != nil {
+		return nil, err
+	}
+	if cp.budget != nil && !cp.budget.Fits(bytesFor(estimate, combinatorics.BitsetBytes(cp.size))) {
+		return nil, combinatorics.ErrBudgetExceeded
+	}
+
 	// Generate combinations outside of read lock
-	rawCombos := GenerateColorCombinations(cp.clues, cp.size, color)
+	rawCombos, err := cacheOrShared(cp.cache).ColorCombinations(ctx, cp.clues, cp.size, color, cp.sem)
+	if err != nil {
+		return nil, err
+	}
 
 	cp.mu.Lock()
-	defer cp.mu.Unlock()
 
 	// Double-check in case another goroutine generated it while we were waiting
 	if cp.generated[color] {
-		return cp.combosByColor[color], nil
+		result := cp.combosByColor[color]
+		cp.mu.Unlock()
+		return nonEmpty(result)
 	}
 
-	// Convert []*big.Int to []*types.Bitset
-	bitsets := make([]*types.Bitset, len(rawCombos))
-	for i, combo := range rawCombos {
-		bitsets[i] = types.NewBitset(combo)
+	// Convert []*big.Int to []*types.Bitset, dropping combos that conflict
+	// with facts seen by an earlier CrossReference
+	bitsets := make([]*types.Bitset, 0, len(rawCombos))
+	for _, combo := range rawCombos {
+		bitset := types.NewBitset(combo)
+		if cp.filled == nil || colorComboFits(bitset, color, cp.filled, cp.empty) {
+			bitsets = append(bitsets, bitset)
+		}
 	}
 
 	cp.combosByColor[color] = bitsets
 	cp.generated[color] = true
+	cp.versions[color]++
+	version := cp.versions[color]
+	cp.mu.Unlock()
+
+	if err := cp.reserve(color, version, len(bitsets)); err != nil {
+		return nil, err
+	}
+	return nonEmpty(bitsets)
+}
+
+// CrossReference drops generated combinations that conflict with the known
+// facts. Colors generated later are filtered against the same facts. When a
+// color loses every combination the error wraps combinatorics.ErrInfeasibleLine.
+func (cp *CombinationsProviderImpl) CrossReference(ctx context.Context, filled map[int]*types.Bitset, empty *types.Bitset) (bool, error) {
+	if cp.err != nil {
+		return false, cp.err
+	}
+
+	cp.mu.Lock()
+
+	cp.filled = filled
+	cp.empty = empty
+
+	changed := false
+	exhausted := false
+	shrunk := make(map[int]int)
+	for color, combos := range cp.combosByColor {
+		kept := combos[:0:0]
+		for _, combo := range combos {
+			if colorComboFits(combo, color, filled, empty) {
+				kept = append(kept, combo)
+			}
+		}
+		if len(kept) != len(combos) {
+			cp.combosByColor[color] = kept
+			cp.versions[color]++
+			shrunk[color] = len(kept)
+			changed = true
+			exhausted = exhausted || len(kept) == 0
+		}
+	}
+	versions := make(map[int]int, len(shrunk))
+	for color := range shrunk {
+		versions[color] = cp.versions[color]
+	}
+	cp.mu.Unlock()
+
+	for color, count := range shrunk {
+		if err := cp.reserve(color, versions[color], count); err != nil {
+			return changed, err
+		}
+	}
+	if exhausted {
+		return changed, errNoCombinations
+	}
+	return changed, nil
+}
+
+// Clone copies the generated combinations and the facts they were filtered
+// against. Combination bitsets are never modified, so only the slices are
+// shared. When the budget cannot hold the copy's cache, the copy regenerates
+// and refilters its colors on the next access.
+func (cp *CombinationsProviderImpl) Clone() combinatorics.CombinationsProvider {
+	out := &CombinationsProviderImpl{
+		clues:         cp.clues,
+		size:          cp.size,
+		generated:     make(map[int]bool),
+		combosByColor: make(map[int][]*types.Bitset),
+		versions:      make(map[int]int),
+		budget:        cp.budget,
+		sem:           cp.sem,
+		cache:         cp.cache,
+		palette:       cp.palette,
+		limit:         cp.limit,
+		err:           cp.err,
+	}
+	cp.mu.RLock()
+	out.filled, out.empty = cp.filled, cp.empty
+	for color, combos := range cp.combosByColor {
+		out.combosByColor[color] = combos
+		out.generated[color] = true
+		out.versions[color] = 1
+	}
+	cp.mu.RUnlock()
+
+	for color, combos := range out.combosByColor {
+		if err := out.reserve(color, 1, len(combos)); err != nil {
+			delete(out.combosByColor, color)
+			delete(out.generated, color)
+		}
+	}
+	return out
+}
+
+// Release drops every cached color slice and its budget reservation. Facts
+// seen so far are kept, so regenerated slices are filtered against them.
+func (cp *CombinationsProviderImpl) Release() {
+	cp.mu.Lock()
+	colors := make([]int, 0, len(cp.combosByColor))
+	for color := range cp.combosByColor {
+		colors = append(colors, color)
+		cp.versions[color]++
+	}
+	cp.generated = make(map[int]bool)
+	cp.combosByColor = make(map[int][]*types.Bitset)
+	cp.mu.Unlock()
+
+	if cp.budget != nil {
+		for _, color := range colors {
+			cp.budget.Release(colorKey{cp, color})
+		}
+	}
+}
+
+// nonEmpty turns an empty combination slice into an infeasibility error
+func nonEmpty(combos []*types.Bitset) ([]*types.Bitset, error) {
+	if len(combos) == 0 {
+		return combos, errNoCombinations
+	}
+	return combos, nil
+}
+
+// reserve accounts a cached color slice against the budget. The eviction
+// callback only drops the slice if it has not been replaced since.
+func (cp *CombinationsProviderImpl) reserve(color, version, count int) error {
+	if cp.budget == nil {
+		return nil
+	}
+	bytes := int64(count) * combinatorics.BitsetBytes(cp.size)
+	return cp.budget.Reserve(colorKey{cp, color}, bytes, func() {
+		cp.mu.Lock()
+		defer cp.mu.Unlock()
+		if cp.versions[color] == version {
+			delete(cp.combosByColor, color)
+			delete(cp.generated, color)
+		}
+	})
+}
 
-	return bitsets, nil
+// countColorCombinations returns how many masks GenerateColorCombinations would
+// produce for color without enumerating them. Target blocks are separated by
+// fixed-length gaps, so the count is C(slack+blocks, blocks).
+func countColorCombinations(clues []types.ClueItem, size int, colorID int) *big.Int {
+	blocks := 0
+	for _, clue := range clues {
+		if clue.ColorID == colorID {
+			blocks++
+		}
+	}
+	return placementCount(size-minLineLength(clues), blocks)
+}
+
+// minLineLength returns the minimal number of cells the clues occupy
+func minLineLength(clues []types.ClueItem) int {
+	total := 0
+	for i := range clues {
+		total += clues[i].Clue
+		if i > 0 && clues[i-1].ColorID == clues[i].ColorID {
+			total++
+		}
+	}
+	return total
+}
+
+// placementCount returns the number of ways to place blocks runs with slack
+// free cells, C(slack+blocks, blocks), or zero when slack is negative.
+func placementCount(slack, blocks int) *big.Int {
+	if slack < 0 {
+		return big.NewInt(0)
+	}
+	return new(big.Int).Binomial(int64(slack+blocks), int64(blocks))
+}
+
+// bytesFor multiplies a combination count by a per-item size, saturating at MaxInt64
+func bytesFor(count *big.Int, perItem int64) int64 {
+	total := new(big.Int).Mul(count, big.NewInt(perItem))
+	if !total.IsInt64() {
+		return math.MaxInt64
+	}
+	return total.Int64()
+}
+
+// colorComboFits reports whether a single-color combination agrees with the facts:
+// it must cover every cell known to be its color and avoid empty cells and cells
+// known to be another color.
+func colorComboFits(combo *types.Bitset, color int, filled map[int]*types.Bitset, empty *types.Bitset) bool {
+	scratch := new(big.Int)
+	if empty != nil && scratch.And(combo.Int, empty.Int).Sign() != 0 {
+		return false
+	}
+	for c, known := range filled {
+		if c == color {
+			if scratch.AndNot(known.Int, combo.Int).Sign() != 0 {
+				return false
+			}
+		} else if scratch.And(combo.Int, known.Int).Sign() != 0 {
+			return false
+		}
+	}
+	return true
 }
 
 // GenerateColorCombinations enumerates combinations for a single color by
@@ -65,8 +348,18 @@ func (cp *CombinationsProviderImpl) Get(color int) ([]*types.Bitset, error) {
 // other-color clues as fixed-length separators. This dramatically reduces the
 // search space compared to enumerating all colors.
 func GenerateColorCombinations(clues []types.ClueItem, size int, colorID int) []*big.Int {
-	if size <= 0 || len(clues) == 0 {
-		return []*big.Int{}
+	result, _ := GenerateColorCombinationsContext(context.Background(), clues, size, colorID, nil)
+	return result
+}
+
+// GenerateColorCombinationsContext is GenerateColorCombinations with cancellation.
+// Branches over the first block's start position run in parallel only while sem
+// has free slots; otherwise they run inline on the calling goroutine. A nil sem
+// uses a process-wide semaphore sized from GOMAXPROCS. It returns ctx.Err() as
+// soon as the enumeration notices cancellation.
+func GenerateColorCombinationsContext(ctx context.Context, clues []types.ClueItem, size int, colorID int, sem *combinatorics.Semaphore) ([]*big.Int, error) {
+	if size <= 0 {
+		return []*big.Int{}, nil
 	}
 
 	// Quick feasibility: minimal required cells across entire line
@@ -79,7 +372,7 @@ func GenerateColorCombinations(clues []types.ClueItem, size int, colorID int) []
 		}
 	}
 	if minRequired > size {
-		return []*big.Int{}
+		return []*big.Int{}, nil
 	}
 
 	// Collect target-color blocks (lengths and original indices)
@@ -94,7 +387,7 @@ func GenerateColorCombinations(clues []types.ClueItem, size int, colorID int) []
 
 	// If no target-color clues, there is exactly one mask: all zeros (if feasible)
 	if len(targetIdx) == 0 {
-		return []*big.Int{big.NewInt(0)}
+		return []*big.Int{big.NewInt(0)}, nil
 	}
 
 	m := len(targetIdx)
@@ -163,7 +456,7 @@ func GenerateColorCombinations(clues []types.ClueItem, size int, colorID int) []
 		latest[k] = size - suffix - tailMin[k]
 		if latest[k] < earliest[k] {
 			// No feasible placement
-			return []*big.Int{}
+			return []*big.Int{}, nil
 		}
 	}
 
@@ -176,12 +469,18 @@ func GenerateColorCombinations(clues []types.ClueItem, size int, colorID int) []
 	}
 
 	// DFS over target blocks only. Iterate start from min to max to yield
-	// masks in descending numeric order (leftmost bits first).
-	var dfs func(k int, prevStart int, mask *big.Int, out *[]*big.Int)
-	dfs = func(k int, prevStart int, mask *big.Int, out *[]*big.Int) {
+	// masks in descending numeric order (leftmost bits first). Each goroutine
+	// passes its own step counter; cancellation is polled every
+	// cancelCheckInterval nodes and unwinds the search by returning false.
+	var dfs func(k int, prevStart int, mask *big.Int, out *[]*big.Int, steps *int) bool
+	dfs = func(k int, prevStart int, mask *big.Int, out *[]*big.Int, steps *int) bool {
+		*steps++
+		if *steps%cancelCheckInterval == 0 && ctx.Err() != nil {
+			return false
+		}
 		if k == m {
 			*out = append(*out, new(big.Int).Set(mask))
-			return
+			return true
 		}
 
 		minStart := earliest[k]
@@ -193,7 +492,7 @@ func GenerateColorCombinations(clues []types.ClueItem, size int, colorID int) []
 		}
 		maxStart := latest[k]
 		if maxStart < minStart {
-			return
+			return true
 		}
 
 		for s := minStart; s <= maxStart; s++ {
@@ -203,9 +502,22 @@ func GenerateColorCombinations(clues []types.ClueItem, size int, colorID int) []
 			block := runMask(size, s, targetLen[k])
 			mask.Or(mask, block)
 			nextPrev := s
-			dfs(k+1, nextPrev, mask, out)
+			ok := dfs(k+1, nextPrev, mask, out, steps)
 			mask.AndNot(mask, block)
+			if !ok {
+				return false
+			}
 		}
+		return true
+	}
+
+	// branch enumerates everything below the first block starting at start
+	branch := func(start int) []*big.Int {
+		local := make([]*big.Int, 0)
+		mask := runMask(size, start, targetLen[0])
+		steps := 0
+		dfs(1, start, mask, &local, &steps)
+		return local
 	}
 
 	// Top-level parallelization over the first block's start positions,
@@ -213,60 +525,46 @@ func GenerateColorCombinations(clues []types.ClueItem, size int, colorID int) []
 	// the final descending numeric ordering of masks.
 	min0, max0 := earliest[0], latest[0]
 	if min0 > max0 {
-		return []*big.Int{}
+		return []*big.Int{}, nil
 	}
 	choices := max0 - min0 + 1
 
-	// Small ranges: run single-threaded for lower overhead
-	if choices <= 3 {
-		result := make([]*big.Int, 0)
-		mask := big.NewInt(0)
-		for s := min0; s <= max0; s++ {
-			if !canPlace(0, s) {
-				continue
-			}
-			block := runMask(size, s, targetLen[0])
-			mask.Or(mask, block)
-			dfs(1, s, mask, &result)
-			mask.AndNot(mask, block)
-		}
-		return result
+	if sem == nil {
+		sem = defaultSemaphore
 	}
 
-	// Parallel path
-	workers := runtime.GOMAXPROCS(0)
-	if workers < 4 {
-		workers = 4
-	}
-	if workers > choices {
-		workers = choices
-	}
-	sem := make(chan struct{}, workers)
 	parts := make([][]*big.Int, choices)
 	var wg sync.WaitGroup
 
 	for idx := 0; idx < choices; idx++ {
+		if err := ctx.Err(); err != nil {
+			wg.Wait()
+			return nil, err
+		}
 		s := min0 + idx
 		if !canPlace(0, s) {
 			// keep empty slice
 			continue
 		}
+		// Small ranges and a saturated semaphore run on the calling goroutine,
+		// which already holds a slot of its own.
+		if choices <= 3 || !sem.TryAcquire() {
+			parts[idx] = branch(s)
+			continue
+		}
 		wg.Add(1)
-		sem <- struct{}{}
 		go func(localIdx, start int) {
 			defer wg.Done()
-			defer func() { <-sem }()
-			mask := big.NewInt(0)
-			block := runMask(size, start, targetLen[0])
-			mask.Or(mask, block)
-			local := make([]*big.Int, 0)
-			dfs(1, start, mask, &local)
-			mask.AndNot(mask, block)
-			parts[localIdx] = local
+			defer sem.Release()
+			parts[localIdx] = branch(start)
 		}(idx, s)
 	}
 	wg.Wait()
 
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	// Merge in ascending start order to maintain descending numeric order overall
 	result := make([]*big.Int, 0)
 	for i := 0; i < choices; i++ {
@@ -275,7 +573,7 @@ func GenerateColorCombinations(clues []types.ClueItem, size int, colorID int) []
 		}
 		result = append(result, parts[i]...)
 	}
-	return result
+	return result, nil
 }
 
 // runMask returns a mask with a contiguous run of 'length' ones starting at 'start' (0-based from left).
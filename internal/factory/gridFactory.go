@@ -2,14 +2,26 @@ package factory
 
 import (
 	"math/big"
+	"nonogram-solver/internal/combinatorics"
 	"nonogram-solver/internal/types"
 )
 
 // CreateGridFromClues creates a Grid from extracted clues
 func CreateGridFromClues(clues map[types.LineID][]types.ClueItem, width, height int, colorMap map[int]string) types.Grid {
+	return CreateGridFromCluesWithOptions(clues, width, height, colorMap, ProviderOptions{})
+}
+
+// CreateGridFromCluesWithOptions creates a Grid whose line providers share the
+// memory budget and generation semaphore in opts. Unless opts sets a palette,
+// providers accept the colors in colorMap.
+func CreateGridFromCluesWithOptions(clues map[types.LineID][]types.ClueItem, width, height int, colorMap map[int]string, opts ProviderOptions) types.Grid {
+	if opts.Palette == nil {
+		opts.Palette = colorMap
+	}
 	grid := types.Grid{
-		Rows: make([]*types.Line, height),
-		Cols: make([]*types.Line, width),
+		Rows:   make([]*types.Line, height),
+		Cols:   make([]*types.Line, width),
+		Colors: colorMap,
 	}
 
 	// Create rows
@@ -25,7 +37,7 @@ func CreateGridFromClues(clues map[types.LineID][]types.ClueItem, width, height
 				FilledByColor: make(map[int]*types.Bitset),
 				EmptyMask:     types.NewBitset(big.NewInt(0)),
 			},
-			Combinations: NewCombinationsProvider(clueList, width),
+			Combinations: NewLineProvider(clueList, width, opts),
 		}
 	}
 
@@ -42,9 +54,21 @@ func CreateGridFromClues(clues map[types.LineID][]types.ClueItem, width, height
 				FilledByColor: make(map[int]*types.Bitset),
 				EmptyMask:     types.NewBitset(big.NewInt(0)),
 			},
-			Combinations: NewCombinationsProvider(clueList, height),
+			Combinations: NewLineProvider(clueList, height, opts),
 		}
 	}
 
 	return grid
 }
+
+// NewLineProvider picks the combinations strategy for a line. Multi-color lines
+// use whole-line arrangements so their per-color projections stay mutually
+// consistent; single-color lines keep the cheaper per-color generator.
+func NewLineProvider(clues []types.ClueItem, size int, opts ProviderOptions) combinatorics.CombinationsProvider {
+	for _, clue := range clues {
+		if clue.ColorID != clues[0].ColorID {
+			return NewArrangementsProviderWithOptions(clues, size, opts)
+		}
+	}
+	return NewCombinationsProviderWithOptions(clues, size, opts)
+}
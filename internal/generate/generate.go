@@ -0,0 +1,178 @@
+package generate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+
+	"nonogram-solver/internal/factory"
+	"nonogram-solver/internal/puzzle"
+	"nonogram-solver/internal/solver"
+)
+
+// Palette holds the colors given to generated puzzles, in color ID order
+var Palette = []string{
+	"#d32f2f", "#1976d2", "#388e3c", "#fbc02d", "#7b1fa2",
+	"#f57c00", "#0097a7", "#5d4037", "#c2185b", "#455a64",
+}
+
+// ErrGaveUp is returned when no acceptable puzzle was found within MaxFlips
+var ErrGaveUp = errors.New("no unique puzzle found")
+
+// Options configures a generated puzzle
+type Options struct {
+	Width  int
+	Height int
+	// Colors is the palette size; 1 makes a black and white puzzle
+	Colors int
+	// Density is the fraction of cells filled initially, between 0 and 1
+	Density float64
+	Seed    int64
+	// Technique is the hardest technique set a solver may need; zero means
+	// solver.TechniqueLine, so the puzzle is line solvable
+	Technique solver.Technique
+	// MaxFlips bounds the cells changed before giving up; zero means four per cell
+	MaxFlips int
+	// Solver and Providers configure the uniqueness checks
+	Solver    solver.Options
+	Providers factory.ProviderOptions
+}
+
+// Result is a generated puzzle with its rating
+type Result struct {
+	Puzzle *puzzle.Puzzle
+	Rating solver.Rating
+	// Flips counts the cells changed after the random start
+	Flips int
+}
+
+// Generate fills a random grid and derives its clues. While the puzzle is not
+// unique or needs a technique harder than opts.Technique, one cell the line
+// solver could not decide is flipped and the puzzle is rated again. The same
+// options always produce the same puzzle.
+func Generate(ctx context.Context, opts Options) (Result, error) {
+	if err := opts.validate(); err != nil {
+		return Result{}, err
+	}
+	if opts.Technique == solver.TechniqueNone {
+		opts.Technique = solver.TechniqueLine
+	}
+	if opts.MaxFlips == 0 {
+		opts.MaxFlips = 4 * opts.Width * opts.Height
+	}
+	// Every flip builds fresh grids, so enumerated combinations are never
+	// reused. A limit of one sends each line to the non-enumerating line
+	// solver, which deduces the same cells.
+	opts.Providers.MaxCombinations = 1
+	opts.Solver.MaxCombinations = 1
+	colors := map[int]string{1: "#000000"}
+	if opts.Colors > 1 {
+		colors = make(map[int]string, opts.Colors)
+		for i := 0; i < opts.Colors; i++ {
+			colors[i+1] = Palette[i]
+		}
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	cells := make([][]int, opts.Height)
+	for r := range cells {
+		cells[r] = make([]int, opts.Width)
+		for c := range cells[r] {
+			if rng.Float64() < opts.Density {
+				cells[r][c] = 1 + rng.Intn(opts.Colors)
+			}
+		}
+	}
+
+	id := fmt.Sprintf("generated-%dx%d-%d", opts.Width, opts.Height, opts.Seed)
+	for flips := 0; ; flips++ {
+		p := puzzle.FromCells(id, cells, colors)
+		undecided, err := undecidedCells(ctx, p, opts)
+		if err != nil {
+			return Result{}, err
+		}
+
+		// Line propagation is cheap next to a full rating, and when it stalls
+		// only a harder technique set could still accept the puzzle
+		if undecided == nil || opts.Technique > solver.TechniqueLine {
+			g := p.Grid(opts.Providers)
+			rating, err := solver.RateWithin(ctx, &g, opts.Technique, opts.Solver)
+			switch {
+			case err != nil:
+				return Result{}, err
+			case rating.Status == solver.Timeout:
+				return Result{}, ctx.Err()
+			case rating.Status == solver.Contradiction:
+				return Result{}, fmt.Errorf("generated clues are unsolvable: %v", rating.Reason)
+			case rating.Status == solver.Solved && rating.Unique:
+				return Result{Puzzle: p, Rating: rating, Flips: flips}, nil
+			}
+		}
+		if flips == opts.MaxFlips {
+			return Result{}, fmt.Errorf("%w after %d flips", ErrGaveUp, flips)
+		}
+
+		// Flip a cell the line solver could not decide, or any cell when the
+		// puzzle was line solvable but too hard for opts.Technique
+		if undecided == nil {
+			undecided = allCells(opts.Width, opts.Height)
+		}
+		cell := undecided[rng.Intn(len(undecided))]
+		cells[cell[0]][cell[1]] = flip(rng, cells[cell[0]][cell[1]], opts.Colors)
+	}
+}
+
+func (o Options) validate() error {
+	switch {
+	case o.Width < 1 || o.Height < 1:
+		return fmt.Errorf("size %dx%d must be at least 1x1", o.Width, o.Height)
+	case o.Colors < 1 || o.Colors > len(Palette):
+		return fmt.Errorf("colors %d must be between 1 and %d", o.Colors, len(Palette))
+	case o.Density <= 0 || o.Density >= 1:
+		return fmt.Errorf("density %v must be between 0 and 1", o.Density)
+	}
+	return nil
+}
+
+// undecidedCells returns the cells line propagation leaves unknown
+func undecidedCells(ctx context.Context, p *puzzle.Puzzle, opts Options) ([][2]int, error) {
+	g := p.Grid(opts.Providers)
+	solverOpts := opts.Solver
+	solverOpts.Search = false
+	result, err := solver.Solve(ctx, &g, solverOpts)
+	if err != nil {
+		return nil, err
+	}
+	if result.Status == solver.Timeout {
+		return nil, ctx.Err()
+	}
+	known, _ := puzzle.Cells(&g)
+	var unknown [][2]int
+	for r, row := range known {
+		for c, color := range row {
+			if color < 0 {
+				unknown = append(unknown, [2]int{r, c})
+			}
+		}
+	}
+	return unknown, nil
+}
+
+func allCells(width, height int) [][2]int {
+	cells := make([][2]int, 0, width*height)
+	for r := 0; r < height; r++ {
+		for c := 0; c < width; c++ {
+			cells = append(cells, [2]int{r, c})
+		}
+	}
+	return cells
+}
+
+// flip empties a filled cell or fills an empty one with a random color
+func flip(rng *rand.Rand, color, colors int) int {
+	if color != 0 {
+		return 0
+	}
+	return 1 + rng.Intn(colors)
+}
@@ -1,7 +1,12 @@
 package grid
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+
+	"nonogram-solver/internal/combinatorics"
+	"nonogram-solver/internal/factory"
 	"nonogram-solver/internal/types"
 )
 
@@ -72,3 +77,60 @@ func (g *GridOperations) ValidateGrid() error {
 
 	return nil
 }
+
+// ValidateClues checks every line's clues and reports all broken lines at once.
+// Each line problem is a *types.LineError wrapping combinatorics.ErrInvalidClue or
+// ErrInfeasibleLine. When colors is non-nil, clue colors must appear in it. Rows
+// and columns must also agree on the number of cells of each color.
+func (g *GridOperations) ValidateClues(colors map[int]string) error {
+	var errs []error
+	rowCells := make(map[int]int)
+	colCells := make(map[int]int)
+
+	check := func(line *types.Line, totals map[int]int) {
+		if err := factory.ValidateClues(line.Clues, line.Length); err != nil {
+			errs = append(errs, &types.LineError{ID: line.ID, Err: err})
+			return
+		}
+		for i, clue := range line.Clues {
+			if colors != nil {
+				if _, ok := colors[clue.ColorID]; !ok {
+					errs = append(errs, &types.LineError{
+						ID:  line.ID,
+						Err: fmt.Errorf("%w: clue %d has unknown color %d", combinatorics.ErrInvalidClue, i, clue.ColorID),
+					})
+					return
+				}
+			}
+			totals[clue.ColorID] += clue.Clue
+		}
+	}
+
+	for _, row := range g.Grid.Rows {
+		check(row, rowCells)
+	}
+	for _, col := range g.Grid.Cols {
+		check(col, colCells)
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	colorIDs := make([]int, 0, len(rowCells)+len(colCells))
+	for color := range rowCells {
+		colorIDs = append(colorIDs, color)
+	}
+	for color := range colCells {
+		if _, ok := rowCells[color]; !ok {
+			colorIDs = append(colorIDs, color)
+		}
+	}
+	sort.Ints(colorIDs)
+	for _, color := range colorIDs {
+		if rowCells[color] != colCells[color] {
+			errs = append(errs, fmt.Errorf("%w: rows have %d cells of color %d, columns have %d",
+				combinatorics.ErrInfeasibleLine, rowCells[color], color, colCells[color]))
+		}
+	}
+	return errors.Join(errs...)
+}
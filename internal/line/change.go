@@ -0,0 +1,76 @@
+package line
+
+import (
+	"fmt"
+	"sort"
+
+	"nonogram-solver/internal/types"
+)
+
+// ErrConflict is returned when a deduction contradicts an existing fact
+var ErrConflict = types.ErrConflict
+
+// Change is a single deduced cell: Color 0 means the position must be empty
+type Change struct {
+	Pos   int
+	Color int
+}
+
+// Apply records the changes in the line's facts. It returns an error wrapping
+// ErrConflict if a position is already known to hold something else.
+func Apply(l *types.Line, changes []Change) error {
+	for _, change := range changes {
+		if err := Mark(l.Facts, change.Pos, change.Color); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Mark sets a single position of facts, failing if it is already known to
+// hold a different state. Marking a known position with its own state is a no-op.
+func Mark(facts *types.Facts, pos, color int) error {
+	if known, ok := facts.ColorAt(pos); ok {
+		if known != color {
+			return fmt.Errorf("%w: position %d is %s, deduced %s", ErrConflict, pos+1, stateName(known), stateName(color))
+		}
+		return nil
+	}
+	if color == 0 {
+		facts.MarkEmpty(pos)
+	} else {
+		facts.MarkFilled(pos, color)
+	}
+	return nil
+}
+
+// ClueColors returns the distinct colors used by the clues, in ascending order
+func ClueColors(clues []types.ClueItem) []int {
+	seen := make(map[int]bool)
+	colors := make([]int, 0)
+	for _, clue := range clues {
+		if !seen[clue.ColorID] {
+			seen[clue.ColorID] = true
+			colors = append(colors, clue.ColorID)
+		}
+	}
+	sort.Ints(colors)
+	return colors
+}
+
+// IsSolved reports whether every position of the line is known
+func IsSolved(l *types.Line) bool {
+	for i := 0; i < l.Length; i++ {
+		if !l.Facts.IsKnown(i) {
+			return false
+		}
+	}
+	return true
+}
+
+func stateName(color int) string {
+	if color == 0 {
+		return "empty"
+	}
+	return fmt.Sprintf("color %d", color)
+}
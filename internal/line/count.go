@@ -0,0 +1,65 @@
+package line
+
+import (
+	"math/big"
+
+	"nonogram-solver/internal/types"
+)
+
+// Count returns how many placements of the clues agree with the line's facts,
+// using the same recurrence as Possible with counts instead of booleans. It is
+// exact for multi-color lines and does not enumerate, so it works for lines of
+// any size.
+func Count(l *types.Line) *big.Int {
+	n, k := l.Length, len(l.Clues)
+	clues := l.Clues
+	fits := func(c, from, to int) bool {
+		for i := from; i < to; i++ {
+			if known, ok := l.Facts.ColorAt(i); ok && known != c {
+				return false
+			}
+		}
+		return true
+	}
+
+	// ways[i][j]: placements of clues [0, j) within positions [0, i), every
+	// other position in that range empty
+	ways := make([][]*big.Int, n+1)
+	for i := range ways {
+		ways[i] = make([]*big.Int, k+1)
+		for j := range ways[i] {
+			ways[i][j] = new(big.Int)
+		}
+	}
+	ways[0][0].SetInt64(1)
+	for i := 1; i <= n; i++ {
+		for j := 0; j <= k; j++ {
+			// Position i-1 is empty...
+			if fits(0, i-1, i) {
+				ways[i][j].Add(ways[i][j], ways[i-1][j])
+			}
+			// ...or clue j-1 ends there
+			if j == 0 {
+				continue
+			}
+			s := i - clues[j-1].Clue
+			if s < 0 || !fits(clues[j-1].ColorID, s, i) {
+				continue
+			}
+			if j > 1 && clues[j-2].ColorID == clues[j-1].ColorID {
+				if s >= 1 && fits(0, s-1, s) {
+					ways[i][j].Add(ways[i][j], ways[s-1][j-1])
+				}
+			} else {
+				ways[i][j].Add(ways[i][j], ways[s][j-1])
+			}
+		}
+	}
+	return ways[n][k]
+}
+
+// Forced returns the cells the clues force on an otherwise unknown line, the
+// classic overlap deduction
+func Forced(clues []types.ClueItem, length int) ([]Change, error) {
+	return Settle(&types.Line{Length: length, Clues: clues, Facts: types.NewFacts()})
+}
@@ -0,0 +1,14 @@
+package line
+
+import (
+	"context"
+
+	"nonogram-solver/internal/types"
+)
+
+// CrossReference eliminates the line's combinations that conflict with its
+// current facts. It reports whether any combination was dropped.
+func CrossReference(ctx context.Context, l *types.Line) (bool, error) {
+	filled, empty := l.Facts.Masks(l.Length)
+	return l.Combinations.CrossReference(ctx, filled, empty)
+}
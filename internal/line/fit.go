@@ -0,0 +1,119 @@
+package line
+
+import (
+	"fmt"
+
+	"nonogram-solver/internal/combinatorics"
+	"nonogram-solver/internal/types"
+)
+
+// fitTable holds the forward and backward dynamic programming tables over
+// (position, clue) shared by Possible and Simple
+type fitTable struct {
+	n, k   int
+	clues  []types.ClueItem
+	states []int // 0 followed by the clue colors
+	// blocked[c][i+1] counts positions < i+1 that cannot hold color c
+	// (0 stands for empty), so a run check is a prefix-sum difference.
+	blocked map[int][]int
+	// fwd[i][j]: clues [0, j) fit in positions [0, i)
+	// bwd[i][j]: clues [j, k) fit in positions [i, n)
+	fwd [][]bool
+	bwd [][]bool
+}
+
+// newFitTable fills the tables for the line's facts. It returns an error
+// wrapping combinatorics.ErrInfeasibleLine when no placement is consistent
+// with the facts.
+func newFitTable(l *types.Line) (*fitTable, error) {
+	n, k := l.Length, len(l.Clues)
+	t := &fitTable{
+		n:       n,
+		k:       k,
+		clues:   l.Clues,
+		states:  append([]int{0}, ClueColors(l.Clues)...),
+		blocked: make(map[int][]int),
+		fwd:     make([][]bool, n+1),
+		bwd:     make([][]bool, n+2),
+	}
+	for _, c := range t.states {
+		counts := make([]int, n+1)
+		for i := 0; i < n; i++ {
+			counts[i+1] = counts[i]
+			if known, ok := l.Facts.ColorAt(i); ok && known != c {
+				counts[i+1]++
+			}
+		}
+		t.blocked[c] = counts
+	}
+	for i := range t.fwd {
+		t.fwd[i] = make([]bool, k+1)
+	}
+	for i := range t.bwd {
+		t.bwd[i] = make([]bool, k+1)
+	}
+
+	clues, fwd, bwd := t.clues, t.fwd, t.bwd
+	fwd[0][0] = true
+	for i := 1; i <= n; i++ {
+		for j := 0; j <= k; j++ {
+			if t.canBe(0, i-1, i) && fwd[i-1][j] {
+				fwd[i][j] = true
+				continue
+			}
+			if j > 0 {
+				length := clues[j-1].Clue
+				s := i - length
+				if s >= 0 && t.canBe(clues[j-1].ColorID, s, i) && t.prefixOK(j-1, s) {
+					fwd[i][j] = true
+				}
+			}
+		}
+	}
+	if !fwd[n][k] {
+		return nil, fmt.Errorf("%w: no placement agrees with the known facts", combinatorics.ErrInfeasibleLine)
+	}
+
+	bwd[n][k] = true
+	for i := n - 1; i >= 0; i-- {
+		for j := k; j >= 0; j-- {
+			if t.canBe(0, i, i+1) && bwd[i+1][j] {
+				bwd[i][j] = true
+				continue
+			}
+			if j < k {
+				e := i + clues[j].Clue
+				if e <= n && t.canBe(clues[j].ColorID, i, e) && t.suffixOK(j, e) {
+					bwd[i][j] = true
+				}
+			}
+		}
+	}
+	return t, nil
+}
+
+// canBe reports whether positions [from, to) may all hold color c
+func (t *fitTable) canBe(c, from, to int) bool {
+	return t.blocked[c][to]-t.blocked[c][from] == 0
+}
+
+// prefixOK: clue j may start at s given what precedes it
+func (t *fitTable) prefixOK(j, s int) bool {
+	if j > 0 && t.clues[j-1].ColorID == t.clues[j].ColorID {
+		return s >= 1 && t.canBe(0, s-1, s) && t.fwd[s-1][j]
+	}
+	return t.fwd[s][j]
+}
+
+// suffixOK: clue j may end just before e given what follows it
+func (t *fitTable) suffixOK(j, e int) bool {
+	if j+1 < t.k && t.clues[j].ColorID == t.clues[j+1].ColorID {
+		return e < t.n && t.canBe(0, e, e+1) && t.bwd[e+1][j+1]
+	}
+	return t.bwd[e][j+1]
+}
+
+// fits reports whether clue j can start at s in some complete placement
+func (t *fitTable) fits(j, s int) bool {
+	return s >= 0 && s+t.clues[j].Clue <= t.n && t.canBe(t.clues[j].ColorID, s, s+t.clues[j].Clue) && t.prefixOK(j, s) && t.suffixOK(j, s+t.clues[j].Clue)
+}
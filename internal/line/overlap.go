@@ -0,0 +1,52 @@
+package line
+
+import (
+	"context"
+	"math/big"
+
+	"nonogram-solver/internal/types"
+)
+
+// Overlap derives new facts from the line's surviving combinations. A position
+// covered by every combination of a color must be that color; a position covered
+// by no combination of any color must be empty. Only positions not already known
+// are returned, in ascending order.
+func Overlap(ctx context.Context, l *types.Line) ([]Change, error) {
+	colors := ClueColors(l.Clues)
+	union := big.NewInt(0)
+	mustFill := make(map[int]int) // position -> color
+
+	for _, color := range colors {
+		combos, err := l.Combinations.GetContext(ctx, color)
+		if err != nil {
+			return nil, err
+		}
+		intersection := new(big.Int).Set(combos[0].Int)
+		for _, combo := range combos {
+			intersection.And(intersection, combo.Int)
+			union.Or(union, combo.Int)
+		}
+		for bit := 0; bit < l.Length; bit++ {
+			if intersection.Bit(bit) == 1 {
+				pos := l.Length - 1 - bit
+				if other, ok := mustFill[pos]; ok && other != color {
+					return nil, ErrConflict
+				}
+				mustFill[pos] = color
+			}
+		}
+	}
+
+	var changes []Change
+	for pos := 0; pos < l.Length; pos++ {
+		if l.Facts.IsKnown(pos) {
+			continue
+		}
+		if color, ok := mustFill[pos]; ok {
+			changes = append(changes, Change{Pos: pos, Color: color})
+		} else if union.Bit(l.Length-1-pos) == 0 {
+			changes = append(changes, Change{Pos: pos, Color: 0})
+		}
+	}
+	return changes, nil
+}
@@ -0,0 +1,81 @@
+package line
+
+import (
+	"fmt"
+
+	"nonogram-solver/internal/combinatorics"
+	"nonogram-solver/internal/types"
+)
+
+// Possible returns, for each position, the states (0 for empty, otherwise a color
+// ID) that appear in at least one placement of the clues consistent with the
+// line's facts. It does not enumerate combinations: a forward and a backward
+// dynamic programming pass over (position, clue) run in O(length * clues), so it
+// is the fallback for lines whose combinations exceed the memory budget or the
+// enumeration limit. It returns an error wrapping combinatorics.ErrInfeasibleLine
+// when no placement is consistent with the facts.
+func Possible(l *types.Line) ([][]int, error) {
+	t, err := newFitTable(l)
+	if err != nil {
+		return nil, err
+	}
+	n, k, clues := t.n, t.k, t.clues
+
+	// Mark every state that takes part in a complete placement. Runs are
+	// accumulated with per-color difference arrays.
+	runs := make(map[int][]int)
+	for _, c := range t.states[1:] {
+		runs[c] = make([]int, n+1)
+	}
+	for j := 0; j < k; j++ {
+		length, c := clues[j].Clue, clues[j].ColorID
+		for s := 0; s+length <= n; s++ {
+			if t.fits(j, s) {
+				runs[c][s]++
+				runs[c][s+length]--
+			}
+		}
+	}
+
+	possible := make([][]int, n)
+	for i := 0; i < n; i++ {
+		if t.canBe(0, i, i+1) {
+			for j := 0; j <= k; j++ {
+				if t.fwd[i][j] && t.bwd[i+1][j] {
+					possible[i] = append(possible[i], 0)
+					break
+				}
+			}
+		}
+	}
+	for _, c := range t.states[1:] {
+		active := 0
+		for i := 0; i < n; i++ {
+			active += runs[c][i]
+			if active > 0 {
+				possible[i] = append(possible[i], c)
+			}
+		}
+	}
+	return possible, nil
+}
+
+// Settle runs the non-enumerating line solver and returns the positions whose
+// state is forced, in ascending order. It finds everything Overlap would find
+// after a CrossReference on a fully enumerated line.
+func Settle(l *types.Line) ([]Change, error) {
+	possible, err := Possible(l)
+	if err != nil {
+		return nil, err
+	}
+	var changes []Change
+	for pos, candidates := range possible {
+		if len(candidates) == 0 {
+			return nil, fmt.Errorf("%w: position %d has no possible state", combinatorics.ErrInfeasibleLine, pos+1)
+		}
+		if len(candidates) == 1 && !l.Facts.IsKnown(pos) {
+			changes = append(changes, Change{Pos: pos, Color: candidates[0]})
+		}
+	}
+	return changes, nil
+}
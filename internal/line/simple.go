@@ -0,0 +1,57 @@
+package line
+
+import (
+	"fmt"
+
+	"nonogram-solver/internal/combinatorics"
+	"nonogram-solver/internal/types"
+)
+
+// Simple applies the simple boxes and simple spaces rules a person starts
+// with. Each clue is pushed to its leftmost and rightmost starts that agree
+// with the facts; cells covered by a clue in both extremes take its color, and
+// cells no clue can reach are empty. It is weaker than Settle, which considers
+// every placement, and returns the unknown positions it forces in ascending order.
+func Simple(l *types.Line) ([]Change, error) {
+	t, err := newFitTable(l)
+	if err != nil {
+		return nil, err
+	}
+
+	state := make([]int, t.n) // -1 unknown
+	for i := range state {
+		state[i] = -1
+	}
+	reach := make([]bool, t.n)
+	for j, clue := range t.clues {
+		first, last := -1, -1
+		for s := 0; s+clue.Clue <= t.n; s++ {
+			if t.fits(j, s) {
+				if first < 0 {
+					first = s
+				}
+				last = s
+			}
+		}
+		if first < 0 {
+			return nil, fmt.Errorf("%w: clue %d has no start", combinatorics.ErrInfeasibleLine, j+1)
+		}
+		for i := first; i < last+clue.Clue; i++ {
+			reach[i] = true
+		}
+		for i := last; i < first+clue.Clue; i++ {
+			state[i] = clue.ColorID
+		}
+	}
+
+	var changes []Change
+	for pos := range state {
+		if !reach[pos] {
+			state[pos] = 0
+		}
+		if state[pos] >= 0 && !l.Facts.IsKnown(pos) {
+			changes = append(changes, Change{Pos: pos, Color: state[pos]})
+		}
+	}
+	return changes, nil
+}
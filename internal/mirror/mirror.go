@@ -0,0 +1,324 @@
+package mirror
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	network "nonogram-solver/internal/network"
+	"nonogram-solver/internal/puzzle"
+)
+
+// Files kept next to the mirrored puzzles
+const (
+	ManifestFile = "manifest.jsonl" // one Record per mirrored puzzle
+	FailuresFile = "failures.jsonl" // one Record per ID that could not be mirrored
+)
+
+// FetchFunc downloads one puzzle; its Meta supplies the manifest's URL and
+// pattern
+type FetchFunc func(ctx context.Context, id string) (*puzzle.Puzzle, error)
+
+// Options configures a mirror run over the IDs From..To
+type Options struct {
+	From, To int
+	Dir      string
+	// Format of the puzzle files; default text
+	Format puzzle.Format
+	// Workers fetch concurrently; default 4
+	Workers int
+	// Rate limits fetches per second across all workers, allowing bursts of
+	// Burst; a Rate of 0 disables limiting
+	Rate  float64
+	Burst int
+	// SkipFailed also skips IDs recorded in the failures file instead of
+	// trying them again
+	SkipFailed bool
+	// Fetch defaults to nonograms.org with Network
+	Fetch   FetchFunc
+	Network network.FetchOptions
+	// Progress, when set, is called with each new record
+	Progress func(Record)
+}
+
+// Record describes one mirrored puzzle or one failure
+type Record struct {
+	ID      string    `json:"id"`
+	File    string    `json:"file,omitempty"`
+	URL     string    `json:"url,omitempty"`
+	Pattern string    `json:"pattern,omitempty"`
+	Title   string    `json:"title,omitempty"`
+	Width   int       `json:"width,omitempty"`
+	Height  int       `json:"height,omitempty"`
+	Colors  int       `json:"colors,omitempty"`
+	Error   string    `json:"error,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// Summary counts the outcomes of a run
+type Summary struct {
+	Mirrored int
+	Skipped  int
+	Failed   int
+}
+
+// Run mirrors the IDs that are not in the manifest yet. Puzzles and manifest
+// records are written as they arrive, so an interrupted run resumes where it
+// stopped. At the end the failures file is rewritten to hold only IDs that are
+// still missing.
+func Run(ctx context.Context, opts Options) (Summary, error) {
+	if opts.From <= 0 || opts.To < opts.From {
+		return Summary{}, fmt.Errorf("invalid ID range %d-%d", opts.From, opts.To)
+	}
+	if opts.Format == "" {
+		opts.Format = puzzle.FormatText
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = 4
+	}
+	if opts.Fetch == nil {
+		opts.Fetch = nonogramsOrg(opts.Network)
+	}
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return Summary{}, err
+	}
+
+	done, err := readRecords(filepath.Join(opts.Dir, ManifestFile))
+	if err != nil {
+		return Summary{}, err
+	}
+	failed, err := readRecords(filepath.Join(opts.Dir, FailuresFile))
+	if err != nil {
+		return Summary{}, err
+	}
+
+	m := &run{opts: opts, done: done, failed: failed, limiter: NewLimiter(opts.Rate, opts.Burst)}
+	if m.manifest, err = openAppend(filepath.Join(opts.Dir, ManifestFile)); err != nil {
+		return Summary{}, err
+	}
+	defer m.manifest.Close()
+	if m.failures, err = openAppend(filepath.Join(opts.Dir, FailuresFile)); err != nil {
+		return Summary{}, err
+	}
+	defer m.failures.Close()
+
+	ids := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range ids {
+				m.mirror(ctx, id)
+			}
+		}()
+	}
+dispatch:
+	for n := opts.From; n <= opts.To; n++ {
+		id := strconv.Itoa(n)
+		if m.skip(id) {
+			m.summary.Skipped++
+			continue
+		}
+		select {
+		case ids <- id:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(ids)
+	wg.Wait()
+	m.failures.Close()
+
+	if m.err != nil {
+		return m.summary, m.err
+	}
+	if err := m.compactFailures(); err != nil {
+		return m.summary, err
+	}
+	return m.summary, ctx.Err()
+}
+
+// run is the shared state of one Run
+type run struct {
+	opts     Options
+	limiter  *Limiter
+	manifest *os.File
+	failures *os.File
+
+	mu      sync.Mutex
+	done    map[string]Record
+	failed  map[string]Record
+	summary Summary
+	err     error // first write error; stops the run's output
+}
+
+func (m *run) skip(id string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if record, ok := m.done[id]; ok {
+		if _, err := os.Stat(filepath.Join(m.opts.Dir, record.File)); err == nil {
+			return true
+		}
+	}
+	_, failed := m.failed[id]
+	return failed && m.opts.SkipFailed
+}
+
+// mirror fetches and stores one puzzle, recording the outcome
+func (m *run) mirror(ctx context.Context, id string) {
+	if err := m.limiter.Wait(ctx); err != nil {
+		return
+	}
+	record := Record{ID: id, Time: time.Now().UTC()}
+	p, err := m.opts.Fetch(ctx, id)
+	if ctx.Err() != nil {
+		// An interrupted fetch is not a failure of the ID
+		return
+	}
+	if err == nil {
+		if p.Meta != nil {
+			record.URL, record.Pattern, record.Title = p.Meta.URL, p.Meta.Pattern, p.Meta.Title
+		}
+		record.Width, record.Height, record.Colors = p.Width, p.Height, len(p.Colors)
+		record.File = id + extension(m.opts.Format)
+		err = m.writePuzzle(record.File, p)
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	m.record(record)
+}
+
+func (m *run) writePuzzle(name string, p *puzzle.Puzzle) error {
+	var buf bytes.Buffer
+	if err := puzzle.Write(&buf, p, m.opts.Format); err != nil {
+		return err
+	}
+	path := filepath.Join(m.opts.Dir, name)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (m *run) record(record Record) {
+	line, _ := json.Marshal(record)
+	line = append(line, '\n')
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := m.manifest
+	if record.Error == "" {
+		m.done[record.ID] = record
+		delete(m.failed, record.ID)
+		m.summary.Mirrored++
+	} else {
+		out = m.failures
+		m.failed[record.ID] = record
+		m.summary.Failed++
+	}
+	if _, err := out.Write(line); err != nil && m.err == nil {
+		m.err = err
+	}
+	if m.opts.Progress != nil {
+		m.opts.Progress(record)
+	}
+}
+
+// compactFailures rewrites the failures file with the latest failure of each
+// ID that is still not mirrored, in ID order
+func (m *run) compactFailures() error {
+	records := make([]Record, 0, len(m.failed))
+	for _, record := range m.failed {
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool { return idLess(records[i].ID, records[j].ID) })
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, record := range records {
+		enc.Encode(record)
+	}
+	path := filepath.Join(m.opts.Dir, FailuresFile)
+	if err := os.WriteFile(path+".tmp", buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// readRecords loads a JSON lines file keyed by ID; later lines win. A missing
+// file is empty, and a torn last line from an interrupted run is ignored.
+func readRecords(path string) (map[string]Record, error) {
+	records := make(map[string]Record)
+	fh, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return records, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	scanner := bufio.NewScanner(fh)
+	for scanner.Scan() {
+		var record Record
+		if json.Unmarshal(scanner.Bytes(), &record) == nil && record.ID != "" {
+			records[record.ID] = record
+		}
+	}
+	return records, scanner.Err()
+}
+
+// openAppend opens a JSON lines file for appending, first ending a torn last
+// line so the next record starts on its own line
+func openAppend(path string) (*os.File, error) {
+	fh, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := fh.Stat()
+	if err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err = fh.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			_, err = fh.Write([]byte{'\n'})
+		}
+	}
+	if err != nil {
+		fh.Close()
+		return nil, err
+	}
+	return fh, nil
+}
+
+func nonogramsOrg(opts network.FetchOptions) FetchFunc {
+	return func(ctx context.Context, id string) (*puzzle.Puzzle, error) {
+		return network.FetchPuzzleContext(ctx, id, opts)
+	}
+}
+
+func extension(format puzzle.Format) string {
+	if format == puzzle.FormatJSON {
+		return ".json"
+	}
+	return ".txt"
+}
+
+// idLess orders numeric IDs by value
+func idLess(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
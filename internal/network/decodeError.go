@@ -0,0 +1,36 @@
+package fetcher
+
+import "fmt"
+
+// DecodeError reports page data that does not have the expected shape: which
+// array of the page's data, which field, and what was expected instead
+type DecodeError struct {
+	Index    int    // index of the array in the page's "var d" data
+	Field    string // e.g. "width", "color 2", "cell"
+	Expected string
+	Actual   string
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("data[%d] (%s): expected %s, got %s", e.Index, e.Field, e.Expected, e.Actual)
+}
+
+// checkFields returns a DecodeError when data has fewer than minEncodedFields values
+func checkFields(data []int, index int, field string) error {
+	if len(data) < minEncodedFields {
+		return &DecodeError{Index: index, Field: field, Expected: fmt.Sprintf("at least %d values", minEncodedFields), Actual: fmt.Sprintf("%d", len(data))}
+	}
+	return nil
+}
+
+// checkEncoded is checkFields for an encoded number, which also needs a
+// positive modulus at dataMultiplierIndex to be decoded
+func checkEncoded(data []int, index int, field string) error {
+	if err := checkFields(data, index, field); err != nil {
+		return err
+	}
+	if data[dataMultiplierIndex] <= 0 {
+		return &DecodeError{Index: index, Field: field + " modulus", Expected: "a positive value", Actual: fmt.Sprintf("%d", data[dataMultiplierIndex])}
+	}
+	return nil
+}
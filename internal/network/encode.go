@@ -0,0 +1,157 @@
+package fetcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"nonogram-solver/internal/puzzle"
+)
+
+// EncodeData is the inverse of the page decoder: it lays out a puzzle's
+// solution as the obfuscated "var d=[...]" array of a nonograms.org page, with
+// the dimensions, palette and one record per horizontal run in shuffled
+// order. The seed picks the obfuscation, so the same seed always gives the
+// same array.
+func EncodeData(p *puzzle.Puzzle, seed int64) ([][]int, error) {
+	if p.Width <= 0 || p.Height <= 0 || p.Width > maxDimension || p.Height > maxDimension {
+		return nil, fmt.Errorf("invalid puzzle dimensions: %dx%d", p.Width, p.Height)
+	}
+	if len(p.Solution) != p.Height {
+		return nil, fmt.Errorf("puzzle %s has no solution to encode", p.ID)
+	}
+	numColors := len(p.Colors)
+	if numColors == 0 {
+		return nil, fmt.Errorf("puzzle %s has no colors", p.ID)
+	}
+	rgb := make([][3]int, numColors)
+	for i := range rgb {
+		hex, ok := p.Colors[i+1]
+		if !ok {
+			return nil, fmt.Errorf("colors must be numbered 1-%d, missing %d", numColors, i+1)
+		}
+		if numColors == 1 {
+			// Monochrome pages always decode as black
+			continue
+		}
+		value, err := strconv.ParseUint(strings.TrimPrefix(hex, "#"), 16, 32)
+		if err != nil || len(strings.TrimPrefix(hex, "#")) != 6 {
+			return nil, fmt.Errorf("color %d: invalid hex color %q", i+1, hex)
+		}
+		rgb[i] = [3]int{int(value >> 16), int(value >> 8 & 0xff), int(value & 0xff)}
+	}
+
+	var runs [][4]int // start column, span, color, row
+	for row, cells := range p.Solution {
+		if len(cells) != p.Width {
+			return nil, fmt.Errorf("solution row %d has %d cells, want %d", row+1, len(cells), p.Width)
+		}
+		for col := 0; col < p.Width; {
+			color := cells[col]
+			if color < 0 || color > numColors {
+				return nil, fmt.Errorf("solution row %d column %d has color %d outside 0-%d", row+1, col+1, color, numColors)
+			}
+			end := col + 1
+			for end < p.Width && cells[end] == color {
+				end++
+			}
+			if color != 0 {
+				runs = append(runs, [4]int{col, end - col, color, row})
+			}
+			col = end
+		}
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	rng.Shuffle(len(runs), func(i, j int) { runs[i], runs[j] = runs[j], runs[i] })
+
+	data := [][]int{noise(rng)}
+	data = append(data, encodeDimension(rng, p.Width), encodeDimension(rng, p.Height), encodeDimension(rng, numColors))
+
+	// The color base shifts every component: red and green by its first
+	// value, blue by its last
+	colorBase := []int{rng.Intn(1000), rng.Intn(1000), rng.Intn(1000), rng.Intn(1000)}
+	data = append(data, colorBase)
+	for _, c := range rgb {
+		data = append(data, []int{c[0] + colorBase[dataValueIndex], c[1] + colorBase[dataValueIndex], c[2] + colorBase[dataMultiplierIndex], rng.Intn(1000)})
+	}
+
+	data = append(data, encodeRunCount(rng, len(runs)))
+	offsets := []int{rng.Intn(100), rng.Intn(100), rng.Intn(100), rng.Intn(100)}
+	data = append(data, offsets)
+	for _, run := range runs {
+		data = append(data, []int{
+			run[0] + 1 + offsets[dataValueIndex],
+			run[1] + offsets[dataOffsetIndex],
+			run[2] + offsets[dataModulusIndex],
+			run[3] + 1 + offsets[dataMultiplierIndex],
+		})
+	}
+	return data, nil
+}
+
+// EncodePage wraps EncodeData in a minimal page in the nonograms.org layout,
+// showing the puzzle's title, author, size and difficulty so that DecodePage
+// reads back the same Meta
+func EncodePage(p *puzzle.Puzzle, seed int64) ([]byte, error) {
+	data, err := EncodeData(p, seed)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<body>\n")
+	if m := p.Meta; !m.IsZero() {
+		if m.Title != "" {
+			fmt.Fprintf(&b, "<h1>Nonogram &laquo;%s&raquo;</h1>\n", html.EscapeString(m.Title))
+		}
+		b.WriteString("<table class=\"nonogram_descr\">\n")
+		for _, label := range [][2]string{{"Author", m.Author}, {"Size", m.Size}, {"Difficulty", m.Difficulty}} {
+			if label[1] != "" {
+				fmt.Fprintf(&b, "<tr><td>%s:</td><td>%s</td></tr>\n", label[0], html.EscapeString(label[1]))
+			}
+		}
+		b.WriteString("</table>\n")
+	}
+	fmt.Fprintf(&b, "<script type=\"text/javascript\">var d=%s;</script>\n</body>\n</html>\n", encoded)
+	return []byte(b.String()), nil
+}
+
+// encodeDimension picks a record that decodeDimension reads as value:
+// a%m + b%m - c%m == value, with each remainder hidden behind multiples of m
+func encodeDimension(rng *rand.Rand, value int) []int {
+	m := value + 2 + rng.Intn(50)
+	c := rng.Intn(m)
+	// a + b == value + c with both below m
+	lo, hi := max(0, value+c-(m-1)), min(m-1, value+c)
+	a := lo + rng.Intn(hi-lo+1)
+	b := value + c - a
+	return []int{a + m*rng.Intn(20), b + m*rng.Intn(20), c + m*rng.Intn(20), m}
+}
+
+// encodeRunCount picks a record that calculateGridDataCount reads as n:
+// (a%m)² + 2(b%m) + c%m == n
+func encodeRunCount(rng *rand.Rand, n int) []int {
+	a := 0
+	for (a+1)*(a+1) <= n {
+		a++
+	}
+	a = rng.Intn(a + 1)
+	rest := n - a*a
+	b := rng.Intn(rest/2 + 1)
+	c := rest - 2*b
+	m := max(a, b, c) + 1 + rng.Intn(50)
+	return []int{a + m*rng.Intn(20), b + m*rng.Intn(20), c + m*rng.Intn(20), m}
+}
+
+// noise fills the unused first record
+func noise(rng *rand.Rand) []int {
+	return []int{rng.Intn(2000), rng.Intn(2000), rng.Intn(2000), rng.Intn(2000)}
+}
@@ -3,6 +3,7 @@ package fetcher
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -11,14 +12,15 @@ import (
 	"time"
 
 	factory "nonogram-solver/internal/factory"
+	"nonogram-solver/internal/puzzle"
 	"nonogram-solver/internal/types"
 )
 
 // Constants for URL patterns and data structure indices
 const (
-	// URL patterns for fetching nonogram data
-	baseURLPattern1 = "https://www.nonograms.org/nonograms/i/%s"
-	baseURLPattern2 = "https://www.nonograms.org/nonograms2/i/%s"
+	// URL patterns for fetching nonogram data, relative to the base URL
+	baseURLPattern1 = "/nonograms/i/%s"
+	baseURLPattern2 = "/nonograms2/i/%s"
 
 	// Data structure indices based on JavaScript implementation
 	widthDataIndex      = 1
@@ -37,7 +39,10 @@ const (
 	// Minimum number of encoded fields required for decoding
 	minEncodedFields = 4
 
-	// Timeout for outbound requests
+	// Largest width or height a page may declare
+	maxDimension = 1000
+
+	// Default timeout for each attempt
 	requestTimeout = 10 * time.Second
 )
 
@@ -49,16 +54,61 @@ var (
 // FetchPage retrieves the HTML content for a nonogram by ID.
 // It tries multiple URL patterns in parallel to accommodate different formats.
 func FetchPage(nonogramID string) ([]byte, error) {
+	return FetchPageContext(context.Background(), nonogramID, FetchOptions{})
+}
+
+// FetchPageContext is FetchPage with cancellation and options. Each attempt
+// races both URL patterns; when neither succeeds and a failure was transient,
+// it waits with jittered exponential backoff and tries again, up to
+// opts.Retries times. A page missing from both patterns fails at once.
+func FetchPageContext(ctx context.Context, nonogramID string, opts FetchOptions) ([]byte, error) {
+	body, _, err := FetchPageURL(ctx, nonogramID, opts)
+	return body, err
+}
+
+// FetchPageURL is FetchPageContext also returning the URL that served the page
+func FetchPageURL(ctx context.Context, nonogramID string, opts FetchOptions) ([]byte, string, error) {
 	if nonogramID == "" {
-		return nil, fmt.Errorf("nonogramID cannot be empty")
+		return nil, "", fmt.Errorf("nonogramID cannot be empty")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	for attempt := 0; ; attempt++ {
+		body, url, transient, err := fetchAttempt(ctx, nonogramID, opts)
+		if err == nil {
+			return body, url, nil
+		}
+		if ctx.Err() != nil {
+			return nil, "", fmt.Errorf("fetching nonogram %s: %w", nonogramID, ctx.Err())
+		}
+		if !transient {
+			if allNotFound(err) {
+				return nil, "", fmt.Errorf("nonogram with ID %s not found on either URL pattern: %w", nonogramID, err)
+			}
+			return nil, "", fmt.Errorf("fetching nonogram %s: %w", nonogramID, err)
+		}
+		if attempt >= opts.Retries {
+			return nil, "", fmt.Errorf("fetching nonogram %s failed after %d attempt(s): %w", nonogramID, attempt+1, err)
+		}
+
+		timer := time.NewTimer(opts.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, "", fmt.Errorf("fetching nonogram %s: %w", nonogramID, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// fetchAttempt races the URL patterns once and returns the first page found
+// with its URL. transient reports whether any failure is worth retrying.
+func fetchAttempt(ctx context.Context, nonogramID string, opts FetchOptions) (body []byte, url string, transient bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, opts.timeout())
 	defer cancel()
 
 	urls := []string{
-		fmt.Sprintf(baseURLPattern2, nonogramID),
-		fmt.Sprintf(baseURLPattern1, nonogramID),
+		opts.baseURL() + fmt.Sprintf(baseURLPattern2, nonogramID),
+		opts.baseURL() + fmt.Sprintf(baseURLPattern1, nonogramID),
 	}
 
 	resultChan := make(chan fetchResult, len(urls))
@@ -68,7 +118,7 @@ func FetchPage(nonogramID string) ([]byte, error) {
 		wg.Add(1)
 		go func(url string) {
 			defer wg.Done()
-			fetchURL(ctx, url, resultChan)
+			fetchURL(ctx, opts, url, resultChan)
 		}(url)
 	}
 
@@ -77,50 +127,84 @@ func FetchPage(nonogramID string) ([]byte, error) {
 		close(resultChan)
 	}()
 
+	var errs []error
 	for result := range resultChan {
 		if result.err == nil {
 			cancel()
-			return result.body, nil
+			return result.body, result.url, false, nil
 		}
+		errs = append(errs, result.err)
+		transient = transient || result.transient
 	}
-
-	return nil, fmt.Errorf("nonogram with ID %s not found on either URL pattern", nonogramID)
+	return nil, "", transient, errors.Join(errs...)
 }
 
 // fetchResult holds the result of a URL fetch attempt
 type fetchResult struct {
-	body []byte
-	err  error
-	url  string
+	body      []byte
+	err       error
+	url       string
+	transient bool
 }
 
 // fetchURL attempts to fetch a URL and sends the result to the channel
-func fetchURL(ctx context.Context, url string, resultChan chan<- fetchResult) {
+func fetchURL(ctx context.Context, opts FetchOptions, url string, resultChan chan<- fetchResult) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		resultChan <- fetchResult{err: fmt.Errorf("failed to create request for %s: %w", url, err), url: url}
 		return
 	}
+	body, transient, err := opts.send(req)
+	resultChan <- fetchResult{body: body, err: err, url: url, transient: transient}
+}
+
+// send issues req with the User-Agent set and returns the body of a 200
+// response. Network errors and timeouts are transient; so are 5xx and 429
+// responses.
+func (o FetchOptions) send(req *http.Request) (body []byte, transient bool, err error) {
+	url := req.URL.String()
+	req.Header.Set("User-Agent", o.userAgent())
 
-	resp, err := httpClient.Do(req)
+	resp, err := o.client().Do(req)
 	if err != nil {
-		resultChan <- fetchResult{err: fmt.Errorf("failed to fetch %s: %w", url, err), url: url}
-		return
+		return nil, true, fmt.Errorf("failed to fetch %s: %w", url, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		resultChan <- fetchResult{err: fmt.Errorf("HTTP %d from %s", resp.StatusCode, url), url: url}
-		return
+		return nil, retryable(resp.StatusCode), &statusError{code: resp.StatusCode, url: url}
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	body, err = io.ReadAll(resp.Body)
 	if err != nil {
-		resultChan <- fetchResult{err: fmt.Errorf("failed to read response body from %s: %w", url, err), url: url}
-		return
+		return nil, true, fmt.Errorf("failed to read response body from %s: %w", url, err)
 	}
+	return body, false, nil
+}
+
+// statusError reports a response other than 200
+type statusError struct {
+	code int
+	url  string
+}
 
-	resultChan <- fetchResult{body: body, url: url}
+func (e *statusError) Error() string {
+	return fmt.Sprintf("HTTP %d from %s", e.code, e.url)
+}
+
+// allNotFound reports whether every failure joined in err is a 404
+func allNotFound(err error) bool {
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+	for _, err := range errs {
+		var status *statusError
+		if !errors.As(err, &status) || status.code != http.StatusNotFound {
+			return false
+		}
+	}
+	return len(errs) > 0
 }
 
 // Note: We no longer materialize NonogramData; we directly build Grid.
@@ -128,30 +212,36 @@ func fetchURL(ctx context.Context, url string, resultChan chan<- fetchResult) {
 // calculateDimensions extracts width, height, and color count from raw data
 func calculateDimensions(rawData [][]int) (width, height, numColors int, err error) {
 	if len(rawData) <= colorsDataIndex {
-		return 0, 0, 0, fmt.Errorf("insufficient data arrays for dimension calculation")
+		return 0, 0, 0, &DecodeError{Index: colorsDataIndex, Field: "dimensions", Expected: fmt.Sprintf("at least %d arrays", colorsDataIndex+1), Actual: fmt.Sprintf("%d", len(rawData))}
 	}
 
 	widthData := rawData[widthDataIndex]
-	if len(widthData) < minEncodedFields {
-		return 0, 0, 0, fmt.Errorf("width data array too short")
+	if err := checkEncoded(widthData, widthDataIndex, "width"); err != nil {
+		return 0, 0, 0, err
 	}
 	width = decodeDimension(widthData)
 
 	heightData := rawData[heightDataIndex]
-	if len(heightData) < minEncodedFields {
-		return 0, 0, 0, fmt.Errorf("height data array too short")
+	if err := checkEncoded(heightData, heightDataIndex, "height"); err != nil {
+		return 0, 0, 0, err
 	}
 	height = decodeDimension(heightData)
 
 	colorsData := rawData[colorsDataIndex]
-	if len(colorsData) < minEncodedFields {
-		return 0, 0, 0, fmt.Errorf("colors data array too short")
+	if err := checkEncoded(colorsData, colorsDataIndex, "colors"); err != nil {
+		return 0, 0, 0, err
 	}
 	numColors = decodeDimension(colorsData)
 
 	if width <= 0 || height <= 0 {
 		return 0, 0, 0, fmt.Errorf("invalid grid dimensions: %dx%d", width, height)
 	}
+	if width > maxDimension {
+		return 0, 0, 0, &DecodeError{Index: widthDataIndex, Field: "width", Expected: fmt.Sprintf("at most %d", maxDimension), Actual: fmt.Sprintf("%d", width)}
+	}
+	if height > maxDimension {
+		return 0, 0, 0, &DecodeError{Index: heightDataIndex, Field: "height", Expected: fmt.Sprintf("at most %d", maxDimension), Actual: fmt.Sprintf("%d", height)}
+	}
 	if numColors <= 0 {
 		return 0, 0, 0, fmt.Errorf("invalid number of colors: %d", numColors)
 	}
@@ -174,15 +264,25 @@ func initializeGrid(width, height int) [][]int {
 	return grid
 }
 
-// decodeColorData extracts color information from the raw data
-func decodeColorData(rawData [][]int, numColors int) (map[int]string, error) {
-	if len(rawData) < colorOffset+numColors {
-		return nil, fmt.Errorf("insufficient data for %d colors", numColors)
+// decodeColorData extracts color information from the raw data. In strict
+// mode a missing or short color array, or a component outside 0-255, is an
+// error; lenient mode skips colors it cannot decode and clamps components.
+func decodeColorData(rawData [][]int, numColors int, strict bool) (map[int]string, error) {
+	var colorBaseData []int
+	if gridDataIndex < len(rawData) {
+		colorBaseData = rawData[gridDataIndex]
+	}
+	baseErr := checkFields(colorBaseData, gridDataIndex, "color base")
+	if strict {
+		if len(rawData) < colorOffset+numColors {
+			return nil, &DecodeError{Index: len(rawData), Field: "colors", Expected: fmt.Sprintf("%d color arrays", numColors), Actual: fmt.Sprintf("%d", max(len(rawData)-colorOffset, 0))}
+		}
+		if baseErr != nil {
+			return nil, baseErr
+		}
 	}
 
 	colorMap := make(map[int]string)
-	colorBaseData := rawData[gridDataIndex]
-
 	for i := 0; i < numColors; i++ {
 		colorIndex := colorOffset + i
 		if colorIndex >= len(rawData) {
@@ -190,9 +290,22 @@ func decodeColorData(rawData [][]int, numColors int) (map[int]string, error) {
 		}
 
 		colorData := rawData[colorIndex]
-		if len(colorData) < minEncodedFields {
+		field := fmt.Sprintf("color %d", i+1)
+		if err := checkFields(colorData, colorIndex, field); err != nil {
+			if strict {
+				return nil, err
+			}
+			continue
+		}
+		// Several colors are offsets from the base array
+		if numColors > 1 && baseErr != nil {
 			continue
 		}
+		if strict && numColors > 1 {
+			if err := checkColor(colorBaseData, colorData, colorIndex, field); err != nil {
+				return nil, err
+			}
+		}
 
 		colorMap[i+1] = decodeColor(colorBaseData, colorData, numColors)
 	}
@@ -200,6 +313,21 @@ func decodeColorData(rawData [][]int, numColors int) (map[int]string, error) {
 	return colorMap, nil
 }
 
+// checkColor reports a color component that decodeColor would clamp
+func checkColor(colorBaseData, colorData []int, index int, field string) error {
+	components := [3]int{
+		colorData[dataValueIndex] - colorBaseData[dataValueIndex],
+		colorData[dataOffsetIndex] - colorBaseData[dataValueIndex],
+		colorData[dataModulusIndex] - colorBaseData[dataMultiplierIndex],
+	}
+	for i, value := range components {
+		if value < 0 || value > 255 {
+			return &DecodeError{Index: index, Field: fmt.Sprintf("%s %s", field, [3]string{"red", "green", "blue"}[i]), Expected: "0-255", Actual: fmt.Sprintf("%d", value)}
+		}
+	}
+	return nil
+}
+
 func decodeColor(colorBaseData, colorData []int, numColors int) string {
 	if numColors == 1 {
 		return "#000000"
@@ -222,37 +350,58 @@ func clampColor(value int) int {
 	return value
 }
 
-// decodeGridCells populates the grid with cell data from the raw encoded format
-func decodeGridCells(rawData [][]int, grid [][]int, width, height, numColors int) error {
+// decodeGridCells populates the grid with cell data from the raw encoded format.
+// Lenient mode skips runs it cannot place; strict mode returns a DecodeError for
+// missing or short arrays, runs outside the grid, colors outside 1..numColors
+// and runs that overlap cells already decoded.
+func decodeGridCells(rawData [][]int, grid [][]int, width, height, numColors int, strict bool) error {
 	gridDataStart := colorOffset + numColors
 	if gridDataStart >= len(rawData) {
-		return fmt.Errorf("no grid data found after color data")
+		return &DecodeError{Index: gridDataStart, Field: "grid metadata", Expected: "an array after the colors", Actual: "end of data"}
 	}
 
 	gridMetadata := rawData[gridDataStart]
-	if len(gridMetadata) < minEncodedFields {
-		return fmt.Errorf("grid metadata array too short")
+	if err := checkEncoded(gridMetadata, gridDataStart, "grid metadata"); err != nil {
+		return err
 	}
 
+	// Every run fills at least one cell
 	gridDataCount := calculateGridDataCount(gridMetadata)
+	if gridDataCount > width*height {
+		return &DecodeError{Index: gridDataStart, Field: "grid metadata", Expected: fmt.Sprintf("at most %d cell runs", width*height), Actual: fmt.Sprintf("%d", gridDataCount)}
+	}
 
+	if gridDataStart+1 >= len(rawData) {
+		return &DecodeError{Index: gridDataStart + 1, Field: "grid offsets", Expected: "an array after the grid metadata", Actual: "end of data"}
+	}
 	gridOffsetData := rawData[gridDataStart+1]
-	if len(gridOffsetData) < minEncodedFields {
-		return fmt.Errorf("grid offset data array too short")
+	if err := checkFields(gridOffsetData, gridDataStart+1, "grid offsets"); err != nil {
+		return err
 	}
 
 	for i := 0; i < gridDataCount; i++ {
 		dataIndex := gridDataStart + gridDataStartOffset + i
 		if dataIndex >= len(rawData) {
+			if strict {
+				return &DecodeError{Index: dataIndex, Field: "cells", Expected: fmt.Sprintf("%d cell runs", gridDataCount), Actual: fmt.Sprintf("%d", i)}
+			}
 			break
 		}
 
 		cellData := rawData[dataIndex]
-		if len(cellData) < minEncodedFields {
+		if err := checkFields(cellData, dataIndex, "cell"); err != nil {
+			if strict {
+				return err
+			}
 			continue
 		}
 
 		startCol, endCol, color, row := decodeGridCell(cellData, gridOffsetData)
+		if strict {
+			if err := checkCell(grid, dataIndex, startCol, endCol, color, row, numColors); err != nil {
+				return err
+			}
+		}
 		if row < 0 || row >= height {
 			continue
 		}
@@ -268,6 +417,26 @@ func decodeGridCells(rawData [][]int, grid [][]int, width, height, numColors int
 	return nil
 }
 
+// checkCell validates one decoded run against the grid and the palette
+func checkCell(grid [][]int, index, startCol, endCol, color, row, numColors int) error {
+	switch {
+	case row < 0 || row >= len(grid):
+		return &DecodeError{Index: index, Field: "cell row", Expected: fmt.Sprintf("1-%d", len(grid)), Actual: fmt.Sprintf("%d", row+1)}
+	case endCol < startCol:
+		return &DecodeError{Index: index, Field: "cell span", Expected: "at least 1", Actual: fmt.Sprintf("%d", endCol-startCol+1)}
+	case startCol < 0 || endCol >= len(grid[row]):
+		return &DecodeError{Index: index, Field: "cell columns", Expected: fmt.Sprintf("within 1-%d", len(grid[row])), Actual: fmt.Sprintf("%d-%d", startCol+1, endCol+1)}
+	case color < 1 || color > numColors:
+		return &DecodeError{Index: index, Field: "cell color", Expected: fmt.Sprintf("1-%d", numColors), Actual: fmt.Sprintf("%d", color)}
+	}
+	for col := startCol; col <= endCol; col++ {
+		if grid[row][col] != 0 {
+			return &DecodeError{Index: index, Field: "cell", Expected: fmt.Sprintf("row %d column %d to be unset", row+1, col+1), Actual: fmt.Sprintf("color %d from an earlier run", grid[row][col])}
+		}
+	}
+	return nil
+}
+
 func calculateGridDataCount(gridMetadata []int) int {
 	encodedValue := gridMetadata[dataValueIndex] % gridMetadata[dataMultiplierIndex]
 	return encodedValue*encodedValue +
@@ -297,13 +466,13 @@ func extractAllClues(grid [][]int, width, height int) (map[types.LineID][]types.
 			return nil, fmt.Errorf("grid row %d width mismatch: expected %d, got %d", rowIndex, width, len(row))
 		}
 		lineID := types.LineID{Direction: types.Row, Index: rowIndex}
-		clues[lineID] = extractCluesFromRow(row)
+		clues[lineID] = puzzle.LineClues(row)
 	}
 
 	for col := 0; col < width; col++ {
 		column := buildColumn(grid, col)
 		lineID := types.LineID{Direction: types.Column, Index: col}
-		clues[lineID] = extractCluesFromRow(column)
+		clues[lineID] = puzzle.LineClues(column)
 	}
 
 	return clues, nil
@@ -317,92 +486,104 @@ func buildColumn(grid [][]int, col int) []int {
 	return column
 }
 
-// extractCluesFromRow extracts clue numbers from a row or column of the grid.
-// This function analyzes consecutive blocks of the same color and creates clue items.
-func extractCluesFromRow(row []int) []types.ClueItem {
-	var (
-		clues        []types.ClueItem
-		currentColor int
-		currentCount int
-	)
-
-	for _, cell := range row {
-		switch {
-		case cell <= 0:
-			if currentCount == 0 {
-				continue
-			}
-			clues = append(clues, types.ClueItem{ColorID: currentColor, Clue: currentCount})
-			currentColor = 0
-			currentCount = 0
-		case cell == currentColor:
-			currentCount++
-		default:
-			if currentCount > 0 {
-				clues = append(clues, types.ClueItem{ColorID: currentColor, Clue: currentCount})
-			}
-			currentColor = cell
-			currentCount = 1
-		}
-	}
-
-	if currentCount > 0 {
-		clues = append(clues, types.ClueItem{ColorID: currentColor, Clue: currentCount})
-	}
+// FetchNonogramData removed: use FetchGrid instead.
 
-	return clues
+// FetchPuzzle fetches and decodes the nonogram with the given ID, including its
+// solution cells.
+func FetchPuzzle(nonogramID string) (*puzzle.Puzzle, error) {
+	return FetchPuzzleContext(context.Background(), nonogramID, FetchOptions{})
 }
 
-// FetchNonogramData removed: use FetchGrid instead.
-
-// FetchGrid fetches, parses, and constructs a Grid directly for the given nonogram ID.
-// This bypasses exposing NonogramData to callers by internally converting clues to a Grid.
-func FetchGrid(nonogramID string) (types.Grid, error) {
+// FetchPuzzleContext is FetchPuzzle with cancellation and options, fetching
+// the page with FetchPageContext
+func FetchPuzzleContext(ctx context.Context, nonogramID string, opts FetchOptions) (*puzzle.Puzzle, error) {
 	if nonogramID == "" {
-		return types.Grid{}, fmt.Errorf("nonogramID cannot be empty")
+		return nil, fmt.Errorf("nonogramID cannot be empty")
 	}
 
-	htmlContent, err := FetchPage(nonogramID)
+	htmlContent, url, err := FetchPageURL(ctx, nonogramID, opts)
 	if err != nil {
-		return types.Grid{}, fmt.Errorf("failed to fetch page for nonogram %s: %w", nonogramID, err)
+		return nil, fmt.Errorf("failed to fetch page for nonogram %s: %w", nonogramID, err)
 	}
+	p, err := decodePage(nonogramID, htmlContent, opts.Strict)
+	if err != nil {
+		return nil, err
+	}
+	setURL(p, url)
+	return p, nil
+}
+
+// setURL records the page URL and its pattern in the puzzle's metadata
+func setURL(p *puzzle.Puzzle, url string) {
+	if p.Meta == nil {
+		p.Meta = &puzzle.Meta{}
+	}
+	p.Meta.URL, p.Meta.Pattern = url, patternOf(url)
+}
 
+// DecodePage decodes the puzzle data embedded in a nonograms.org page, with its
+// solution cells and the metadata the page shows. It tolerates malformed cell
+// and color data by skipping it; DecodePageStrict rejects it.
+func DecodePage(nonogramID string, htmlContent []byte) (*puzzle.Puzzle, error) {
+	return decodePage(nonogramID, htmlContent, false)
+}
+
+// DecodePageStrict is DecodePage failing with a *DecodeError on any data it
+// would otherwise skip, clamp or place outside the grid
+func DecodePageStrict(nonogramID string, htmlContent []byte) (*puzzle.Puzzle, error) {
+	return decodePage(nonogramID, htmlContent, true)
+}
+
+func decodePage(nonogramID string, htmlContent []byte, strict bool) (*puzzle.Puzzle, error) {
 	if len(htmlContent) == 0 {
-		return types.Grid{}, fmt.Errorf("HTML content is empty")
+		return nil, fmt.Errorf("HTML content is empty")
 	}
 
 	htmlStr := string(htmlContent)
 	matches := dataRegex.FindStringSubmatch(htmlStr)
 	if len(matches) < 2 {
-		return types.Grid{}, fmt.Errorf("could not find nonogram data variable 'd' in HTML")
+		return nil, fmt.Errorf("could not find nonogram data variable 'd' in HTML")
 	}
 
 	var rawData [][]int
 	if err := json.Unmarshal([]byte(matches[1]), &rawData); err != nil {
-		return types.Grid{}, fmt.Errorf("failed to parse nonogram data JSON: %w", err)
+		return nil, fmt.Errorf("failed to parse nonogram data JSON: %w", err)
 	}
 	if len(rawData) == 0 {
-		return types.Grid{}, fmt.Errorf("parsed nonogram data is empty")
+		return nil, fmt.Errorf("parsed nonogram data is empty")
 	}
 
 	width, height, numColors, err := calculateDimensions(rawData)
 	if err != nil {
-		return types.Grid{}, fmt.Errorf("failed to calculate dimensions: %w", err)
+		return nil, fmt.Errorf("failed to calculate dimensions: %w", err)
 	}
 
 	gridData := initializeGrid(width, height)
-	colorMap, err := decodeColorData(rawData, numColors)
+	colorMap, err := decodeColorData(rawData, numColors, strict)
 	if err != nil {
-		return types.Grid{}, fmt.Errorf("failed to decode color data: %w", err)
+		return nil, fmt.Errorf("failed to decode color data: %w", err)
 	}
-	if err := decodeGridCells(rawData, gridData, width, height, numColors); err != nil {
-		return types.Grid{}, fmt.Errorf("failed to decode grid cells: %w", err)
+	if err := decodeGridCells(rawData, gridData, width, height, numColors, strict); err != nil {
+		return nil, fmt.Errorf("failed to decode grid cells: %w", err)
 	}
 	clues, err := extractAllClues(gridData, width, height)
 	if err != nil {
-		return types.Grid{}, fmt.Errorf("failed to extract clues: %w", err)
+		return nil, fmt.Errorf("failed to extract clues: %w", err)
 	}
 
-	grid := factory.CreateGridFromClues(clues, width, height, colorMap)
+	p := puzzle.New(nonogramID, clues, width, height, colorMap)
+	p.Solution = gridData
+	p.Meta = parseMeta(htmlContent)
+	return p, nil
+}
+
+// FetchGrid fetches, parses, and constructs a Grid directly for the given nonogram ID.
+// This bypasses exposing NonogramData to callers by internally converting clues to a Grid.
+func FetchGrid(nonogramID string) (types.Grid, error) {
+	p, err := FetchPuzzle(nonogramID)
+	if err != nil {
+		return types.Grid{}, err
+	}
+	grid := factory.CreateGridFromClues(p.Clues(), p.Width, p.Height, p.Colors)
 	return grid, nil
 }
@@ -0,0 +1,92 @@
+package fetcher
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	"nonogram-solver/internal/puzzle"
+)
+
+// URL patterns reported in puzzle.Meta.Pattern
+const (
+	PatternMonochrome = "nonograms"
+	PatternColor      = "nonograms2"
+)
+
+var (
+	scriptRegex = regexp.MustCompile(`(?is)<(script|style)\b.*?</(script|style)>`)
+	tagRegex    = regexp.MustCompile(`(?s)<[^>]*>`)
+	h1Regex     = regexp.MustCompile(`(?is)<h1\b[^>]*>(.*?)</h1>`)
+	titleRegex  = regexp.MustCompile(`(?is)<title\b[^>]*>(.*?)</title>`)
+	quotedRegex = regexp.MustCompile(`«([^»]+)»`)
+	labelRegex  = regexp.MustCompile(`(?i)^(author|size|difficulty)\s*:\s*(.*)$`)
+)
+
+// patternOf names the URL pattern of a page URL
+func patternOf(url string) string {
+	switch {
+	case strings.Contains(url, "/"+PatternColor+"/"):
+		return PatternColor
+	case strings.Contains(url, "/"+PatternMonochrome+"/"):
+		return PatternMonochrome
+	}
+	return ""
+}
+
+// parseMeta reads what a puzzle page shows about the puzzle: the title from
+// its heading (or <title>), and the values labelled "Author:", "Size:" and
+// "Difficulty:". It returns nil when the page shows none of them.
+func parseMeta(page []byte) *puzzle.Meta {
+	text := scriptRegex.ReplaceAllString(string(page), "")
+	meta := &puzzle.Meta{}
+
+	for _, re := range []*regexp.Regexp{h1Regex, titleRegex} {
+		if m := re.FindStringSubmatch(text); m != nil {
+			if meta.Title = pageTitle(m[1]); meta.Title != "" {
+				break
+			}
+		}
+	}
+
+	// A label's value follows on the same line or in the next element
+	var lines []string
+	for _, line := range strings.Split(tagRegex.ReplaceAllString(text, "\n"), "\n") {
+		if line = strings.Join(strings.Fields(html.UnescapeString(line)), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	for i, line := range lines {
+		m := labelRegex.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		value := m[2]
+		if value == "" && i+1 < len(lines) {
+			value = lines[i+1]
+		}
+		switch strings.ToLower(m[1]) {
+		case "author":
+			meta.Author = value
+		case "size":
+			meta.Size = value
+		case "difficulty":
+			meta.Difficulty = value
+		}
+	}
+
+	if meta.IsZero() {
+		return nil
+	}
+	return meta
+}
+
+// pageTitle strips markup from a heading and keeps the quoted name when the
+// heading reads like `Nonogram «Name»`
+func pageTitle(heading string) string {
+	title := strings.Join(strings.Fields(html.UnescapeString(tagRegex.ReplaceAllString(heading, " "))), " ")
+	if m := quotedRegex.FindStringSubmatch(title); m != nil {
+		return strings.TrimSpace(m[1])
+	}
+	return title
+}
@@ -0,0 +1,99 @@
+package fetcher
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultUserAgent identifies the solver to nonograms.org
+const DefaultUserAgent = "nonogram-solver"
+
+// DefaultBaseURL is where puzzle pages are fetched from
+const DefaultBaseURL = "https://www.nonograms.org"
+
+const (
+	defaultBackoff    = 500 * time.Millisecond
+	defaultMaxBackoff = 10 * time.Second
+)
+
+// FetchOptions configures page downloads. The zero value makes one attempt
+// with a 10s timeout through a shared client.
+type FetchOptions struct {
+	// Client sends the requests; when nil, a client using Transport (or the
+	// default transport) is used
+	Client *http.Client
+	// Transport is used when Client is nil, e.g. to stub the site in tests
+	Transport http.RoundTripper
+	// Timeout bounds each attempt; 0 means 10s
+	Timeout time.Duration
+	// Retries is how many times a transient failure (a 5xx response, 429, a
+	// timeout or a network error) is retried; 404s and other client errors
+	// are not
+	Retries int
+	// Backoff is the delay before the first retry, doubled for each later one
+	// up to MaxBackoff; the actual delay is jittered between half and all of it.
+	// 0 means 500ms, and MaxBackoff 0 means 10s.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// UserAgent defaults to DefaultUserAgent
+	UserAgent string
+	// BaseURL replaces DefaultBaseURL, e.g. with an httptest server
+	BaseURL string
+	// Strict decodes pages with DecodePageStrict
+	Strict bool
+}
+
+func (o FetchOptions) client() *http.Client {
+	switch {
+	case o.Client != nil:
+		return o.Client
+	case o.Transport != nil:
+		return &http.Client{Transport: o.Transport}
+	default:
+		return httpClient
+	}
+}
+
+func (o FetchOptions) baseURL() string {
+	if o.BaseURL != "" {
+		return strings.TrimSuffix(o.BaseURL, "/")
+	}
+	return DefaultBaseURL
+}
+
+func (o FetchOptions) timeout() time.Duration {
+	if o.Timeout > 0 {
+		return o.Timeout
+	}
+	return requestTimeout
+}
+
+func (o FetchOptions) userAgent() string {
+	if o.UserAgent != "" {
+		return o.UserAgent
+	}
+	return DefaultUserAgent
+}
+
+// backoff returns the jittered delay before retry n (0-based)
+func (o FetchOptions) backoff(n int) time.Duration {
+	delay, limit := o.Backoff, o.MaxBackoff
+	if delay <= 0 {
+		delay = defaultBackoff
+	}
+	if limit <= 0 {
+		limit = defaultMaxBackoff
+	}
+	for i := 0; i < n && delay < limit; i++ {
+		delay *= 2
+	}
+	delay = min(delay, limit)
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// retryable reports whether an HTTP status is worth another attempt
+func retryable(status int) bool {
+	return status >= 500 || status == http.StatusTooManyRequests || status == http.StatusRequestTimeout
+}
@@ -0,0 +1,47 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// PostForm posts form to target and returns the body of a 200 response, with
+// the same client, per-attempt timeout, User-Agent and retries of transient
+// failures as page downloads. BaseURL and Strict are not used.
+func (o FetchOptions) PostForm(ctx context.Context, target string, form url.Values) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		body, transient, err := o.postAttempt(ctx, target, form)
+		if err == nil {
+			return body, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if !transient || attempt >= o.Retries {
+			return nil, err
+		}
+
+		timer := time.NewTimer(o.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (o FetchOptions) postAttempt(ctx context.Context, target string, form url.Values) ([]byte, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, o.timeout())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return o.send(req)
+}
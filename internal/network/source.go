@@ -0,0 +1,78 @@
+package fetcher
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+
+	"nonogram-solver/internal/cache"
+	"nonogram-solver/internal/puzzle"
+)
+
+var numericID = regexp.MustCompile(`^[0-9]+$`)
+
+// Source fetches puzzles from nonograms.org by numeric ID
+type Source struct {
+	Options FetchOptions
+	// Cache, when set, keeps each page's HTML and decoded puzzle so fetching
+	// the same ID again does not hit the site
+	Cache *cache.Cache
+	// Refresh ignores cached entries but still stores what it fetches
+	Refresh bool
+}
+
+// Fetch downloads and decodes the puzzle with the given ID, preferring the
+// cached puzzle, then the cached page
+func (s Source) Fetch(ctx context.Context, id string) (*puzzle.Puzzle, error) {
+	if !numericID.MatchString(id) {
+		return nil, fmt.Errorf("nonograms.org IDs are numeric, got %q", id)
+	}
+	if s.Cache == nil {
+		return FetchPuzzleContext(ctx, id, s.Options)
+	}
+
+	pageKey, urlKey, puzzleKey := "nonograms.org/"+id+".html", "nonograms.org/"+id+".url", "nonograms.org/"+id+".json"
+	// A cached puzzle may have been decoded leniently, so strict mode decodes
+	// the cached page again
+	if !s.Refresh && !s.Options.Strict {
+		if data, ok := s.Cache.Get(puzzleKey); ok {
+			if p, err := puzzle.Read(bytes.NewReader(data), puzzle.FormatJSON); err == nil {
+				return p, nil
+			}
+		}
+	}
+
+	page, ok := []byte(nil), false
+	var url []byte
+	if !s.Refresh {
+		page, ok = s.Cache.Get(pageKey)
+		url, _ = s.Cache.Get(urlKey)
+	}
+	if !ok {
+		var err error
+		var served string
+		if page, served, err = FetchPageURL(ctx, id, s.Options); err != nil {
+			return nil, fmt.Errorf("failed to fetch page for nonogram %s: %w", id, err)
+		}
+		url = []byte(served)
+	}
+	p, err := decodePage(id, page, s.Options.Strict)
+	if err != nil {
+		return nil, err
+	}
+	if len(url) > 0 {
+		setURL(p, string(url))
+	}
+
+	// The cache is best effort: a read-only or full disk only costs a refetch
+	if !ok {
+		s.Cache.Put(pageKey, page)
+		s.Cache.Put(urlKey, url)
+	}
+	var buf bytes.Buffer
+	if puzzle.Write(&buf, p, puzzle.FormatJSON) == nil {
+		s.Cache.Put(puzzleKey, buf.Bytes())
+	}
+	return p, nil
+}
@@ -0,0 +1,181 @@
+package play
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"nonogram-solver/internal/factory"
+	"nonogram-solver/internal/puzzle"
+	"nonogram-solver/internal/solver"
+)
+
+// Unknown marks a cell the player has not decided yet
+const Unknown = -1
+
+// Game is the state of a play session: the player's cells, the cursor and the
+// solver's facts used to check the player's work. It has no terminal code so it
+// can be driven by tests.
+type Game struct {
+	Puzzle *puzzle.Puzzle
+	// Cells is the player's grid by row: Unknown, 0 for marked empty, otherwise a color ID
+	Cells [][]int
+	// Facts is what the solver knows: the solution when the puzzle is uniquely
+	// solvable, otherwise only the cells propagation forces (Unknown elsewhere)
+	Facts [][]int
+	// Row and Col are the cursor position
+	Row, Col int
+	// Color is the palette color used by fill
+	Color int
+	// ShowMistakes highlights cells that contradict Facts
+	ShowMistakes bool
+	// Message is shown in the status line until the next key
+	Message string
+	// Highlight marks the cells of the last hint
+	Highlight map[[2]int]bool
+
+	palette []int
+}
+
+// NewGame starts with an empty player grid
+func NewGame(p *puzzle.Puzzle, facts [][]int) *Game {
+	g := &Game{
+		Puzzle: p,
+		Cells:  make([][]int, p.Height),
+		Facts:  facts,
+	}
+	for r := range g.Cells {
+		g.Cells[r] = make([]int, p.Width)
+		for c := range g.Cells[r] {
+			g.Cells[r][c] = Unknown
+		}
+	}
+	for color := range p.Colors {
+		g.palette = append(g.palette, color)
+	}
+	sort.Ints(g.palette)
+	if len(g.palette) > 0 {
+		g.Color = g.palette[0]
+	}
+	return g
+}
+
+// Palette returns the color IDs in selection order
+func (g *Game) Palette() []int {
+	return g.palette
+}
+
+// Move shifts the cursor, wrapping around the edges
+func (g *Game) Move(dr, dc int) {
+	g.Row = (g.Row + dr + g.Puzzle.Height) % g.Puzzle.Height
+	g.Col = (g.Col + dc + g.Puzzle.Width) % g.Puzzle.Width
+}
+
+// Fill paints the cursor cell with the selected color, or clears it if it
+// already has that color
+func (g *Game) Fill() {
+	if g.Cells[g.Row][g.Col] == g.Color {
+		g.Cells[g.Row][g.Col] = Unknown
+	} else {
+		g.Cells[g.Row][g.Col] = g.Color
+	}
+}
+
+// MarkEmpty toggles the cursor cell between marked empty and unknown
+func (g *Game) MarkEmpty() {
+	if g.Cells[g.Row][g.Col] == 0 {
+		g.Cells[g.Row][g.Col] = Unknown
+	} else {
+		g.Cells[g.Row][g.Col] = 0
+	}
+}
+
+// Clear resets the cursor cell to unknown
+func (g *Game) Clear() {
+	g.Cells[g.Row][g.Col] = Unknown
+}
+
+// SelectColor picks the n-th palette color (1-based)
+func (g *Game) SelectColor(n int) {
+	if n >= 1 && n <= len(g.palette) {
+		g.Color = g.palette[n-1]
+	}
+}
+
+// NextColor cycles through the palette
+func (g *Game) NextColor() {
+	for i, color := range g.palette {
+		if color == g.Color {
+			g.Color = g.palette[(i+1)%len(g.palette)]
+			return
+		}
+	}
+}
+
+// IsMistake reports whether the player's cell contradicts the solver's facts
+func (g *Game) IsMistake(row, col int) bool {
+	cell, fact := g.Cells[row][col], g.Facts[row][col]
+	return cell != Unknown && fact != Unknown && cell != fact
+}
+
+// Mistakes returns the cells that contradict the solver's facts, in row-major order
+func (g *Game) Mistakes() [][2]int {
+	var out [][2]int
+	for r, row := range g.Cells {
+		for c := range row {
+			if g.IsMistake(r, c) {
+				out = append(out, [2]int{r, c})
+			}
+		}
+	}
+	return out
+}
+
+// Progress counts the player's decided cells
+func (g *Game) Progress() (decided, total int) {
+	for _, row := range g.Cells {
+		for _, cell := range row {
+			if cell != Unknown {
+				decided++
+			}
+		}
+		total += len(row)
+	}
+	return decided, total
+}
+
+// Solved reports whether every filled cell matches a complete solution. Cells
+// left unknown count as empty, so marking empties is optional.
+func (g *Game) Solved() bool {
+	for r, row := range g.Facts {
+		for c, fact := range row {
+			cell := g.Cells[r][c]
+			if fact == Unknown || (cell != fact && !(fact == 0 && cell == Unknown)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Check reports the player's mistakes in the status line
+func (g *Game) Check() {
+	mistakes := g.Mistakes()
+	switch {
+	case g.Solved():
+		g.Message = "Solved!"
+	case len(mistakes) == 0:
+		g.Message = "No mistakes so far"
+	default:
+		first := mistakes[0]
+		g.Message = fmt.Sprintf("%d mistake(s), first at row %d column %d", len(mistakes), first[0]+1, first[1]+1)
+		g.ShowMistakes = true
+	}
+}
+
+// SolverFacts returns the cells a game checks against: the full solution when
+// the puzzle has exactly one, otherwise only what line propagation forces.
+func SolverFacts(ctx context.Context, p *puzzle.Puzzle, opts solver.Options, providers factory.ProviderOptions) ([][]int, error) {
+	g := p.Grid(providers)
+	return solver.Reference(ctx, &g, opts)
+}
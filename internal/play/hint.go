@@ -0,0 +1,90 @@
+package play
+
+import (
+	"context"
+	"fmt"
+
+	"nonogram-solver/internal/explain"
+	"nonogram-solver/internal/factory"
+	"nonogram-solver/internal/solver"
+)
+
+// Hint operations, from least to most effort for a person
+const (
+	HintMistake        = solver.HintMistake
+	HintOverlap        = solver.HintOverlap
+	HintCrossReference = solver.HintCrossReference
+	HintProbe          = solver.HintProbe
+	HintReveal         = "reveal" // nothing can be deduced; the solver's answer is shown
+)
+
+// Hint is the solver's next deduction from the player's cells, with a sentence
+// justifying it
+type Hint struct {
+	solver.Hint
+	Text string
+}
+
+// NextHint asks the solver for the easiest deduction from the player's cells.
+// When there is none, a cell the solver knows but the player has not decided
+// is revealed instead.
+func (g *Game) NextHint() (Hint, bool) {
+	grid := g.Puzzle.Grid(factory.ProviderOptions{})
+	next, ok, err := solver.NextHint(context.Background(), &grid, g.Cells, solver.Options{})
+	if err != nil {
+		// The player's cells admit no solution, or the puzzle itself has none
+		return Hint{Hint: solver.Hint{Operation: HintMistake}, Text: err.Error()}, true
+	}
+	if ok {
+		return Hint{Hint: next, Text: g.describe(next)}, true
+	}
+
+	for r, row := range g.Facts {
+		for c, fact := range row {
+			if fact != Unknown && g.Cells[r][c] == Unknown {
+				cell := solver.Cell{Row: r, Col: c, Color: fact}
+				return Hint{
+					Hint: solver.Hint{Operation: HintReveal, Cells: []solver.Cell{cell}},
+					Text: fmt.Sprintf("no single step forces a cell; the solver has %s as %s", explain.CellName(cell), explain.ColorName(g.Puzzle, fact)),
+				}, true
+			}
+		}
+	}
+	return Hint{}, false
+}
+
+// Hint shows the next hint in the status line and highlights its cells
+func (g *Game) Hint() {
+	hint, ok := g.NextHint()
+	if !ok {
+		g.Message = "Nothing left to deduce"
+		return
+	}
+	g.Message = hint.Text
+	g.Highlight = make(map[[2]int]bool, len(hint.Cells))
+	for _, cell := range hint.Cells {
+		g.Highlight[[2]int{cell.Row, cell.Col}] = true
+	}
+	if len(hint.Cells) > 0 {
+		g.Row, g.Col = hint.Cells[0].Row, hint.Cells[0].Col
+	}
+}
+
+// describe words a solver hint for the status line
+func (g *Game) describe(h solver.Hint) string {
+	switch {
+	case h.Operation == HintMistake && len(h.Cells) > 0:
+		text := fmt.Sprintf("%s should be %s", explain.CellName(h.Cells[0]), explain.ColorName(g.Puzzle, h.Cells[0].Color))
+		if more := len(h.Cells) - 1; more > 0 {
+			text += fmt.Sprintf(" (and %d more mistake(s))", more)
+		}
+		return text
+	case h.Operation == HintMistake:
+		return fmt.Sprintf("%s: the filled cells cannot satisfy its clues", explain.LineName(h.Line))
+	case h.Operation == HintProbe:
+		cell := h.Cells[0]
+		return fmt.Sprintf("probe: %s must be %s, every other state leads to a contradiction", explain.CellName(cell), explain.ColorName(g.Puzzle, cell.Color))
+	default:
+		return explain.Describe(g.Puzzle, h.Operation, h.Line, h.Cells)
+	}
+}
@@ -0,0 +1,131 @@
+package play
+
+// Action is something a key press asks the game to do
+type Action int
+
+const (
+	ActionNone Action = iota
+	ActionUp
+	ActionDown
+	ActionLeft
+	ActionRight
+	ActionFill
+	ActionEmpty
+	ActionClear
+	ActionNextColor
+	ActionSelectColor // Key.Color holds the 1-based palette index
+	ActionMistakes
+	ActionCheck
+	ActionHint
+	ActionQuit
+)
+
+// Key is a decoded key press
+type Key struct {
+	Action Action
+	Color  int
+}
+
+// ParseKeys decodes raw terminal input: arrow keys (ESC [ A-D), vi and WASD
+// movement, and single-letter commands. Unknown bytes are ignored.
+func ParseKeys(buf []byte) []Key {
+	var keys []Key
+	for i := 0; i < len(buf); i++ {
+		b := buf[i]
+		if b == 0x1b && i+2 < len(buf) && (buf[i+1] == '[' || buf[i+1] == 'O') {
+			switch buf[i+2] {
+			case 'A':
+				keys = append(keys, Key{Action: ActionUp})
+			case 'B':
+				keys = append(keys, Key{Action: ActionDown})
+			case 'C':
+				keys = append(keys, Key{Action: ActionRight})
+			case 'D':
+				keys = append(keys, Key{Action: ActionLeft})
+			case '3': // ESC [ 3 ~ is Delete
+				if i+3 < len(buf) && buf[i+3] == '~' {
+					keys = append(keys, Key{Action: ActionClear})
+					i++
+				}
+			}
+			i += 2
+			continue
+		}
+
+		if b >= '1' && b <= '9' {
+			keys = append(keys, Key{Action: ActionSelectColor, Color: int(b - '0')})
+			continue
+		}
+		action := ActionNone
+		switch b {
+		case 'k', 'w':
+			action = ActionUp
+		case 'j', 's':
+			action = ActionDown
+		case 'h', 'a':
+			action = ActionLeft
+		case 'l', 'd':
+			action = ActionRight
+		case ' ', 'f', '\r':
+			action = ActionFill
+		case 'x', 'e':
+			action = ActionEmpty
+		case 0x7f, 0x08, '.', '0':
+			action = ActionClear
+		case '\t':
+			action = ActionNextColor
+		case 'm':
+			action = ActionMistakes
+		case 'c':
+			action = ActionCheck
+		case '?':
+			action = ActionHint
+		case 'q', 0x03: // Ctrl-C arrives as a byte in raw mode
+			action = ActionQuit
+		}
+		if action != ActionNone {
+			keys = append(keys, Key{Action: action})
+		}
+	}
+	return keys
+}
+
+// Handle applies a key and reports whether the session continues
+func (g *Game) Handle(k Key) bool {
+	g.Message = ""
+	if k.Action != ActionHint {
+		g.Highlight = nil
+	}
+	switch k.Action {
+	case ActionUp:
+		g.Move(-1, 0)
+	case ActionDown:
+		g.Move(1, 0)
+	case ActionLeft:
+		g.Move(0, -1)
+	case ActionRight:
+		g.Move(0, 1)
+	case ActionFill:
+		g.Fill()
+	case ActionEmpty:
+		g.MarkEmpty()
+	case ActionClear:
+		g.Clear()
+	case ActionNextColor:
+		g.NextColor()
+	case ActionSelectColor:
+		g.SelectColor(k.Color)
+	case ActionMistakes:
+		g.ShowMistakes = !g.ShowMistakes
+	case ActionCheck:
+		g.Check()
+	case ActionHint:
+		g.Hint()
+	case ActionQuit:
+		return false
+	}
+	if (k.Action == ActionFill || k.Action == ActionEmpty) && g.Solved() {
+		g.Message = "Solved!"
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+package play
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+const (
+	enterScreen = "\x1b[?1049h\x1b[?25l" // alternate screen, hidden cursor
+	leaveScreen = "\x1b[?25h\x1b[?1049l"
+)
+
+// Run plays the game full-screen until the player quits, in is closed or ctx
+// ends. in must be a terminal.
+func Run(ctx context.Context, in *os.File, out io.Writer, g *Game) error {
+	restore, err := makeRaw(in)
+	if err != nil {
+		return fmt.Errorf("failed to set up the terminal: %w", err)
+	}
+	defer restore()
+	fmt.Fprint(out, enterScreen)
+	defer fmt.Fprint(out, leaveScreen)
+
+	// The reader stops once Run is done, before the terminal is restored, so
+	// it does not keep consuming input meant for the shell
+	done := make(chan struct{})
+	input := make(chan []byte)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(input)
+		buf := make([]byte, 64)
+		for {
+			n, err := in.Read(buf)
+			if n > 0 {
+				select {
+				case input <- append([]byte(nil), buf[:n]...):
+				case <-done:
+					return
+				}
+			}
+			select {
+			case <-done:
+				return
+			default:
+			}
+			// An empty read is the terminal's timeout, reported as io.EOF
+			if err != nil && (n > 0 || !errors.Is(err, io.EOF)) {
+				return
+			}
+		}
+	}()
+	defer func() {
+		close(done)
+		wg.Wait()
+	}()
+
+	for {
+		fmt.Fprint(out, g.View())
+		select {
+		case <-ctx.Done():
+			return nil
+		case buf, ok := <-input:
+			if !ok {
+				return nil
+			}
+			for _, k := range ParseKeys(buf) {
+				if !g.Handle(k) {
+					return nil
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !unix
+
+package play
+
+import (
+	"errors"
+	"os"
+)
+
+func makeRaw(f *os.File) (func(), error) {
+	return nil, errors.New("play needs a Unix terminal")
+}
@@ -0,0 +1,30 @@
+//go:build unix
+
+package play
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// makeRaw switches the terminal to raw, unechoed input and returns a function
+// that restores the previous settings. Reads return after at most 100ms, with
+// nothing if no key was pressed, so the reader can notice when to stop.
+func makeRaw(f *os.File) (func(), error) {
+	saved, err := stty(f, "-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stty(f, "raw", "-echo", "min", "0", "time", "1"); err != nil {
+		return nil, err
+	}
+	return func() { stty(f, strings.TrimSpace(saved)) }, nil
+}
+
+func stty(f *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = f
+	out, err := cmd.Output()
+	return string(out), err
+}
@@ -0,0 +1,162 @@
+package play
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"nonogram-solver/internal/render"
+	"nonogram-solver/internal/types"
+)
+
+const (
+	clearScreen = "\x1b[H\x1b[2J"
+	reset       = "\x1b[0m"
+	dim         = "\x1b[2m"
+	reverse     = "\x1b[7m"
+	red         = "\x1b[1;31m"
+	yellow      = "\x1b[1;33m"
+)
+
+const help = "arrows/hjkl move  space fill  x empty  bksp clear  1-9/tab color  ? hint  c check  m mistakes  q quit"
+
+// View draws the whole screen: column clues above the grid, row clues to its
+// left, then a status line and the key help. Lines end with \r\n because the
+// terminal is in raw mode.
+func (g *Game) View() string {
+	p := g.Puzzle
+	var sb strings.Builder
+	sb.WriteString(clearScreen)
+
+	rowClues := make([]string, p.Height)
+	rowWidth := 0
+	for r, clues := range p.Rows {
+		rowClues[r] = g.clueLine(clues, " ")
+		rowWidth = max(rowWidth, visibleLen(clues))
+	}
+	colDepth := 0
+	for _, clues := range p.Cols {
+		colDepth = max(colDepth, len(clues))
+	}
+
+	// Column clues, bottom-aligned, two characters per column
+	for d := 0; d < colDepth; d++ {
+		sb.WriteString(strings.Repeat(" ", rowWidth+1))
+		for _, clues := range p.Cols {
+			i := d - (colDepth - len(clues))
+			if i < 0 {
+				sb.WriteString("  ")
+				continue
+			}
+			sb.WriteString(g.clueNumber(clues[i], 2))
+		}
+		sb.WriteString("\r\n")
+	}
+
+	for r := 0; r < p.Height; r++ {
+		pad := rowWidth - visibleLen(p.Rows[r])
+		sb.WriteString(strings.Repeat(" ", pad))
+		sb.WriteString(rowClues[r])
+		sb.WriteByte(' ')
+		for c := 0; c < p.Width; c++ {
+			sb.WriteString(g.cell(r, c))
+		}
+		sb.WriteString("\r\n")
+	}
+
+	decided, total := g.Progress()
+	fmt.Fprintf(&sb, "\r\nrow %d col %d  color %s  %d/%d cells", g.Row+1, g.Col+1, g.swatch(g.Color), decided, total)
+	if g.ShowMistakes {
+		fmt.Fprintf(&sb, "  %s%d mistake(s)%s", red, len(g.Mistakes()), reset)
+	}
+	sb.WriteString("\r\n")
+	if g.Message != "" {
+		sb.WriteString(g.Message)
+	}
+	sb.WriteString("\r\n" + dim + help + reset + "\r\n")
+	return sb.String()
+}
+
+// cell draws one two-character cell with its cursor, hint and mistake markers
+func (g *Game) cell(r, c int) string {
+	state := g.Cells[r][c]
+	text := "· "
+	switch {
+	case state == 0:
+		text = " x"
+	case state > 0:
+		text = "  "
+	}
+	switch {
+	case r == g.Row && c == g.Col:
+		text = "[]"
+	case g.ShowMistakes && g.IsMistake(r, c):
+		text = red + "!!"
+	case g.Highlight[[2]int{r, c}]:
+		text = yellow + "**"
+	}
+
+	if state > 0 {
+		return g.background(state) + text + reset
+	}
+	if state == Unknown && text == "· " {
+		return dim + text + reset
+	}
+	return text + reset
+}
+
+// background is the escape sequence painting a color's cells. Monochrome
+// puzzles use reverse video so filled cells show on dark and light terminals.
+func (g *Game) background(color int) string {
+	if g.Puzzle.IsMonochrome() {
+		return reverse
+	}
+	if r, gr, b, ok := render.ParseHex(g.Puzzle.Colors[color]); ok {
+		return fmt.Sprintf("\x1b[48;2;%d;%d;%dm", r, gr, b)
+	}
+	return reverse
+}
+
+func (g *Game) foreground(color int) string {
+	if g.Puzzle.IsMonochrome() {
+		return ""
+	}
+	if r, gr, b, ok := render.ParseHex(g.Puzzle.Colors[color]); ok {
+		return fmt.Sprintf("\x1b[1;38;2;%d;%d;%dm", r, gr, b)
+	}
+	return ""
+}
+
+func (g *Game) swatch(color int) string {
+	return fmt.Sprintf("%s  %s %d", g.background(color), reset, color)
+}
+
+// clueLine draws a row's clues in their colors
+func (g *Game) clueLine(clues []types.ClueItem, sep string) string {
+	parts := make([]string, len(clues))
+	for i, clue := range clues {
+		parts[i] = g.clueNumber(clue, 0)
+	}
+	return strings.Join(parts, sep)
+}
+
+// clueNumber draws a clue right-aligned to width in its color
+func (g *Game) clueNumber(clue types.ClueItem, width int) string {
+	text := fmt.Sprintf("%*s", width, strconv.Itoa(clue.Clue))
+	if fg := g.foreground(clue.ColorID); fg != "" {
+		return fg + text + reset
+	}
+	return text
+}
+
+// visibleLen is the printed width of a row's clues joined by spaces
+func visibleLen(clues []types.ClueItem) int {
+	n := 0
+	for i, clue := range clues {
+		if i > 0 {
+			n++
+		}
+		n += len(strconv.Itoa(clue.Clue))
+	}
+	return n
+}
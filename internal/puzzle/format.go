@@ -0,0 +1,404 @@
+package puzzle
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"nonogram-solver/internal/types"
+)
+
+// Format names a puzzle serialization
+type Format string
+
+const (
+	// FormatJSON is the native JSON format
+	FormatJSON Format = "json"
+	// FormatText is a line-oriented format meant to be written by hand:
+	//
+	//	id 12345
+	//	size 5x3
+	//	color 1 #000000
+	//	meta title Lighthouse   (optional; keys: url pattern title author size difficulty)
+	//	rows
+	//	2 1        (one line per row; "n" is color 1, "n:c" is color c, "-" is empty)
+	//	...
+	//	columns
+	//	...
+	//	solution   (optional and last; one line per row, see Symbol)
+	//	##.#.
+	FormatText Format = "text"
+)
+
+// ParseFormat validates a format name
+func ParseFormat(name string) (Format, error) {
+	switch Format(strings.ToLower(name)) {
+	case FormatJSON:
+		return FormatJSON, nil
+	case FormatText, "txt":
+		return FormatText, nil
+	default:
+		return "", fmt.Errorf("unknown puzzle format %q (want json or text)", name)
+	}
+}
+
+// DetectFormat guesses a format from a file name, defaulting to JSON
+func DetectFormat(path string) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".txt", ".non", ".text":
+		return FormatText
+	default:
+		return FormatJSON
+	}
+}
+
+// Read parses a puzzle and validates its shape
+func Read(r io.Reader, format Format) (*Puzzle, error) {
+	var (
+		p   *Puzzle
+		err error
+	)
+	switch format {
+	case FormatJSON:
+		p, err = readJSON(r)
+	case FormatText:
+		p, err = readText(r)
+	default:
+		return nil, fmt.Errorf("unknown puzzle format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Write serializes a puzzle
+func Write(w io.Writer, p *Puzzle, format Format) error {
+	switch format {
+	case FormatJSON:
+		return writeJSON(w, p)
+	case FormatText:
+		return writeText(w, p)
+	default:
+		return fmt.Errorf("unknown puzzle format %q", format)
+	}
+}
+
+// Symbol returns the character used for a cell in text output: '.' for empty,
+// '?' for unknown (-1), '#' for filled cells of monochrome puzzles and the base-36
+// digit of the color ID otherwise.
+func Symbol(color int, monochrome bool) byte {
+	switch {
+	case color < 0:
+		return '?'
+	case color == 0:
+		return '.'
+	case monochrome:
+		return '#'
+	default:
+		return strconv.FormatInt(int64(color), 36)[0]
+	}
+}
+
+// parseSymbol is the inverse of Symbol
+func parseSymbol(ch byte) (int, error) {
+	switch ch {
+	case '.':
+		return 0, nil
+	case '#':
+		return 1, nil
+	}
+	v, err := strconv.ParseInt(string(ch), 36, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cell symbol %q", ch)
+	}
+	return int(v), nil
+}
+
+// IsMonochrome reports whether the puzzle only uses color 1
+func (p *Puzzle) IsMonochrome() bool {
+	for color := range p.Colors {
+		if color != 1 {
+			return false
+		}
+	}
+	for _, lines := range [][][]types.ClueItem{p.Rows, p.Cols} {
+		for _, clues := range lines {
+			for _, clue := range clues {
+				if clue.ColorID != 1 {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+type jsonClue struct {
+	Color  int `json:"color"`
+	Length int `json:"length"`
+}
+
+type jsonPuzzle struct {
+	ID       string            `json:"id,omitempty"`
+	Width    int               `json:"width"`
+	Height   int               `json:"height"`
+	Colors   map[string]string `json:"colors"`
+	Rows     [][]jsonClue      `json:"rows"`
+	Columns  [][]jsonClue      `json:"columns"`
+	Solution [][]int           `json:"solution,omitempty"`
+	Meta     *Meta             `json:"meta,omitempty"`
+}
+
+func readJSON(r io.Reader) (*Puzzle, error) {
+	var doc jsonPuzzle
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse puzzle JSON: %w", err)
+	}
+	p := &Puzzle{
+		ID:       doc.ID,
+		Width:    doc.Width,
+		Height:   doc.Height,
+		Colors:   make(map[int]string, len(doc.Colors)),
+		Rows:     fromJSONClues(doc.Rows),
+		Cols:     fromJSONClues(doc.Columns),
+		Solution: doc.Solution,
+		Meta:     doc.Meta,
+	}
+	for key, hex := range doc.Colors {
+		id, err := strconv.Atoi(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid color ID %q", key)
+		}
+		p.Colors[id] = hex
+	}
+	return p, nil
+}
+
+func writeJSON(w io.Writer, p *Puzzle) error {
+	doc := jsonPuzzle{
+		ID:       p.ID,
+		Width:    p.Width,
+		Height:   p.Height,
+		Colors:   make(map[string]string, len(p.Colors)),
+		Rows:     toJSONClues(p.Rows),
+		Columns:  toJSONClues(p.Cols),
+		Solution: p.Solution,
+	}
+	if !p.Meta.IsZero() {
+		doc.Meta = p.Meta
+	}
+	for id, hex := range p.Colors {
+		doc.Colors[strconv.Itoa(id)] = hex
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+func fromJSONClues(lines [][]jsonClue) [][]types.ClueItem {
+	out := make([][]types.ClueItem, len(lines))
+	for i, clues := range lines {
+		for _, clue := range clues {
+			out[i] = append(out[i], types.ClueItem{ColorID: clue.Color, Clue: clue.Length})
+		}
+	}
+	return out
+}
+
+func toJSONClues(lines [][]types.ClueItem) [][]jsonClue {
+	out := make([][]jsonClue, len(lines))
+	for i, clues := range lines {
+		out[i] = make([]jsonClue, 0, len(clues))
+		for _, clue := range clues {
+			out[i] = append(out[i], jsonClue{Color: clue.ColorID, Length: clue.Clue})
+		}
+	}
+	return out
+}
+
+func readText(r io.Reader) (*Puzzle, error) {
+	p := &Puzzle{Colors: make(map[int]string)}
+	section := ""
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") && section != "solution" {
+			continue
+		}
+		fail := func(format string, args ...any) error {
+			return fmt.Errorf("line %d: %s", lineNo, fmt.Sprintf(format, args...))
+		}
+
+		// The solution section runs to the end of the input
+		if section == "solution" {
+			row := make([]int, len(text))
+			for i := 0; i < len(text); i++ {
+				color, err := parseSymbol(text[i])
+				if err != nil {
+					return nil, fail("%v", err)
+				}
+				row[i] = color
+			}
+			p.Solution = append(p.Solution, row)
+			continue
+		}
+
+		fields := strings.Fields(text)
+
+		switch fields[0] {
+		case "id":
+			if len(fields) != 2 {
+				return nil, fail("expected: id <id>")
+			}
+			p.ID = fields[1]
+			continue
+		case "size":
+			if len(fields) != 2 {
+				return nil, fail("expected: size <width>x<height>")
+			}
+			if _, err := fmt.Sscanf(fields[1], "%dx%d", &p.Width, &p.Height); err != nil {
+				return nil, fail("invalid size %q", fields[1])
+			}
+			continue
+		case "color":
+			if len(fields) != 3 {
+				return nil, fail("expected: color <id> <hex>")
+			}
+			id, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return nil, fail("invalid color ID %q", fields[1])
+			}
+			p.Colors[id] = fields[2]
+			continue
+		case "meta":
+			if len(fields) < 3 {
+				return nil, fail("expected: meta <key> <value>")
+			}
+			if p.Meta == nil {
+				p.Meta = &Meta{}
+			}
+			field := p.Meta.field(fields[1])
+			if field == nil {
+				return nil, fail("unknown meta key %q", fields[1])
+			}
+			*field = strings.Join(fields[2:], " ")
+			continue
+		case "rows", "columns", "solution":
+			section = fields[0]
+			continue
+		}
+
+		switch section {
+		case "rows", "columns":
+			clues, err := parseClueLine(fields)
+			if err != nil {
+				return nil, fail("%v", err)
+			}
+			if section == "rows" {
+				p.Rows = append(p.Rows, clues)
+			} else {
+				p.Cols = append(p.Cols, clues)
+			}
+		default:
+			return nil, fail("unexpected %q outside a section", fields[0])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(p.Colors) == 0 {
+		p.Colors[1] = "#000000"
+	}
+	return p, nil
+}
+
+func parseClueLine(fields []string) ([]types.ClueItem, error) {
+	if len(fields) == 1 && (fields[0] == "-" || fields[0] == "0") {
+		return nil, nil
+	}
+	clues := make([]types.ClueItem, 0, len(fields))
+	for _, field := range fields {
+		lengthText, colorText, hasColor := strings.Cut(field, ":")
+		length, err := strconv.Atoi(lengthText)
+		if err != nil {
+			return nil, fmt.Errorf("invalid clue %q", field)
+		}
+		color := 1
+		if hasColor {
+			if color, err = strconv.Atoi(colorText); err != nil {
+				return nil, fmt.Errorf("invalid clue color %q", field)
+			}
+		}
+		clues = append(clues, types.ClueItem{ColorID: color, Clue: length})
+	}
+	return clues, nil
+}
+
+func writeText(w io.Writer, p *Puzzle) error {
+	bw := bufio.NewWriter(w)
+	monochrome := p.IsMonochrome()
+
+	if p.ID != "" {
+		fmt.Fprintf(bw, "id %s\n", p.ID)
+	}
+	fmt.Fprintf(bw, "size %dx%d\n", p.Width, p.Height)
+	ids := make([]int, 0, len(p.Colors))
+	for id := range p.Colors {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		fmt.Fprintf(bw, "color %d %s\n", id, p.Colors[id])
+	}
+	if !p.Meta.IsZero() {
+		for _, key := range metaKeys {
+			// Values are single lines; the reader joins the words back
+			if value := strings.Join(strings.Fields(*p.Meta.field(key)), " "); value != "" {
+				fmt.Fprintf(bw, "meta %s %s\n", key, value)
+			}
+		}
+	}
+
+	writeClues := func(section string, lines [][]types.ClueItem) {
+		fmt.Fprintln(bw, section)
+		for _, clues := range lines {
+			if len(clues) == 0 {
+				fmt.Fprintln(bw, "-")
+				continue
+			}
+			tokens := make([]string, len(clues))
+			for i, clue := range clues {
+				if monochrome {
+					tokens[i] = strconv.Itoa(clue.Clue)
+				} else {
+					tokens[i] = fmt.Sprintf("%d:%d", clue.Clue, clue.ColorID)
+				}
+			}
+			fmt.Fprintln(bw, strings.Join(tokens, " "))
+		}
+	}
+	writeClues("rows", p.Rows)
+	writeClues("columns", p.Cols)
+
+	if p.Solution != nil {
+		fmt.Fprintln(bw, "solution")
+		for _, row := range p.Solution {
+			for _, color := range row {
+				bw.WriteByte(Symbol(color, monochrome))
+			}
+			bw.WriteByte('\n')
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,41 @@
+package puzzle
+
+// Meta describes where a puzzle came from. Fields the source does not show
+// are left empty.
+type Meta struct {
+	URL string `json:"url,omitempty"` // page the puzzle was fetched from
+	// Pattern is the nonograms.org URL pattern that served the puzzle:
+	// "nonograms" for monochrome puzzles, "nonograms2" for color ones
+	Pattern    string `json:"pattern,omitempty"`
+	Title      string `json:"title,omitempty"`
+	Author     string `json:"author,omitempty"`
+	Size       string `json:"size,omitempty"` // as shown by the source, e.g. "20x15"
+	Difficulty string `json:"difficulty,omitempty"`
+}
+
+// IsZero reports whether no field is set
+func (m *Meta) IsZero() bool {
+	return m == nil || *m == Meta{}
+}
+
+// metaKeys are the text format's "meta <key> <value>" keys in output order
+var metaKeys = []string{"url", "pattern", "title", "author", "size", "difficulty"}
+
+// field returns the field for a text format key, or nil for an unknown key
+func (m *Meta) field(key string) *string {
+	switch key {
+	case "url":
+		return &m.URL
+	case "pattern":
+		return &m.Pattern
+	case "title":
+		return &m.Title
+	case "author":
+		return &m.Author
+	case "size":
+		return &m.Size
+	case "difficulty":
+		return &m.Difficulty
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package puzzle
+
+import (
+	"fmt"
+
+	"nonogram-solver/internal/factory"
+	"nonogram-solver/internal/types"
+)
+
+// Puzzle is a nonogram independent of where it came from: its clues, its
+// palette and, when known, its solution.
+type Puzzle struct {
+	ID       string
+	Width    int
+	Height   int
+	Colors   map[int]string     // color ID -> hex color
+	Rows     [][]types.ClueItem // one clue list per row, top to bottom
+	Cols     [][]types.ClueItem // one clue list per column, left to right
+	Solution [][]int            // optional cells by row: 0 = empty, otherwise a color ID
+	Meta     *Meta              // optional: where the puzzle came from
+}
+
+// New builds a Puzzle from clues keyed by line, as produced by the decoders
+func New(id string, clues map[types.LineID][]types.ClueItem, width, height int, colors map[int]string) *Puzzle {
+	p := &Puzzle{
+		ID:     id,
+		Width:  width,
+		Height: height,
+		Colors: colors,
+		Rows:   make([][]types.ClueItem, height),
+		Cols:   make([][]types.ClueItem, width),
+	}
+	for row := 0; row < height; row++ {
+		p.Rows[row] = clues[types.LineID{Direction: types.Row, Index: row}]
+	}
+	for col := 0; col < width; col++ {
+		p.Cols[col] = clues[types.LineID{Direction: types.Column, Index: col}]
+	}
+	return p
+}
+
+// FromGrid captures a grid's clues and palette. When every cell is known the
+// grid's cells become the puzzle's solution.
+func FromGrid(id string, g *types.Grid) *Puzzle {
+	p := &Puzzle{
+		ID:     id,
+		Width:  g.Width(),
+		Height: g.Height(),
+		Colors: g.Colors,
+		Rows:   make([][]types.ClueItem, g.Height()),
+		Cols:   make([][]types.ClueItem, g.Width()),
+	}
+	for i, row := range g.Rows {
+		p.Rows[i] = row.Clues
+	}
+	for i, col := range g.Cols {
+		p.Cols[i] = col.Clues
+	}
+	if cells, complete := Cells(g); complete {
+		p.Solution = cells
+	}
+	return p
+}
+
+// FromCells builds a puzzle whose solution is the given cells by row (0 =
+// empty, otherwise a color ID), deriving the clues with LineClues
+func FromCells(id string, cells [][]int, colors map[int]string) *Puzzle {
+	height, width := len(cells), 0
+	if height > 0 {
+		width = len(cells[0])
+	}
+	p := &Puzzle{
+		ID:       id,
+		Width:    width,
+		Height:   height,
+		Colors:   colors,
+		Rows:     make([][]types.ClueItem, height),
+		Cols:     make([][]types.ClueItem, width),
+		Solution: cells,
+	}
+	for r, row := range cells {
+		p.Rows[r] = LineClues(row)
+	}
+	column := make([]int, height)
+	for c := 0; c < width; c++ {
+		for r := range cells {
+			column[r] = cells[r][c]
+		}
+		p.Cols[c] = LineClues(column)
+	}
+	return p
+}
+
+// LineClues extracts the clues of a row or column of cells.
+// This function analyzes consecutive blocks of the same color and creates clue items.
+func LineClues(cells []int) []types.ClueItem {
+	var (
+		clues        []types.ClueItem
+		currentColor int
+		currentCount int
+	)
+
+	for _, cell := range cells {
+		switch {
+		case cell <= 0:
+			if currentCount == 0 {
+				continue
+			}
+			clues = append(clues, types.ClueItem{ColorID: currentColor, Clue: currentCount})
+			currentColor = 0
+			currentCount = 0
+		case cell == currentColor:
+			currentCount++
+		default:
+			if currentCount > 0 {
+				clues = append(clues, types.ClueItem{ColorID: currentColor, Clue: currentCount})
+			}
+			currentColor = cell
+			currentCount = 1
+		}
+	}
+
+	if currentCount > 0 {
+		clues = append(clues, types.ClueItem{ColorID: currentColor, Clue: currentCount})
+	}
+
+	return clues
+}
+
+// Cells returns the grid's known cells by row: -1 = unknown, 0 = empty,
+// otherwise a color ID. A cell its row and column disagree on is unknown.
+// complete reports whether no cell is unknown.
+func Cells(g *types.Grid) (cells [][]int, complete bool) {
+	complete = true
+	cells = make([][]int, len(g.Rows))
+	for r, row := range g.Rows {
+		cells[r] = make([]int, row.Length)
+		for c := 0; c < row.Length; c++ {
+			cell, err := g.At(r, c)
+			if err != nil || !cell.Known() {
+				complete = false
+			}
+			cells[r][c] = cell.Value()
+		}
+	}
+	return cells, complete
+}
+
+// Clues returns the clues keyed by line, as expected by the grid factory
+func (p *Puzzle) Clues() map[types.LineID][]types.ClueItem {
+	clues := make(map[types.LineID][]types.ClueItem, len(p.Rows)+len(p.Cols))
+	for i, row := range p.Rows {
+		clues[types.LineID{Direction: types.Row, Index: i}] = row
+	}
+	for i, col := range p.Cols {
+		clues[types.LineID{Direction: types.Column, Index: i}] = col
+	}
+	return clues
+}
+
+// Grid builds a solvable Grid whose providers share the resources in opts
+func (p *Puzzle) Grid(opts factory.ProviderOptions) types.Grid {
+	return factory.CreateGridFromCluesWithOptions(p.Clues(), p.Width, p.Height, p.Colors, opts)
+}
+
+// Validate checks the puzzle's shape: dimensions, clue list counts and, when
+// present, the solution's shape and colors.
+func (p *Puzzle) Validate() error {
+	if p.Width <= 0 || p.Height <= 0 {
+		return fmt.Errorf("invalid puzzle dimensions: %dx%d", p.Width, p.Height)
+	}
+	if len(p.Rows) != p.Height {
+		return fmt.Errorf("puzzle has %d row clue lists, expected %d", len(p.Rows), p.Height)
+	}
+	if len(p.Cols) != p.Width {
+		return fmt.Errorf("puzzle has %d column clue lists, expected %d", len(p.Cols), p.Width)
+	}
+	if p.Solution == nil {
+		return nil
+	}
+	if len(p.Solution) != p.Height {
+		return fmt.Errorf("solution has %d rows, expected %d", len(p.Solution), p.Height)
+	}
+	for r, row := range p.Solution {
+		if len(row) != p.Width {
+			return fmt.Errorf("solution row %d has %d cells, expected %d", r, len(row), p.Width)
+		}
+		for c, color := range row {
+			if color < 0 {
+				return fmt.Errorf("solution cell (%d,%d) has invalid color %d", r, c, color)
+			}
+			if _, ok := p.Colors[color]; color > 0 && !ok {
+				return fmt.Errorf("solution cell (%d,%d) has unknown color %d", r, c, color)
+			}
+		}
+	}
+	return nil
+}
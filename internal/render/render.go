@@ -0,0 +1,119 @@
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"nonogram-solver/internal/puzzle"
+	"nonogram-solver/internal/types"
+)
+
+// Style selects how cells are drawn
+type Style string
+
+const (
+	// Text draws one symbol per cell, see puzzle.Symbol
+	Text Style = "text"
+	// ANSI draws two-character cells with 24-bit terminal background colors
+	ANSI Style = "ansi"
+)
+
+// ParseStyle validates a style name
+func ParseStyle(name string) (Style, error) {
+	switch Style(strings.ToLower(name)) {
+	case Text:
+		return Text, nil
+	case ANSI:
+		return ANSI, nil
+	default:
+		return "", fmt.Errorf("unknown render style %q (want text or ansi)", name)
+	}
+}
+
+// Options controls what is drawn around the cells
+type Options struct {
+	Style Style
+	// Clues prints each row's clues after the row and the column clues below the grid
+	Clues bool
+}
+
+// Grid draws the grid's known cells; unknown cells are shown as '?'
+func Grid(w io.Writer, g *types.Grid, opts Options) error {
+	cells, _ := puzzle.Cells(g)
+	return Cells(w, puzzle.FromGrid("", g), cells, opts)
+}
+
+// Cells draws a cell matrix (-1 unknown, 0 empty, otherwise a color ID) using the
+// puzzle's palette and clues
+func Cells(w io.Writer, p *puzzle.Puzzle, cells [][]int, opts Options) error {
+	bw := bufio.NewWriter(w)
+	monochrome := p.IsMonochrome()
+
+	for r, row := range cells {
+		for _, color := range row {
+			if opts.Style == ANSI {
+				bw.WriteString(ansiCell(color, p.Colors[color], monochrome))
+			} else {
+				bw.WriteByte(puzzle.Symbol(color, monochrome))
+			}
+		}
+		if opts.Clues && r < len(p.Rows) {
+			bw.WriteString("  ")
+			bw.WriteString(clueText(p.Rows[r], monochrome))
+		}
+		bw.WriteByte('\n')
+	}
+
+	if opts.Clues {
+		for c, clues := range p.Cols {
+			fmt.Fprintf(bw, "col %d: %s\n", c, clueText(clues, monochrome))
+		}
+	}
+	return bw.Flush()
+}
+
+func clueText(clues []types.ClueItem, monochrome bool) string {
+	if len(clues) == 0 {
+		return "-"
+	}
+	tokens := make([]string, len(clues))
+	for i, clue := range clues {
+		if monochrome {
+			tokens[i] = strconv.Itoa(clue.Clue)
+		} else {
+			tokens[i] = fmt.Sprintf("%d:%d", clue.Clue, clue.ColorID)
+		}
+	}
+	return strings.Join(tokens, " ")
+}
+
+func ansiCell(color int, hex string, monochrome bool) string {
+	switch {
+	case color < 0:
+		return "??"
+	case color == 0:
+		return ". "
+	}
+	r, g, b, ok := ParseHex(hex)
+	if !ok {
+		symbol := string(puzzle.Symbol(color, monochrome))
+		return symbol + symbol
+	}
+	return fmt.Sprintf("\x1b[48;2;%d;%d;%dm  \x1b[0m", r, g, b)
+}
+
+// ParseHex decodes a #rrggbb palette color
+func ParseHex(hex string) (r, g, b int, ok bool) {
+	hex = strings.TrimPrefix(hex, "#")
+	if len(hex) != 6 {
+		return 0, 0, 0, false
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	return int(v >> 16 & 0xFF), int(v >> 8 & 0xFF), int(v & 0xFF), true
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"nonogram-solver/internal/puzzle"
+	"nonogram-solver/internal/solver"
+)
+
+// Job states
+const (
+	jobQueued    = "queued"
+	jobRunning   = "running"
+	jobDone      = "done"
+	jobCancelled = "cancelled"
+)
+
+// job is an asynchronous solve whose events are kept so that any number of
+// subscribers can replay them from the start
+type job struct {
+	id     string
+	puzzle *puzzle.Puzzle
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	state  string
+	events []solver.Event
+	report *solver.Report
+	notify chan struct{} // closed and replaced whenever events or state change
+}
+
+func newJobID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// publish appends an event and wakes subscribers
+func (j *job) publish(e solver.Event) {
+	j.mu.Lock()
+	j.events = append(j.events, e)
+	j.wakeLocked()
+	j.mu.Unlock()
+}
+
+func (j *job) setState(state string, report *solver.Report) {
+	j.mu.Lock()
+	// A cancelled job keeps that state when the solver returns
+	if j.state != jobCancelled || state == jobCancelled {
+		j.state = state
+	}
+	if report != nil {
+		j.report = report
+	}
+	j.wakeLocked()
+	j.mu.Unlock()
+}
+
+func (j *job) wakeLocked() {
+	close(j.notify)
+	j.notify = make(chan struct{})
+}
+
+// since returns the events from index from, whether the job has finished, and a
+// channel closed on the next change
+func (j *job) since(from int) ([]solver.Event, bool, <-chan struct{}) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	var events []solver.Event
+	if from < len(j.events) {
+		events = j.events[from:len(j.events):len(j.events)]
+	}
+	return events, j.report != nil, j.notify
+}
+
+// jobStatus is the body of job creation and GET /solve/{jobId}
+type jobStatus struct {
+	ID     string         `json:"id"`
+	State  string         `json:"state"`
+	Events int            `json:"events"`
+	Report *solver.Report `json:"report,omitempty"`
+}
+
+func (j *job) status() jobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return jobStatus{ID: j.id, State: j.state, Events: len(j.events), Report: j.report}
+}
+
+// jobs is the registry of running and recently finished jobs
+type jobs struct {
+	mu   sync.Mutex
+	byID map[string]*job
+}
+
+func (r *jobs) get(id string) *job {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.byID[id]
+}
+
+// add registers a job unless max jobs are already held
+func (r *jobs) add(j *job, max int) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.byID) >= max {
+		return false
+	}
+	r.byID[j.id] = j
+	return true
+}
+
+// expire forgets a finished job after ttl
+func (r *jobs) expire(j *job, ttl time.Duration) {
+	time.AfterFunc(ttl, func() {
+		r.mu.Lock()
+		delete(r.byID, j.id)
+		r.mu.Unlock()
+	})
+}
@@ -0,0 +1,260 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"nonogram-solver/internal/combinatorics"
+	"nonogram-solver/internal/factory"
+	network "nonogram-solver/internal/network"
+	"nonogram-solver/internal/puzzle"
+	"nonogram-solver/internal/solver"
+)
+
+const (
+	// DefaultTimeout bounds each request when Options.Timeout is zero
+	DefaultTimeout = 30 * time.Second
+	// DefaultMaxJobs and DefaultJobTTL bound the job registry when unset
+	DefaultMaxJobs = 64
+	DefaultJobTTL  = 10 * time.Minute
+	// maxBodyBytes caps uploaded puzzles
+	maxBodyBytes = 1 << 20
+)
+
+var numericID = regexp.MustCompile(`^[0-9]+$`)
+
+// Options configures the HTTP API
+type Options struct {
+	// Timeout is the longest a request may run; clients can ask for less with
+	// ?timeout=<duration>. Zero means DefaultTimeout.
+	Timeout time.Duration
+	// MaxConcurrent bounds the requests solving at once; others wait for a slot
+	// until their timeout. <= 0 means GOMAXPROCS.
+	MaxConcurrent int
+	// Workers bounds line work across all requests; <= 0 means GOMAXPROCS
+	Workers int
+	// Search enables backtracking for /solve; /check always searches
+	Search bool
+	// MaxJobs bounds the asynchronous jobs held at once, running or finished;
+	// <= 0 means DefaultMaxJobs
+	MaxJobs int
+	// JobTTL is how long a finished job and its events are kept; <= 0 means
+	// DefaultJobTTL
+	JobTTL time.Duration
+	// Fetch loads puzzles for GET /puzzles/{id} under the request's context;
+	// nil means an uncached network.Source
+	Fetch func(ctx context.Context, id string) (*puzzle.Puzzle, error)
+	// Logf, if set, receives one line per request
+	Logf func(format string, args ...any)
+}
+
+// Server serves the solver over HTTP
+type Server struct {
+	opts     Options
+	requests *combinatorics.Semaphore
+	sem      *combinatorics.Semaphore
+	jobs     jobs
+}
+
+// New creates a server; use Handler to mount it
+func New(opts Options) *Server {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.MaxJobs <= 0 {
+		opts.MaxJobs = DefaultMaxJobs
+	}
+	if opts.JobTTL <= 0 {
+		opts.JobTTL = DefaultJobTTL
+	}
+	if opts.Fetch == nil {
+		opts.Fetch = network.Source{}.Fetch
+	}
+	return &Server{
+		opts:     opts,
+		requests: combinatorics.NewSemaphore(opts.MaxConcurrent),
+		sem:      combinatorics.NewSemaphore(opts.Workers),
+		jobs:     jobs{byID: make(map[string]*job)},
+	}
+}
+
+// Handler returns the API routes:
+//
+//	POST /solve          puzzle JSON in, solver.Report out
+//	POST /check          puzzle JSON in, checkResponse out
+//	GET  /puzzles/{id}   nonograms.org puzzle as puzzle JSON, without its solution
+//
+//	POST   /solve/jobs             start an asynchronous solve, 202 with the job status
+//	GET    /solve/{jobId}          job state and, once finished, its report
+//	GET    /solve/{jobId}/events   Server-Sent Events: every solver.Event, then "end"
+//	DELETE /solve/{jobId}          cancel the job
+//
+// Solver outcomes (including contradiction and timeout) are 200 responses with
+// a status field; errors are {"error": "..."} with a 4xx or 5xx code.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /solve", s.limited(s.handleSolve))
+	mux.HandleFunc("POST /check", s.limited(s.handleCheck))
+	mux.HandleFunc("GET /puzzles/{id}", s.limited(s.handleFetch))
+	mux.HandleFunc("POST /solve/jobs", s.handleCreateJob)
+	mux.HandleFunc("GET /solve/{jobId}", s.handleJobStatus)
+	mux.HandleFunc("GET /solve/{jobId}/events", s.handleJobEvents)
+	mux.HandleFunc("DELETE /solve/{jobId}", s.handleCancelJob)
+	return s.logged(mux)
+}
+
+// checkResponse is the body of POST /check. Solutions is capped at 2.
+type checkResponse struct {
+	ID        string `json:"id,omitempty"`
+	Status    string `json:"status"`
+	Unique    bool   `json:"unique"`
+	Solutions int    `json:"solutions"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+func (s *Server) handleSolve(w http.ResponseWriter, r *http.Request) {
+	p, ok := readPuzzle(w, r)
+	if !ok {
+		return
+	}
+	opts, providers := s.solverOptions(s.opts.Search)
+	g := p.Grid(providers)
+	result, err := solver.Solve(r.Context(), &g, opts)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, solver.NewReport(p.ID, &g, result))
+}
+
+func (s *Server) handleCheck(w http.ResponseWriter, r *http.Request) {
+	p, ok := readPuzzle(w, r)
+	if !ok {
+		return
+	}
+	opts, providers := s.solverOptions(true)
+	g := p.Grid(providers)
+	count, result, err := solver.CountSolutions(r.Context(), &g, 2, opts)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	resp := checkResponse{ID: p.ID, Status: result.Status.String(), Unique: count == 1, Solutions: count}
+	if result.Reason != nil {
+		resp.Reason = result.Reason.Error()
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleFetch(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !numericID.MatchString(id) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid puzzle ID %q", id))
+		return
+	}
+	p, err := s.opts.Fetch(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	clues := *p
+	clues.Solution = nil
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	puzzle.Write(w, &clues, puzzle.FormatJSON)
+}
+
+// limited applies the request timeout and waits for a concurrency slot
+func (s *Server) limited(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		timeout, err := s.requestTimeout(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		if err := s.requests.Acquire(ctx); err != nil {
+			writeError(w, http.StatusServiceUnavailable, errors.New("server busy"))
+			return
+		}
+		defer s.requests.Release()
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// requestTimeout is the server timeout, shortened by ?timeout=<duration>
+func (s *Server) requestTimeout(r *http.Request) (time.Duration, error) {
+	value := r.URL.Query().Get("timeout")
+	if value == "" {
+		return s.opts.Timeout, nil
+	}
+	requested, err := time.ParseDuration(value)
+	if err != nil || requested <= 0 {
+		return 0, fmt.Errorf("invalid timeout %q", value)
+	}
+	return min(s.opts.Timeout, requested), nil
+}
+
+func (s *Server) solverOptions(search bool) (solver.Options, factory.ProviderOptions) {
+	opts := solver.Options{
+		Semaphore:       s.sem,
+		Search:          search,
+		MaxCombinations: solver.DefaultMaxCombinations,
+	}
+	return opts, factory.ProviderOptions{Semaphore: s.sem, MaxCombinations: solver.DefaultMaxCombinations}
+}
+
+// statusRecorder remembers the response code for logging
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap lets http.ResponseController reach the connection, e.g. to flush
+// event streams
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func (s *Server) logged(next http.Handler) http.Handler {
+	if s.opts.Logf == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		s.opts.Logf("%s %s %d %v", r.Method, r.URL.Path, rec.status, time.Since(start))
+	})
+}
+
+func readPuzzle(w http.ResponseWriter, r *http.Request) (*puzzle.Puzzle, bool) {
+	p, err := puzzle.Read(http.MaxBytesReader(w, r.Body, maxBodyBytes), puzzle.FormatJSON)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return nil, false
+	}
+	return p, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
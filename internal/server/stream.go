@@ -0,0 +1,180 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+
+	"nonogram-solver/internal/solver"
+)
+
+// eventJSON is the wire form of a solver.Event
+type eventJSON struct {
+	Type         string     `json:"type"`
+	Depth        int        `json:"depth"`
+	Line         *lineJSON  `json:"line,omitempty"`
+	Operation    string     `json:"operation,omitempty"`
+	Combinations *big.Int   `json:"combinations,omitempty"`
+	Cells        []cellJSON `json:"cells,omitempty"`
+	Candidates   int        `json:"candidates,omitempty"`
+	Known        int        `json:"known,omitempty"`
+	Total        int        `json:"total,omitempty"`
+	Status       string     `json:"status,omitempty"`
+	Reason       string     `json:"reason,omitempty"`
+}
+
+type lineJSON struct {
+	Direction string `json:"direction"`
+	Index     int    `json:"index"`
+}
+
+type cellJSON struct {
+	Row   int `json:"row"`
+	Col   int `json:"col"`
+	Color int `json:"color"`
+}
+
+func toEventJSON(e solver.Event) eventJSON {
+	out := eventJSON{Type: e.Kind.String(), Depth: e.Depth, Operation: e.Operation}
+	switch e.Kind {
+	case solver.EventDeduction:
+		out.Line = &lineJSON{Direction: e.Line.Direction.String(), Index: e.Line.Index}
+		out.Combinations = e.Combinations
+	case solver.EventGuess:
+		out.Candidates = e.Candidates
+	case solver.EventProgress:
+		out.Known, out.Total = e.Known, e.Total
+	case solver.EventDone:
+		out.Status = e.Status.String()
+		if e.Reason != nil {
+			out.Reason = e.Reason.Error()
+		}
+	}
+	for _, cell := range e.Cells {
+		out.Cells = append(out.Cells, cellJSON{Row: cell.Row, Col: cell.Col, Color: cell.Color})
+	}
+	return out
+}
+
+// handleCreateJob starts an asynchronous solve and returns its ID with 202
+func (s *Server) handleCreateJob(w http.ResponseWriter, r *http.Request) {
+	timeout, err := s.requestTimeout(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	p, ok := readPuzzle(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	j := &job{id: newJobID(), puzzle: p, cancel: cancel, state: jobQueued, notify: make(chan struct{})}
+	if !s.jobs.add(j, s.opts.MaxJobs) {
+		cancel()
+		writeError(w, http.StatusServiceUnavailable, errors.New("too many jobs"))
+		return
+	}
+	go s.runJob(ctx, j)
+
+	w.Header().Set("Location", "/solve/"+j.id)
+	writeJSON(w, http.StatusAccepted, j.status())
+}
+
+// runJob solves the job's puzzle once a concurrency slot is free
+func (s *Server) runJob(ctx context.Context, j *job) {
+	defer s.jobs.expire(j, s.opts.JobTTL)
+	defer j.cancel()
+
+	if err := s.requests.Acquire(ctx); err != nil {
+		j.publish(solver.Event{Kind: solver.EventDone, Status: solver.Timeout})
+		j.setState(jobDone, &solver.Report{ID: j.puzzle.ID, Status: solver.Timeout.String()})
+		return
+	}
+	defer s.requests.Release()
+	j.setState(jobRunning, nil)
+
+	opts, providers := s.solverOptions(s.opts.Search)
+	opts.Events = j.publish
+	g := j.puzzle.Grid(providers)
+	result, err := solver.Solve(ctx, &g, opts)
+	if err != nil {
+		j.setState(jobDone, &solver.Report{ID: j.puzzle.ID, Status: "error", Reason: err.Error()})
+		return
+	}
+	report := solver.NewReport(j.puzzle.ID, &g, result)
+	j.setState(jobDone, &report)
+}
+
+func (s *Server) handleJobStatus(w http.ResponseWriter, r *http.Request) {
+	j := s.jobs.get(r.PathValue("jobId"))
+	if j == nil {
+		writeError(w, http.StatusNotFound, errors.New("unknown job"))
+		return
+	}
+	writeJSON(w, http.StatusOK, j.status())
+}
+
+// handleCancelJob stops a job; its stream ends with a timeout done event
+func (s *Server) handleCancelJob(w http.ResponseWriter, r *http.Request) {
+	j := s.jobs.get(r.PathValue("jobId"))
+	if j == nil {
+		writeError(w, http.StatusNotFound, errors.New("unknown job"))
+		return
+	}
+	if j.status().Report == nil {
+		j.setState(jobCancelled, nil)
+		j.cancel()
+	}
+	writeJSON(w, http.StatusOK, j.status())
+}
+
+// handleJobEvents streams the job's events as Server-Sent Events, replaying from
+// the start or from after Last-Event-ID. The stream ends with an "end" event
+// carrying the job status.
+func (s *Server) handleJobEvents(w http.ResponseWriter, r *http.Request) {
+	j := s.jobs.get(r.PathValue("jobId"))
+	if j == nil {
+		writeError(w, http.StatusNotFound, errors.New("unknown job"))
+		return
+	}
+	rc := http.NewResponseController(w)
+
+	next := 0
+	if last := r.Header.Get("Last-Event-ID"); last != "" {
+		if id, err := strconv.Atoi(last); err == nil && id >= 0 {
+			next = id + 1
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	for {
+		events, finished, changed := j.since(next)
+		for _, e := range events {
+			data, _ := json.Marshal(toEventJSON(e))
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", next, e.Kind, data)
+			next++
+		}
+		if finished && len(events) == 0 {
+			data, _ := json.Marshal(j.status())
+			fmt.Fprintf(w, "event: end\ndata: %s\n\n", data)
+			rc.Flush()
+			return
+		}
+		rc.Flush()
+		if len(events) > 0 {
+			continue
+		}
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package solver
+
+import (
+	"nonogram-solver/internal/factory"
+	"nonogram-solver/internal/types"
+)
+
+// copyLine returns l with the given facts and a fresh provider, and a func
+// returning the provider's cached combinations to the memory budget once the
+// copy is dropped
+func (s *solver) copyLine(l *types.Line, facts *types.Facts) (*types.Line, func()) {
+	provider := factory.NewLineProvider(l.Clues, l.Length, s.providers)
+	return &types.Line{
+		ID:           l.ID,
+		Direction:    l.Direction,
+		Length:       l.Length,
+		Clues:        l.Clues,
+		Facts:        facts,
+		Combinations: provider,
+	}, provider.Release
+}
+
+// copyFacts replaces dst's facts with copies of src's
+func copyFacts(dst, src *types.Grid) {
+	for i, row := range src.Rows {
+		dst.Rows[i].Facts = row.Facts.Clone()
+	}
+	for i, col := range src.Cols {
+		dst.Cols[i].Facts = col.Facts.Clone()
+	}
+}
@@ -0,0 +1,110 @@
+package solver
+
+import (
+	"math/big"
+
+	"nonogram-solver/internal/line"
+	"nonogram-solver/internal/types"
+)
+
+// EventKind identifies what an Event reports
+type EventKind int
+
+const (
+	EventDeduction EventKind = iota // a line operation fixed cells
+	EventProgress                   // a propagation pass finished
+	EventGuess                      // the search assigned a cell
+	EventBacktrack                  // a guess failed; its branch is discarded
+	EventDone                       // the solve finished
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventDeduction:
+		return "deduction"
+	case EventProgress:
+		return "progress"
+	case EventGuess:
+		return "guess"
+	case EventBacktrack:
+		return "backtrack"
+	case EventDone:
+		return "done"
+	default:
+		return "unknown"
+	}
+}
+
+// Line operations reported by deduction events
+const (
+	OpOverlap = "overlap" // cross-reference and overlap of enumerated combinations
+	OpSettle  = "settle"  // the non-enumerating line solver
+	OpSimple  = "simple"  // simple boxes and spaces only, while rating
+)
+
+// Cell is a grid position and its state: 0 for empty, otherwise a color ID
+type Cell struct {
+	Row   int
+	Col   int
+	Color int
+}
+
+// Event is one step of a solve. Events are delivered in order on the solving
+// goroutine, so handlers must return quickly.
+//
+// Depth is 0 for the puzzle's own grid and d for the branch opened by the
+// d-th nested guess. A backtrack at depth d undoes the guess at depth d and
+// every deduction made at depth d or deeper since that guess.
+type Event struct {
+	Kind  EventKind
+	Depth int
+	// Line, Operation and Combinations are set for deductions. Combinations
+	// counts the line's placements that still agree with its facts.
+	Line         types.LineID
+	Operation    string
+	Combinations *big.Int
+	// Cells holds the fixed cells of a deduction, or the guessed cell of a guess
+	// or backtrack
+	Cells []Cell
+	// Candidates counts the states not yet tried for a guessed cell; a guess
+	// with none left after earlier ones backtracked is forced
+	Candidates int
+	// Known and Total count cells for progress events
+	Known int
+	Total int
+	// Status and Reason are set for done events
+	Status Status
+	Reason error
+}
+
+func (s *solver) emit(e Event) {
+	if s.opts.Events != nil {
+		e.Depth = s.depth
+		s.opts.Events(e)
+	}
+}
+
+// emitDeduction reports a line's changes as grid cells
+func (s *solver) emitDeduction(g *types.Grid, l *types.Line, op string, changes []line.Change) {
+	if s.opts.Events == nil || len(changes) == 0 {
+		return
+	}
+	s.emit(Event{Kind: EventDeduction, Line: l.ID, Operation: op, Cells: toCells(l.ID, changes), Combinations: line.Count(l)})
+}
+
+// emitProgress reports how many cells of g are known
+func (s *solver) emitProgress(g *types.Grid) {
+	if s.opts.Events == nil {
+		return
+	}
+	known, total := 0, 0
+	for _, row := range g.Rows {
+		for i := 0; i < row.Length; i++ {
+			if row.Facts.IsKnown(i) {
+				known++
+			}
+		}
+		total += row.Length
+	}
+	s.emit(Event{Kind: EventProgress, Known: known, Total: total})
+}
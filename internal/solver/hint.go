@@ -0,0 +1,244 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"nonogram-solver/internal/combinatorics"
+	"nonogram-solver/internal/line"
+	"nonogram-solver/internal/puzzle"
+	"nonogram-solver/internal/types"
+)
+
+// Hint operations, from least to most effort for a person
+const (
+	HintMistake        = "mistake"         // user cells contradict the solution
+	HintOverlap        = "overlap"         // the line's clues alone force the cells
+	HintCrossReference = "cross-reference" // the clues and the line's known cells force them
+	HintProbe          = "probe"           // every other state of the cell leads to a contradiction
+)
+
+// Hint is the easiest next step from a user's partially filled grid
+type Hint struct {
+	Operation string
+	// Line is the line that forces Cells, or for a mistake without cells the
+	// line whose user cells cannot satisfy its clues
+	Line types.LineID
+	// Cells are the forced cells, or for a mistake the wrong cells with their
+	// correct states
+	Cells []Cell
+	// Combinations counts the line's placements that agree with the user's
+	// cells; nil for mistakes and probes
+	Combinations *big.Int
+}
+
+// ErrNoSolution is returned by NextHint when the user's cells admit no
+// solution but no single cell or line is to blame
+var ErrNoSolution = errors.New("the user's cells admit no solution")
+
+// NextHint checks state, the user's cells by row (-1 unknown, 0 empty,
+// otherwise a color ID), against the grid's clues and returns the easiest next
+// deduction. Mistakes come first, then lines forced by their clues alone, then
+// lines forced by their clues and known cells, preferring the line with the
+// fewest surviving combinations and then the most cells. When no line forces a
+// cell, the first cell in row-major order whose other states all lead to a
+// contradiction is returned as a probe. ok is false when nothing can be deduced.
+// g is left untouched; opts.Events is ignored.
+func NextHint(ctx context.Context, g *types.Grid, state [][]int, opts Options) (hint Hint, ok bool, err error) {
+	if err := checkState(g, state); err != nil {
+		return Hint{}, false, err
+	}
+	reference, err := Reference(ctx, g, opts)
+	if err != nil {
+		return Hint{}, false, err
+	}
+	if hint, ok := mistakes(state, reference); ok {
+		return hint, true, nil
+	}
+
+	opts.Events = nil
+	s := newSolver(opts)
+	work := g.Clone()
+	defer work.Release()
+	for r, row := range state {
+		for c, color := range row {
+			if color < 0 {
+				continue
+			}
+			if err := work.Set(r, c, types.ColorCell(color)); err != nil {
+				return Hint{}, false, fmt.Errorf("row %d column %d: %w", r+1, c+1, err)
+			}
+		}
+	}
+
+	hint, ok, err = s.lineHint(ctx, work)
+	if ok || err != nil {
+		return hint, ok, err
+	}
+	return s.probeHint(ctx, work)
+}
+
+// Reference returns the cells a user's grid is checked against: the solution
+// when the grid has exactly one, otherwise only what line propagation forces
+// (-1 elsewhere). g is left untouched.
+func Reference(ctx context.Context, g *types.Grid, opts Options) ([][]int, error) {
+	opts.Events = nil
+	opts.Search = false
+	work := g.Clone()
+	defer work.Release()
+	result, err := Solve(ctx, work, opts)
+	if err != nil {
+		return nil, err
+	}
+	switch result.Status {
+	case Contradiction:
+		return nil, fmt.Errorf("puzzle has no solution: %v", result.Reason)
+	case Timeout:
+		return nil, ctx.Err()
+	}
+	cells, _ := puzzle.Cells(work)
+	if result.Status == Solved {
+		return cells, nil
+	}
+
+	count, _, err := CountSolutions(ctx, work, 2, opts)
+	if err != nil || count != 1 {
+		return cells, err
+	}
+	opts.Search = true
+	if _, err := Solve(ctx, work, opts); err != nil {
+		return nil, err
+	}
+	cells, _ = puzzle.Cells(work)
+	return cells, nil
+}
+
+func checkState(g *types.Grid, state [][]int) error {
+	if len(state) != g.Height() {
+		return fmt.Errorf("user grid has %d rows, want %d", len(state), g.Height())
+	}
+	for r, row := range state {
+		if len(row) != g.Width() {
+			return fmt.Errorf("user grid row %d has %d cells, want %d", r+1, len(row), g.Width())
+		}
+		for c, color := range row {
+			if _, ok := g.Colors[color]; color > 0 && !ok {
+				return fmt.Errorf("user grid row %d column %d: unknown color %d", r+1, c+1, color)
+			}
+		}
+	}
+	return nil
+}
+
+// mistakes returns the user's cells that disagree with the reference
+func mistakes(state, reference [][]int) (Hint, bool) {
+	var cells []Cell
+	for r, row := range state {
+		for c, color := range row {
+			if want := reference[r][c]; color >= 0 && want >= 0 && color != want {
+				cells = append(cells, Cell{Row: r, Col: c, Color: want})
+			}
+		}
+	}
+	return Hint{Operation: HintMistake, Cells: cells}, cells != nil
+}
+
+// processCopy runs processLine on a copy of l with the given facts, so l
+// itself is left untouched
+func (s *solver) processCopy(ctx context.Context, l *types.Line, facts *types.Facts) ([]line.Change, error) {
+	copied, release := s.copyLine(l, facts)
+	defer release()
+	changes, _, err := s.processLine(ctx, copied)
+	return changes, err
+}
+
+// lineHint runs each line through the solver's line operations twice, with and
+// without the user's cells, to split what the clues force alone from what
+// needs the known cells too
+func (s *solver) lineHint(ctx context.Context, g *types.Grid) (Hint, bool, error) {
+	var overlap, crossReference Hint
+	for _, id := range allLines(g) {
+		l := lineAt(g, id)
+		changes, err := s.processCopy(ctx, l, l.Facts.Clone())
+		switch {
+		case errors.Is(err, combinatorics.ErrInfeasibleLine):
+			return Hint{Operation: HintMistake, Line: id}, true, nil
+		case err != nil:
+			return Hint{}, false, err
+		case len(changes) == 0:
+			continue
+		}
+		bare, err := s.processCopy(ctx, l, types.NewFacts())
+		if err != nil {
+			return Hint{}, false, err
+		}
+
+		forced := make(map[int]int, len(bare))
+		for _, change := range bare {
+			forced[change.Pos] = change.Color
+		}
+		var byClues []line.Change
+		for _, change := range changes {
+			if color, ok := forced[change.Pos]; ok && color == change.Color {
+				byClues = append(byClues, change)
+			}
+		}
+
+		count := line.Count(l)
+		if byClues != nil {
+			overlap = easier(overlap, Hint{Operation: HintOverlap, Line: id, Cells: toCells(id, byClues), Combinations: count})
+		} else {
+			crossReference = easier(crossReference, Hint{Operation: HintCrossReference, Line: id, Cells: toCells(id, changes), Combinations: count})
+		}
+	}
+	switch {
+	case overlap.Cells != nil:
+		return overlap, true, nil
+	case crossReference.Cells != nil:
+		return crossReference, true, nil
+	}
+	return Hint{}, false, nil
+}
+
+// easier prefers fewer surviving combinations, then more cells, then the
+// current hint
+func easier(current, next Hint) Hint {
+	if current.Cells == nil {
+		return next
+	}
+	switch cmp := next.Combinations.Cmp(current.Combinations); {
+	case cmp < 0, cmp == 0 && len(next.Cells) > len(current.Cells):
+		return next
+	}
+	return current
+}
+
+// probeHint returns the first cell that probing decides
+func (s *solver) probeHint(ctx context.Context, g *types.Grid) (Hint, bool, error) {
+	cell, found, status, reason := s.findProbe(ctx, g)
+	var lineErr *types.LineError
+	switch {
+	case status == Timeout:
+		return Hint{}, false, ctx.Err()
+	case status == Contradiction && errors.As(reason, &lineErr):
+		return Hint{Operation: HintMistake, Line: lineErr.ID}, true, nil
+	case status == Contradiction:
+		return Hint{}, false, ErrNoSolution
+	case found:
+		return Hint{Operation: HintProbe, Cells: []Cell{cell}}, true, nil
+	}
+	return Hint{}, false, nil
+}
+
+func toCells(id types.LineID, changes []line.Change) []Cell {
+	cells := make([]Cell, len(changes))
+	for i, change := range changes {
+		cells[i] = Cell{Row: id.Index, Col: change.Pos, Color: change.Color}
+		if id.Direction == types.Column {
+			cells[i].Row, cells[i].Col = change.Pos, id.Index
+		}
+	}
+	return cells
+}
@@ -0,0 +1,177 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"nonogram-solver/internal/combinatorics"
+	"nonogram-solver/internal/line"
+	"nonogram-solver/internal/types"
+)
+
+// lineResult is the outcome of processing one line in a batch
+type lineResult struct {
+	changes []line.Change
+	op      string
+	err     error
+}
+
+// propagate runs line operations from the seed lines until nothing changes.
+// Lines are processed in batches of a single direction: each line in a batch only
+// writes its own facts, so the batch runs in parallel, and the orthogonal updates
+// are applied afterwards on the calling goroutine in line order.
+func (s *solver) propagate(ctx context.Context, g *types.Grid, seed []types.LineID) (Status, error) {
+	dirty := make(map[types.LineID]bool, len(seed))
+	for _, id := range seed {
+		dirty[id] = true
+	}
+
+	direction := types.Row
+	for len(dirty) > 0 {
+		if ctx.Err() != nil {
+			return Timeout, nil
+		}
+
+		batch := takeBatch(g, dirty, direction)
+		if len(batch) == 0 {
+			direction = flip(direction)
+			continue
+		}
+
+		results, err := s.runBatch(ctx, batch)
+		if err != nil {
+			return Timeout, nil
+		}
+		s.passes++
+
+		changed := 0
+		for i, res := range results {
+			l := batch[i]
+			if res.err != nil {
+				status, reason := classify(ctx, res.err)
+				if reason != nil {
+					reason = &types.LineError{ID: l.ID, Err: reason}
+				}
+				return status, reason
+			}
+			s.emitDeduction(g, l, res.op, res.changes)
+			for _, change := range res.changes {
+				orthID, orthPos := g.Orthogonal(l.ID, change.Pos)
+				orth := lineAt(g, orthID)
+				if known, ok := orth.Facts.ColorAt(orthPos); ok && known == change.Color {
+					continue
+				}
+				if err := line.Mark(orth.Facts, orthPos, change.Color); err != nil {
+					return Contradiction, &types.LineError{ID: orthID, Err: err}
+				}
+				dirty[orthID] = true
+				changed++
+			}
+		}
+		s.logf("pass %d: %d %s lines, %d cells deduced", s.passes, len(batch), direction, changed)
+		s.emitProgress(g)
+		direction = flip(direction)
+	}
+
+	if isSolved(g) {
+		return Solved, nil
+	}
+	return Stalled, nil
+}
+
+// runBatch processes the lines concurrently, bounded by the shared semaphore.
+// It only fails when ctx ends while waiting for a slot.
+func (s *solver) runBatch(ctx context.Context, batch []*types.Line) ([]lineResult, error) {
+	results := make([]lineResult, len(batch))
+	var wg sync.WaitGroup
+	for i, l := range batch {
+		if err := s.sem.Acquire(ctx); err != nil {
+			wg.Wait()
+			return nil, err
+		}
+		wg.Add(1)
+		go func(i int, l *types.Line) {
+			defer wg.Done()
+			defer s.sem.Release()
+			changes, op, err := s.lineOp(ctx, l)
+			if err == nil {
+				err = line.Apply(l, changes)
+			}
+			results[i] = lineResult{changes: changes, op: op, err: err}
+		}(i, l)
+	}
+	wg.Wait()
+	return results, nil
+}
+
+// processLine cross-references the line's combinations with its facts and runs
+// overlap. Lines whose combinations exceed the memory budget or the enumeration
+// limit switch to the non-enumerating line solver for the rest of the solve.
+func (s *solver) processLine(ctx context.Context, l *types.Line) ([]line.Change, string, error) {
+	if _, ok := s.fallback.Load(l.ID); !ok {
+		// Per-color providers generate lazily, so Overlap can hit the limits too
+		changes, err := overlapLine(ctx, l)
+		if err == nil {
+			return changes, OpOverlap, nil
+		}
+		if !errors.Is(err, combinatorics.ErrBudgetExceeded) && !errors.Is(err, combinatorics.ErrEnumerationLimit) {
+			return nil, OpOverlap, err
+		}
+		s.fallback.Store(l.ID, true)
+		s.logf("%s %d: %v, using line solver", l.ID.Direction, l.ID.Index+1, err)
+	}
+	changes, err := line.Settle(l)
+	return changes, OpSettle, err
+}
+
+func overlapLine(ctx context.Context, l *types.Line) ([]line.Change, error) {
+	if _, err := line.CrossReference(ctx, l); err != nil {
+		return nil, err
+	}
+	return line.Overlap(ctx, l)
+}
+
+// takeBatch removes and returns the dirty lines of one direction, lowest slack
+// first so the cheapest, most constrained lines lead.
+func takeBatch(g *types.Grid, dirty map[types.LineID]bool, direction types.Direction) []*types.Line {
+	var batch []*types.Line
+	for id := range dirty {
+		if id.Direction == direction {
+			batch = append(batch, lineAt(g, id))
+			delete(dirty, id)
+		}
+	}
+	sort.Slice(batch, func(i, j int) bool {
+		si, sj := slack(batch[i]), slack(batch[j])
+		if si != sj {
+			return si < sj
+		}
+		return batch[i].ID.Index < batch[j].ID.Index
+	})
+	return batch
+}
+
+func flip(d types.Direction) types.Direction {
+	if d == types.Row {
+		return types.Column
+	}
+	return types.Row
+}
+
+func lineAt(g *types.Grid, id types.LineID) *types.Line {
+	if id.Direction == types.Row {
+		return g.Rows[id.Index]
+	}
+	return g.Cols[id.Index]
+}
+
+func isSolved(g *types.Grid) bool {
+	for _, row := range g.Rows {
+		if !line.IsSolved(row) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,280 @@
+package solver
+
+import (
+	"context"
+	"fmt"
+
+	"nonogram-solver/internal/line"
+	"nonogram-solver/internal/types"
+)
+
+// Technique is a set of solving techniques; each includes the ones before it
+type Technique int
+
+const (
+	TechniqueNone           Technique = iota // nothing solves the grid
+	TechniqueOverlap                         // simple boxes and spaces from each clue's extreme placements
+	TechniqueLine                            // complete line solving, propagated across rows and columns
+	TechniqueCrossReference                  // intersecting each cell's row and column candidates
+	TechniqueProbe                           // trying each state of a cell one level deep
+	TechniqueBacktrack                       // backtracking search
+)
+
+func (t Technique) String() string {
+	switch t {
+	case TechniqueNone:
+		return "none"
+	case TechniqueOverlap:
+		return "overlap"
+	case TechniqueLine:
+		return "line"
+	case TechniqueCrossReference:
+		return "cross-reference"
+	case TechniqueProbe:
+		return "probe"
+	case TechniqueBacktrack:
+		return "backtrack"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseTechnique looks up a technique set by its String name
+func ParseTechnique(name string) (Technique, error) {
+	for t := TechniqueOverlap; t <= TechniqueBacktrack; t++ {
+		if t.String() == name {
+			return t, nil
+		}
+	}
+	return TechniqueNone, fmt.Errorf("unknown technique %q (want overlap, line, cross-reference, probe or backtrack)", name)
+}
+
+// Rating describes the weakest technique set that solves a grid
+type Rating struct {
+	Technique Technique
+	// Status is Solved, or the Contradiction or Timeout that stopped the rating
+	Status Status
+	Reason error
+	// Passes counts propagation batches under Technique, including those run
+	// while probing and searching
+	Passes int
+	// Probes counts cells decided by probing
+	Probes int
+	// Depth is the deepest nesting of probes or guesses: 1 for probing, the
+	// search depth for backtracking
+	Depth   int
+	Guesses int
+	// Unique reports whether the solution is the only one; deductive
+	// techniques only ever find unique solutions
+	Unique bool
+}
+
+// Rate solves copies of the grid with progressively stronger technique sets
+// and reports the first that finishes. g is left untouched; opts.Search and
+// opts.Events are ignored.
+func Rate(ctx context.Context, g *types.Grid, opts Options) (Rating, error) {
+	return RateWithin(ctx, g, TechniqueBacktrack, opts)
+}
+
+// RateWithin is Rate stopping after the hardest technique set; when none up to
+// it finishes, the rating is TechniqueNone with status Stalled
+func RateWithin(ctx context.Context, g *types.Grid, hardest Technique, opts Options) (Rating, error) {
+	opts.Events = nil
+	opts.Search = false
+	if reason, err := newSolver(opts).validate(g); err != nil {
+		return Rating{}, err
+	} else if reason != nil {
+		return Rating{Status: Contradiction, Reason: reason}, nil
+	}
+
+	for technique := TechniqueOverlap; technique <= hardest; technique++ {
+		s := newSolver(opts)
+		work := g.Clone()
+		status, reason := s.rate(ctx, work, technique)
+		work.Release()
+		rating := Rating{
+			Technique: technique,
+			Status:    status,
+			Reason:    reason,
+			Passes:    s.passes,
+			Probes:    s.probes,
+			Depth:     s.maxDepth,
+			Guesses:   s.guesses,
+			Unique:    true,
+		}
+		switch status {
+		case Stalled:
+			continue
+		case Solved:
+			if technique == TechniqueBacktrack {
+				count, result, err := CountSolutions(ctx, g, 2, opts)
+				if err != nil {
+					return Rating{}, err
+				}
+				if result.Status == Timeout {
+					return Rating{Status: Timeout}, nil
+				}
+				rating.Unique = count == 1
+			}
+		default:
+			rating.Technique = TechniqueNone
+		}
+		return rating, nil
+	}
+	return Rating{Status: Stalled}, nil
+}
+
+// rate runs the technique set on g
+func (s *solver) rate(ctx context.Context, g *types.Grid, technique Technique) (Status, error) {
+	if technique == TechniqueOverlap {
+		s.lineOp = func(_ context.Context, l *types.Line) ([]line.Change, string, error) {
+			changes, err := line.Simple(l)
+			return changes, OpSimple, err
+		}
+	}
+	status, reason := s.propagate(ctx, g, allLines(g))
+	for status == Stalled && technique >= TechniqueCrossReference {
+		seed, err := crossCandidates(g)
+		if err != nil {
+			return Contradiction, err
+		}
+		if len(seed) == 0 && technique >= TechniqueProbe {
+			cell, found, probed, reason := s.findProbe(ctx, g)
+			if probed != Stalled {
+				return probed, reason
+			}
+			if !found {
+				break
+			}
+			s.probes++
+			s.maxDepth = max(s.maxDepth, 1)
+			if err := g.Set(cell.Row, cell.Col, types.ColorCell(cell.Color)); err != nil {
+				return Contradiction, err
+			}
+			seed = []types.LineID{g.Rows[cell.Row].ID, g.Cols[cell.Col].ID}
+		}
+		if len(seed) == 0 {
+			break
+		}
+		status, reason = s.propagate(ctx, g, seed)
+	}
+	if status == Stalled && technique == TechniqueBacktrack {
+		var solution *types.Grid
+		solution, status, reason = s.search(ctx, g)
+		if solution != nil {
+			copyFacts(g, solution)
+			solution.Release()
+		}
+	}
+	return status, reason
+}
+
+// candidates returns each unknown cell's states allowed by both its row and
+// its column; known cells get nil
+func candidates(g *types.Grid) ([][][]int, error) {
+	cols := make([][][]int, len(g.Cols))
+	for c, l := range g.Cols {
+		possible, err := line.Possible(l)
+		if err != nil {
+			return nil, &types.LineError{ID: l.ID, Err: err}
+		}
+		cols[c] = possible
+	}
+
+	out := make([][][]int, len(g.Rows))
+	for r, l := range g.Rows {
+		possible, err := line.Possible(l)
+		if err != nil {
+			return nil, &types.LineError{ID: l.ID, Err: err}
+		}
+		out[r] = make([][]int, l.Length)
+		for c, states := range possible {
+			if l.Facts.IsKnown(c) {
+				continue
+			}
+			for _, state := range states {
+				for _, other := range cols[c][r] {
+					if other == state {
+						out[r][c] = append(out[r][c], state)
+					}
+				}
+			}
+			if out[r][c] == nil {
+				return nil, fmt.Errorf("row %d column %d: row and column share no state", r+1, c+1)
+			}
+		}
+	}
+	return out, nil
+}
+
+// crossCandidates fixes every unknown cell whose row and column agree on a
+// single state, returning the lines to propagate from
+func crossCandidates(g *types.Grid) ([]types.LineID, error) {
+	states, err := candidates(g)
+	if err != nil {
+		return nil, err
+	}
+	var seed []types.LineID
+	dirty := make(map[types.LineID]bool)
+	for r, row := range states {
+		for c, cell := range row {
+			if len(cell) != 1 {
+				continue
+			}
+			if err := g.Set(r, c, types.ColorCell(cell[0])); err != nil {
+				return nil, err
+			}
+			for _, id := range []types.LineID{g.Rows[r].ID, g.Cols[c].ID} {
+				if !dirty[id] {
+					dirty[id] = true
+					seed = append(seed, id)
+				}
+			}
+		}
+	}
+	return seed, nil
+}
+
+// findProbe tries every candidate state of each unknown cell in row-major
+// order and returns the first cell whose other states all propagate to a
+// contradiction. Each try runs on g and is rolled back from a snapshot, so g
+// ends as it started. status is Stalled unless a cell has no surviving state
+// (Contradiction) or ctx ends (Timeout).
+func (s *solver) findProbe(ctx context.Context, g *types.Grid) (cell Cell, found bool, status Status, reason error) {
+	states, err := candidates(g)
+	if err != nil {
+		return Cell{}, false, Contradiction, err
+	}
+	snapshot := g.Snapshot()
+	defer snapshot.Release()
+	for r, row := range states {
+		for c, cellStates := range row {
+			if len(cellStates) < 2 {
+				continue
+			}
+			var survivors []int
+			for _, color := range cellStates {
+				status := Contradiction
+				if g.Set(r, c, types.ColorCell(color)) == nil {
+					status, _ = s.propagate(ctx, g, []types.LineID{g.Rows[r].ID, g.Cols[c].ID})
+				}
+				if err := g.Restore(snapshot); err != nil {
+					return Cell{}, false, Contradiction, err
+				}
+				if status == Timeout {
+					return Cell{}, false, Timeout, nil
+				}
+				if status != Contradiction {
+					survivors = append(survivors, color)
+				}
+			}
+			switch len(survivors) {
+			case 0:
+				return Cell{}, false, Contradiction, fmt.Errorf("row %d column %d: every state leads to a contradiction", r+1, c+1)
+			case 1:
+				return Cell{Row: r, Col: c, Color: survivors[0]}, true, Stalled, nil
+			}
+		}
+	}
+	return Cell{}, false, Stalled, nil
+}
@@ -0,0 +1,38 @@
+package solver
+
+import (
+	"nonogram-solver/internal/puzzle"
+	"nonogram-solver/internal/types"
+)
+
+// Report is the JSON form of a solve, shared by the CLI and the HTTP API
+type Report struct {
+	ID      string  `json:"id,omitempty"`
+	Status  string  `json:"status"`
+	Reason  string  `json:"reason,omitempty"`
+	Passes  int     `json:"passes"`
+	Guesses int     `json:"guesses"`
+	Width   int     `json:"width"`
+	Height  int     `json:"height"`
+	Cells   [][]int `json:"cells"` // -1 = unknown, 0 = empty, otherwise a color ID
+	// Meta is the puzzle's source metadata, when known
+	Meta *puzzle.Meta `json:"meta,omitempty"`
+}
+
+// NewReport captures the grid's cells and the outcome of solving it
+func NewReport(id string, g *types.Grid, result Result) Report {
+	cells, _ := puzzle.Cells(g)
+	report := Report{
+		ID:      id,
+		Status:  result.Status.String(),
+		Passes:  result.Passes,
+		Guesses: result.Guesses,
+		Width:   g.Width(),
+		Height:  g.Height(),
+		Cells:   cells,
+	}
+	if result.Reason != nil {
+		report.Reason = result.Reason.Error()
+	}
+	return report
+}
@@ -0,0 +1,148 @@
+package solver
+
+import (
+	"context"
+	"fmt"
+
+	"nonogram-solver/internal/combinatorics"
+	"nonogram-solver/internal/line"
+	"nonogram-solver/internal/types"
+)
+
+// errExhausted is the Reason reported when the search tried every candidate
+var errExhausted = fmt.Errorf("%w: no assignment satisfies every line", combinatorics.ErrInfeasibleLine)
+
+// search runs a depth-first backtracking search from a stalled grid and returns
+// the first solution it finds.
+func (s *solver) search(ctx context.Context, g *types.Grid) (*types.Grid, Status, error) {
+	row, col, candidates, err := chooseCell(g)
+	if err != nil {
+		return nil, Contradiction, err
+	}
+
+	for i, color := range candidates {
+		if ctx.Err() != nil {
+			return nil, Timeout, nil
+		}
+		depth := s.depth
+		branch, status, reason := s.guess(ctx, g, row, col, color, len(candidates)-1-i)
+		switch status {
+		case Solved:
+			return branch, Solved, nil
+		case Stalled:
+			solution, status, _ := s.search(ctx, branch)
+			branch.Release()
+			if status == Solved || status == Timeout {
+				return solution, status, nil
+			}
+		case Timeout:
+			return nil, Timeout, reason
+		}
+		s.backtrack(depth, row, col, color)
+	}
+	return nil, Contradiction, errExhausted
+}
+
+// count explores every branch of a stalled grid and counts solutions, stopping
+// once limit is reached.
+func (s *solver) count(ctx context.Context, g *types.Grid, limit int) (int, Status, error) {
+	row, col, candidates, err := chooseCell(g)
+	if err != nil {
+		return 0, Contradiction, err
+	}
+
+	total := 0
+	for i, color := range candidates {
+		if ctx.Err() != nil {
+			return total, Timeout, nil
+		}
+		depth := s.depth
+		branch, status, _ := s.guess(ctx, g, row, col, color, len(candidates)-1-i)
+		switch status {
+		case Solved:
+			total++
+			branch.Release()
+		case Stalled:
+			n, status, _ := s.count(ctx, branch, limit-total)
+			branch.Release()
+			total += n
+			if status == Timeout {
+				return total, Timeout, nil
+			}
+		case Timeout:
+			return total, Timeout, nil
+		}
+		// Counting abandons every branch, solved or not
+		s.backtrack(depth, row, col, color)
+		if total >= limit {
+			break
+		}
+	}
+	if total == 0 {
+		return 0, Contradiction, errExhausted
+	}
+	return total, Solved, nil
+}
+
+// guess assigns color to cell (row, col) on a copy of g and propagates it one
+// level deeper than g. remaining counts the candidates left to try after this one.
+// The copy is only returned when it is solved or stalled; otherwise it is
+// released.
+func (s *solver) guess(ctx context.Context, g *types.Grid, row, col, color, remaining int) (*types.Grid, Status, error) {
+	s.guesses++
+	s.logf("guess: row %d column %d = %s", row+1, col+1, stateLabel(color))
+	s.depth++
+	s.maxDepth = max(s.maxDepth, s.depth)
+	s.emit(Event{Kind: EventGuess, Cells: []Cell{{Row: row, Col: col, Color: color}}, Candidates: remaining})
+
+	branch := g.Clone()
+	if err := branch.Set(row, col, types.ColorCell(color)); err != nil {
+		branch.Release()
+		return nil, Contradiction, err
+	}
+	seed := []types.LineID{branch.Rows[row].ID, branch.Cols[col].ID}
+	status, reason := s.propagate(ctx, branch, seed)
+	if status != Solved && status != Stalled {
+		branch.Release()
+		return nil, status, reason
+	}
+	return branch, status, reason
+}
+
+// backtrack returns to depth after the guess made there was abandoned
+func (s *solver) backtrack(depth, row, col, color int) {
+	s.depth = depth + 1
+	s.emit(Event{Kind: EventBacktrack, Cells: []Cell{{Row: row, Col: col, Color: color}}})
+	s.depth = depth
+}
+
+// chooseCell picks the unknown cell with the fewest possible states according to
+// its row, preferring the first such cell in row-major order.
+func chooseCell(g *types.Grid) (row, col int, candidates []int, err error) {
+	row, col = -1, -1
+	for r, l := range g.Rows {
+		possible, err := line.Possible(l)
+		if err != nil {
+			return 0, 0, nil, &types.LineError{ID: l.ID, Err: err}
+		}
+		for c, states := range possible {
+			if l.Facts.IsKnown(c) || len(states) < 2 {
+				continue
+			}
+			if candidates == nil || len(states) < len(candidates) {
+				row, col, candidates = r, c, states
+			}
+		}
+	}
+	if candidates == nil {
+		return 0, 0, nil, errExhausted
+	}
+	return row, col, candidates, nil
+}
+
+func stateLabel(color int) string {
+	if color == 0 {
+		return "empty"
+	}
+	return fmt.Sprintf("color %d", color)
+}
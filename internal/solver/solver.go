@@ -0,0 +1,230 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+
+	"nonogram-solver/internal/combinatorics"
+	"nonogram-solver/internal/factory"
+	"nonogram-solver/internal/grid"
+	"nonogram-solver/internal/line"
+	"nonogram-solver/internal/types"
+)
+
+// DefaultMaxCombinations caps per-line enumeration for grids the solver rebuilds
+// while searching; larger lines use the non-enumerating line solver instead.
+const DefaultMaxCombinations = 1 << 20
+
+// Status is the outcome of a solve
+type Status int
+
+const (
+	Solved        Status = iota // every cell is known
+	Stalled                     // propagation stopped with unknown cells left
+	Contradiction               // the clues admit no solution
+	Timeout                     // the context was cancelled or timed out
+)
+
+func (s Status) String() string {
+	switch s {
+	case Solved:
+		return "solved"
+	case Stalled:
+		return "stalled"
+	case Contradiction:
+		return "contradiction"
+	case Timeout:
+		return "timeout"
+	default:
+		return "unknown"
+	}
+}
+
+// Options configures a solve
+type Options struct {
+	// Workers bounds how many lines are processed in parallel; <= 0 means GOMAXPROCS.
+	// Ignored when Semaphore is set.
+	Workers int
+	// Semaphore is shared with the grid's combinations providers so line workers
+	// and nested generation do not oversubscribe CPUs
+	Semaphore *combinatorics.Semaphore
+	// Search enables backtracking when line propagation stalls
+	Search bool
+	// Budget and MaxCombinations configure providers the solver builds for
+	// single lines, as for hints; copies of the grid keep its own providers
+	Budget          *combinatorics.MemoryBudget
+	MaxCombinations int64
+	// Logf, if set, receives progress messages
+	Logf func(format string, args ...any)
+	// Events, if set, receives every deduction, pass and guess as it happens
+	Events func(Event)
+}
+
+// Result describes how a solve ended
+type Result struct {
+	Status Status
+	// Reason explains a Contradiction; line problems are *types.LineError
+	Reason error
+	// Passes counts propagation batches, including those inside the search
+	Passes int
+	// Guesses counts cells assigned by the search
+	Guesses int
+}
+
+// Solve deduces the grid's cells in place. Line propagation runs first; when it
+// stalls and opts.Search is set, a backtracking search finishes the grid. The
+// returned error is only set for invalid input; contradictions and timeouts are
+// reported through Result.Status.
+func Solve(ctx context.Context, g *types.Grid, opts Options) (Result, error) {
+	s := newSolver(opts)
+
+	if reason, err := s.validate(g); err != nil {
+		return Result{}, err
+	} else if reason != nil {
+		return s.result(Contradiction, reason), nil
+	}
+
+	status, reason := s.propagate(ctx, g, allLines(g))
+	if status == Stalled && opts.Search {
+		var solution *types.Grid
+		solution, status, reason = s.search(ctx, g)
+		if solution != nil {
+			copyFacts(g, solution)
+			solution.Release()
+		}
+	}
+	return s.result(status, reason), nil
+}
+
+// CountSolutions counts the grid's solutions up to limit using propagation and
+// backtracking, leaving g untouched. It returns Timeout when ctx ends first.
+func CountSolutions(ctx context.Context, g *types.Grid, limit int, opts Options) (int, Result, error) {
+	s := newSolver(opts)
+
+	if reason, err := s.validate(g); err != nil {
+		return 0, Result{}, err
+	} else if reason != nil {
+		return 0, s.result(Contradiction, reason), nil
+	}
+
+	work := g.Clone()
+	defer work.Release()
+	status, reason := s.propagate(ctx, work, allLines(work))
+	switch status {
+	case Solved:
+		return 1, s.result(Solved, nil), nil
+	case Stalled:
+		count, status, reason := s.count(ctx, work, limit)
+		return count, s.result(status, reason), nil
+	default:
+		return 0, s.result(status, reason), nil
+	}
+}
+
+// solver holds the per-solve configuration and counters
+type solver struct {
+	opts      Options
+	sem       *combinatorics.Semaphore
+	providers factory.ProviderOptions
+	fallback  sync.Map // types.LineID -> true once a line needs the line solver
+	// lineOp deduces one line's cells; processLine unless a rating restricts it
+	lineOp   func(ctx context.Context, l *types.Line) ([]line.Change, string, error)
+	passes   int
+	guesses  int
+	probes   int
+	depth    int // nesting of the current search branch
+	maxDepth int
+}
+
+func newSolver(opts Options) *solver {
+	sem := opts.Semaphore
+	if sem == nil {
+		sem = combinatorics.NewSemaphore(opts.Workers)
+	}
+	limit := opts.MaxCombinations
+	if limit == 0 {
+		limit = DefaultMaxCombinations
+	}
+	s := &solver{
+		opts: opts,
+		sem:  sem,
+		providers: factory.ProviderOptions{
+			Budget:          opts.Budget,
+			Semaphore:       sem,
+			MaxCombinations: limit,
+		},
+	}
+	s.lineOp = s.processLine
+	return s
+}
+
+// validate rejects malformed grids up front. Invalid clue data is returned as
+// err; infeasible clues are returned as reason for a Contradiction.
+func (s *solver) validate(g *types.Grid) (reason error, err error) {
+	ops := grid.NewGridOperations(g)
+	if err := ops.ValidateGrid(); err != nil {
+		return nil, err
+	}
+	if err := ops.ValidateClues(g.Colors); err != nil {
+		if errors.Is(err, combinatorics.ErrInvalidClue) {
+			return nil, err
+		}
+		return err, nil
+	}
+	return nil, nil
+}
+
+// result ends the solve and reports it to the event handler
+func (s *solver) result(status Status, reason error) Result {
+	s.depth = 0
+	s.emit(Event{Kind: EventDone, Status: status, Reason: reason})
+	return Result{Status: status, Reason: reason, Passes: s.passes, Guesses: s.guesses}
+}
+
+func (s *solver) logf(format string, args ...any) {
+	if s.opts.Logf != nil {
+		s.opts.Logf(format, args...)
+	}
+}
+
+// classify maps an error from line processing to a solve status
+func classify(ctx context.Context, err error) (Status, error) {
+	switch {
+	case ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		return Timeout, nil
+	case errors.Is(err, combinatorics.ErrInfeasibleLine):
+		return Contradiction, err
+	default:
+		return Contradiction, fmt.Errorf("unexpected line failure: %w", err)
+	}
+}
+
+func allLines(g *types.Grid) []types.LineID {
+	ids := make([]types.LineID, 0, len(g.Rows)+len(g.Cols))
+	for _, row := range g.Rows {
+		ids = append(ids, row.ID)
+	}
+	for _, col := range g.Cols {
+		ids = append(ids, col.ID)
+	}
+	return ids
+}
+
+// slack is the number of free cells a line has beyond its minimal layout;
+// lower slack means more overlap and cheaper enumeration
+func slack(l *types.Line) int {
+	used := 0
+	for i, clue := range l.Clues {
+		used += clue.Clue
+		if i > 0 && l.Clues[i-1].ColorID == clue.ColorID {
+			used++
+		}
+	}
+	if used == 0 {
+		return math.MinInt
+	}
+	return l.Length - used
+}
@@ -0,0 +1,50 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"nonogram-solver/internal/puzzle"
+)
+
+// File reads puzzle files; the ID is a path, or "-" for Stdin
+type File struct {
+	// Format overrides detection from the file extension; stdin defaults to JSON
+	Format puzzle.Format
+	Stdin  io.Reader
+}
+
+// Fetch reads the puzzle file at path
+func (f File) Fetch(ctx context.Context, path string) (*puzzle.Puzzle, error) {
+	if path == "-" {
+		if f.Stdin == nil {
+			return nil, fmt.Errorf("no stdin to read a puzzle from")
+		}
+		format := f.Format
+		if format == "" {
+			format = puzzle.FormatJSON
+		}
+		p, err := puzzle.Read(f.Stdin, format)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read stdin: %w", err)
+		}
+		return p, nil
+	}
+
+	format := f.Format
+	if format == "" {
+		format = puzzle.DetectFormat(path)
+	}
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+	p, err := puzzle.Read(fh, format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return p, nil
+}
@@ -0,0 +1,100 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	network "nonogram-solver/internal/network"
+	"nonogram-solver/internal/puzzle"
+)
+
+// Source loads puzzles by an ID whose meaning depends on the source: a
+// numeric site ID, a file path, ...
+type Source interface {
+	Fetch(ctx context.Context, id string) (*puzzle.Puzzle, error)
+}
+
+// Func adapts a function to a Source
+type Func func(ctx context.Context, id string) (*puzzle.Puzzle, error)
+
+// Fetch calls f
+func (f Func) Fetch(ctx context.Context, id string) (*puzzle.Puzzle, error) {
+	return f(ctx, id)
+}
+
+// Registry maps URI schemes to sources, so "webpbn:1234" fetches ID 1234 from
+// the source registered as "webpbn". It is safe for concurrent use.
+type Registry struct {
+	mu      sync.RWMutex
+	sources map[string]Source
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{sources: make(map[string]Source)}
+}
+
+// Register adds or replaces the source for a scheme
+func (r *Registry) Register(scheme string, s Source) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sources[strings.ToLower(scheme)] = s
+}
+
+// Lookup returns the source registered for a scheme
+func (r *Registry) Lookup(scheme string) (Source, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, ok := r.sources[strings.ToLower(scheme)]
+	return s, ok
+}
+
+// Schemes returns the registered schemes in order
+func (r *Registry) Schemes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	schemes := make([]string, 0, len(r.sources))
+	for scheme := range r.sources {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// Split separates a "scheme:id" URI. ok is false when the text before the
+// first colon is not a registered scheme, so Windows paths and plain IDs are
+// left alone.
+func (r *Registry) Split(uri string) (scheme, id string, ok bool) {
+	scheme, id, found := strings.Cut(uri, ":")
+	if !found {
+		return "", "", false
+	}
+	if _, registered := r.Lookup(scheme); !registered {
+		return "", "", false
+	}
+	return strings.ToLower(scheme), id, true
+}
+
+// Fetch loads the puzzle a "scheme:id" URI names
+func (r *Registry) Fetch(ctx context.Context, uri string) (*puzzle.Puzzle, error) {
+	scheme, id, ok := r.Split(uri)
+	if !ok {
+		return nil, fmt.Errorf("%q is not a puzzle URI (known schemes: %s)", uri, strings.Join(r.Schemes(), ", "))
+	}
+	s, _ := r.Lookup(scheme)
+	return s.Fetch(ctx, id)
+}
+
+// Default returns a registry with the built-in sources: "nonograms.org",
+// "webpbn" and "file" (reading "-" from os.Stdin)
+func Default() *Registry {
+	r := NewRegistry()
+	r.Register("nonograms.org", network.Source{})
+	r.Register("webpbn", WebPBN{})
+	r.Register("file", File{Stdin: os.Stdin})
+	return r
+}
@@ -0,0 +1,239 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	network "nonogram-solver/internal/network"
+	"nonogram-solver/internal/puzzle"
+	"nonogram-solver/internal/types"
+)
+
+// DefaultWebPBNURL is webpbn.com's export endpoint
+const DefaultWebPBNURL = "https://webpbn.com/export.cgi"
+
+var numericID = regexp.MustCompile(`^[0-9]+$`)
+
+// WebPBN fetches puzzles from webpbn.com by numeric ID
+type WebPBN struct {
+	// BaseURL overrides DefaultWebPBNURL, e.g. for tests
+	BaseURL string
+	// Options sets the client, per-attempt timeout, retries and User-Agent,
+	// as for nonograms.org downloads
+	Options network.FetchOptions
+}
+
+// Fetch exports the puzzle with the given ID as PBN XML and decodes it
+func (w WebPBN) Fetch(ctx context.Context, id string) (*puzzle.Puzzle, error) {
+	if !numericID.MatchString(id) {
+		return nil, fmt.Errorf("webpbn IDs are numeric, got %q", id)
+	}
+	base := w.BaseURL
+	if base == "" {
+		base = DefaultWebPBNURL
+	}
+
+	form := url.Values{"id": {id}, "fmt": {"xml"}, "go": {"1"}}
+	body, err := w.Options.PostForm(ctx, base, form)
+	if err != nil {
+		return nil, fmt.Errorf("fetching webpbn puzzle %s: %w", id, err)
+	}
+	p, err := DecodePBN(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("decoding webpbn puzzle %s: %w", id, err)
+	}
+	p.ID = "webpbn-" + id
+	return p, nil
+}
+
+type pbnSet struct {
+	Puzzles []pbnPuzzle `xml:"puzzle"`
+}
+
+type pbnPuzzle struct {
+	DefaultColor    string        `xml:"defaultcolor,attr"`
+	BackgroundColor string        `xml:"backgroundcolor,attr"`
+	ID              string        `xml:"id"`
+	Title           string        `xml:"title"`
+	Author          string        `xml:"author"`
+	Colors          []pbnColor    `xml:"color"`
+	Clues           []pbnClues    `xml:"clues"`
+	Solutions       []pbnSolution `xml:"solution"`
+}
+
+type pbnColor struct {
+	Name string `xml:"name,attr"`
+	Char string `xml:"char,attr"`
+	Hex  string `xml:",chardata"`
+}
+
+type pbnClues struct {
+	Type  string    `xml:"type,attr"`
+	Lines []pbnLine `xml:"line"`
+}
+
+type pbnLine struct {
+	Counts []pbnCount `xml:"count"`
+}
+
+type pbnCount struct {
+	Color string `xml:"color,attr"`
+	N     string `xml:",chardata"`
+}
+
+type pbnSolution struct {
+	Type  string `xml:"type,attr"`
+	Image string `xml:"image"`
+}
+
+// DecodePBN reads the first puzzle of a PBN XML document, as exported by
+// webpbn.com. The background color becomes 0 and the other colors are
+// numbered from 1 in document order. A goal solution, when present, becomes
+// the puzzle's solution, and the title and author become its Meta.
+func DecodePBN(r io.Reader) (*puzzle.Puzzle, error) {
+	var set pbnSet
+	if err := xml.NewDecoder(r).Decode(&set); err != nil {
+		return nil, fmt.Errorf("invalid PBN XML: %w", err)
+	}
+	if len(set.Puzzles) == 0 {
+		return nil, fmt.Errorf("PBN document has no puzzle")
+	}
+	doc := set.Puzzles[0]
+
+	background, foreground := doc.BackgroundColor, doc.DefaultColor
+	if background == "" {
+		background = "white"
+	}
+	if foreground == "" {
+		foreground = "black"
+	}
+	// Puzzles without a palette are black and white
+	if len(doc.Colors) == 0 {
+		doc.Colors = []pbnColor{{Name: "white", Char: ".", Hex: "fff"}, {Name: "black", Char: "X", Hex: "000"}}
+	}
+
+	ids := map[string]int{background: 0}
+	chars := map[byte]int{}
+	colors := map[int]string{}
+	for _, c := range doc.Colors {
+		if c.Name != background {
+			if _, seen := ids[c.Name]; seen {
+				return nil, fmt.Errorf("color %q is defined twice", c.Name)
+			}
+			hex, err := expandHex(c.Hex)
+			if err != nil {
+				return nil, fmt.Errorf("color %q: %w", c.Name, err)
+			}
+			ids[c.Name] = len(colors) + 1
+			colors[ids[c.Name]] = hex
+		}
+		if c.Char != "" {
+			chars[c.Char[0]] = ids[c.Name]
+		}
+	}
+
+	var rows, cols [][]types.ClueItem
+	for _, set := range doc.Clues {
+		lines := make([][]types.ClueItem, len(set.Lines))
+		for i, l := range set.Lines {
+			for _, count := range l.Counts {
+				name := count.Color
+				if name == "" {
+					name = foreground
+				}
+				id, ok := ids[name]
+				if !ok || id == 0 {
+					return nil, fmt.Errorf("%s line %d uses unknown color %q", set.Type, i+1, name)
+				}
+				n, err := strconv.Atoi(strings.TrimSpace(count.N))
+				if err != nil || n <= 0 {
+					return nil, fmt.Errorf("%s line %d has invalid count %q", set.Type, i+1, count.N)
+				}
+				lines[i] = append(lines[i], types.ClueItem{ColorID: id, Clue: n})
+			}
+		}
+		switch set.Type {
+		case "rows":
+			rows = lines
+		case "columns":
+			cols = lines
+		default:
+			return nil, fmt.Errorf("unknown clue type %q", set.Type)
+		}
+	}
+	if rows == nil || cols == nil {
+		return nil, fmt.Errorf("PBN puzzle needs both row and column clues")
+	}
+
+	p := &puzzle.Puzzle{
+		ID:     strings.TrimPrefix(strings.TrimSpace(doc.ID), "#"),
+		Width:  len(cols),
+		Height: len(rows),
+		Colors: colors,
+		Rows:   rows,
+		Cols:   cols,
+	}
+	if title, author := strings.TrimSpace(doc.Title), strings.TrimSpace(doc.Author); title != "" || author != "" {
+		p.Meta = &puzzle.Meta{Title: title, Author: author}
+	}
+	for _, s := range doc.Solutions {
+		if s.Type != "" && s.Type != "goal" {
+			continue
+		}
+		solution, err := decodeImage(s.Image, chars)
+		if err != nil {
+			return nil, err
+		}
+		p.Solution = solution
+		break
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// decodeImage parses a solution image: one "|...|" row per line, one color
+// character per cell
+func decodeImage(image string, chars map[byte]int) ([][]int, error) {
+	var cells [][]int
+	for _, text := range strings.Split(image, "\n") {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		text = strings.TrimSuffix(strings.TrimPrefix(text, "|"), "|")
+		row := make([]int, len(text))
+		for i := 0; i < len(text); i++ {
+			color, ok := chars[text[i]]
+			if !ok {
+				return nil, fmt.Errorf("solution row %d has unknown color character %q", len(cells)+1, text[i])
+			}
+			row[i] = color
+		}
+		cells = append(cells, row)
+	}
+	return cells, nil
+}
+
+// expandHex turns "f0a" or "ff00aa" into "#ff00aa"
+func expandHex(hex string) (string, error) {
+	hex = strings.TrimPrefix(strings.TrimSpace(hex), "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return "", fmt.Errorf("invalid hex color %q", hex)
+	}
+	if _, err := strconv.ParseUint(hex, 16, 32); err != nil {
+		return "", fmt.Errorf("invalid hex color %q", hex)
+	}
+	return "#" + strings.ToLower(hex), nil
+}
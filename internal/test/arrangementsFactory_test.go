@@ -0,0 +1,91 @@
+package test
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"nonogram-solver/internal/factory"
+	"nonogram-solver/internal/types"
+)
+
+func TestGenerateLineArrangements(t *testing.T) {
+	clues := []types.ClueItem{
+		{ColorID: 1, Clue: 1},
+		{ColorID: 2, Clue: 2},
+		{ColorID: 2, Clue: 1},
+	}
+
+	result := factory.GenerateLineArrangements(clues, 6)
+	expected := [][]int{
+		{0, 1, 4},
+		{0, 1, 5},
+		{0, 2, 5},
+		{1, 2, 5},
+	}
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("GenerateLineArrangements() = %v, want %v", result, expected)
+	}
+}
+
+func TestArrangementsProviderMatchesSingleColor(t *testing.T) {
+	clues := []types.ClueItem{
+		{ColorID: 1, Clue: 4},
+		{ColorID: 1, Clue: 3},
+	}
+
+	provider := factory.NewArrangementsProvider(clues, 10)
+	combos, err := provider.Get(1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := factory.GenerateColorCombinations(clues, 10, 1)
+	if len(combos) != len(expected) {
+		t.Fatalf("Expected %d combinations, got %d", len(expected), len(combos))
+	}
+	for i := range combos {
+		if combos[i].Cmp(expected[i]) != 0 {
+			t.Errorf("combination %d = %v, want %v", i, combos[i], expected[i])
+		}
+	}
+}
+
+func TestArrangementsProviderCrossReferenceIsJoint(t *testing.T) {
+	// (1,1), (2,1) on 3 cells: knowing the first cell is empty forces color 1
+	// into the middle, which leaves only the last cell for color 2.
+	clues := []types.ClueItem{
+		{ColorID: 1, Clue: 1},
+		{ColorID: 2, Clue: 1},
+	}
+	filled := map[int]*types.Bitset{}
+	empty := types.NewBitset(big.NewInt(4))
+
+	joint := factory.NewArrangementsProvider(clues, 3)
+	changed, err := joint.CrossReference(context.Background(), filled, empty)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !changed {
+		t.Fatal("Expected CrossReference to drop arrangements")
+	}
+
+	combos, _ := joint.Get(2)
+	if len(combos) != 1 || combos[0].Int64() != 1 {
+		t.Errorf("joint color 2 combinations = %v, want [1]", combos)
+	}
+
+	perColor := factory.NewCombinationsProvider(clues, 3)
+	if _, err := perColor.Get(2); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := perColor.CrossReference(context.Background(), filled, empty); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	combos, _ = perColor.Get(2)
+	if len(combos) != 2 {
+		t.Errorf("per-color color 2 combinations = %v, want 2 masks", combos)
+	}
+}
@@ -0,0 +1,97 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"nonogram-solver/internal/cli"
+)
+
+func writeBatchDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.txt":    monochromePuzzle,
+		"b.txt":    colorPuzzle,
+		"c.non":    "size 2x2\nrows\n-\n2\ncolumns\n-\n2\n",
+		"skip.md":  "not a puzzle",
+		"d.broken": "ignored extension",
+	}
+	for name, text := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestBatchCSV(t *testing.T) {
+	dir := writeBatchDir(t)
+
+	var stdout, stderr bytes.Buffer
+	code := cli.Run(context.Background(), []string{"batch", "--jobs", "2", "--dir", dir}, strings.NewReader(""), &stdout, &stderr)
+	if code != cli.ExitUnsolvable {
+		t.Errorf("exit code = %d, want %d\nstderr: %s", code, cli.ExitUnsolvable, stderr.String())
+	}
+
+	rows, err := csv.NewReader(&stdout).ReadAll()
+	if err != nil {
+		t.Fatalf("Expected valid CSV, got %v", err)
+	}
+	if len(rows) != 4 || rows[0][0] != "source" {
+		t.Fatalf("rows = %v, want a header and 3 puzzles", rows)
+	}
+	want := []struct{ file, size, status string }{
+		{"a.txt", "3x3", "solved"},
+		{"b.txt", "3x2", "solved"},
+		{"c.non", "2x2", "contradiction"},
+	}
+	for i, w := range want {
+		row := rows[i+1]
+		if filepath.Base(row[0]) != w.file || row[1]+"x"+row[2] != w.size || row[4] != w.status {
+			t.Errorf("row %d = %v, want %s %s %s", i+1, row, w.file, w.size, w.status)
+		}
+	}
+}
+
+func TestBatchJSON(t *testing.T) {
+	dir := writeBatchDir(t)
+	out := filepath.Join(t.TempDir(), "summary.json")
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"batch", "--format", "json", "--out", out, filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")}
+	if code := cli.Run(context.Background(), args, strings.NewReader(""), &stdout, &stderr); code != cli.ExitOK {
+		t.Fatalf("exit code = %d, want %d\nstderr: %s", code, cli.ExitOK, stderr.String())
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []struct {
+		Source  string `json:"source"`
+		Colors  int    `json:"colors"`
+		Status  string `json:"status"`
+		Guesses int    `json:"guesses"`
+	}
+	if err := json.Unmarshal(data, &records); err != nil {
+		t.Fatalf("Expected valid JSON, got %v", err)
+	}
+	if len(records) != 2 || records[0].Status != "solved" || records[1].Colors != 2 {
+		t.Errorf("records = %+v", records)
+	}
+}
+
+func TestBatchRejectsBadRange(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := cli.Run(context.Background(), []string{"batch", "--range", "1200-1000"}, strings.NewReader(""), &stdout, &stderr)
+	if code != cli.ExitError {
+		t.Errorf("exit code = %d, want %d", code, cli.ExitError)
+	}
+}
@@ -0,0 +1,103 @@
+package test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"nonogram-solver/internal/bench"
+	"nonogram-solver/internal/factory"
+	"nonogram-solver/internal/solver"
+)
+
+var corpusDir = filepath.Join("..", "..", bench.DefaultCorpus)
+
+func loadCorpus(tb testing.TB) []bench.Entry {
+	tb.Helper()
+	entries, err := bench.LoadCorpus(corpusDir)
+	if err != nil {
+		tb.Fatalf("Expected no error, got %v", err)
+	}
+	return entries
+}
+
+func BenchmarkCorpus(b *testing.B) {
+	for _, entry := range loadCorpus(b) {
+		b.Run(entry.Name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				result, err := bench.Run(context.Background(), entry.Puzzle, solver.Options{Search: true}, factory.ProviderOptions{})
+				if err != nil || result.Status != solver.Solved {
+					b.Fatalf("Solve() = %v, %v", result.Status, err)
+				}
+			}
+		})
+	}
+}
+
+func TestCorpusSolves(t *testing.T) {
+	if testing.Short() {
+		t.Skip("corpus solves take about a second")
+	}
+	for _, entry := range loadCorpus(t) {
+		sample, err := bench.Measure(context.Background(), entry.Name, entry.Puzzle, 1, solver.Options{Search: true}, factory.ProviderOptions{})
+		if err != nil {
+			t.Fatalf("%s: %v", entry.Name, err)
+		}
+		if sample.Status != "solved" || sample.AllocsPerOp == 0 || sample.NsPerOp == 0 {
+			t.Errorf("%s: sample = %+v, want a solved measurement", entry.Name, sample)
+		}
+	}
+}
+
+func TestRunKeepsSharedCache(t *testing.T) {
+	shared := factory.SharedCache()
+	entry := loadCorpus(t)[0]
+	if _, err := bench.Run(context.Background(), entry.Puzzle, solver.Options{Search: true}, factory.ProviderOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if factory.SharedCache() != shared {
+		t.Error("bench.Run replaced the shared combinations cache")
+	}
+}
+
+func TestCompareBaselines(t *testing.T) {
+	base := bench.NewBaseline([]bench.Sample{
+		{Name: "steady", NsPerOp: 1000, AllocsPerOp: 100, Status: "solved"},
+		{Name: "slower", NsPerOp: 1000, AllocsPerOp: 100, Status: "solved"},
+		{Name: "hungrier", NsPerOp: 1000, AllocsPerOp: 100, Status: "solved"},
+		{Name: "broken", NsPerOp: 1000, AllocsPerOp: 100, Status: "solved"},
+		{Name: "dropped", NsPerOp: 1000, AllocsPerOp: 100, Status: "solved"},
+	})
+	current := bench.NewBaseline([]bench.Sample{
+		{Name: "steady", NsPerOp: 1050, AllocsPerOp: 90, Status: "solved"},
+		{Name: "slower", NsPerOp: 1200, AllocsPerOp: 100, Status: "solved"},
+		{Name: "hungrier", NsPerOp: 900, AllocsPerOp: 150, Status: "solved"},
+		{Name: "broken", NsPerOp: 500, AllocsPerOp: 50, Status: "stalled"},
+		{Name: "added", NsPerOp: 1, AllocsPerOp: 1, Status: "solved"},
+	})
+
+	deltas := bench.Compare(base, current, 10)
+	want := []struct {
+		name      string
+		regressed bool
+		timePct   float64
+	}{
+		{"steady", false, 5},
+		{"slower", true, 20},
+		{"hungrier", true, -10},
+		{"broken", true, -50},
+		{"added", false, 0},
+		{"dropped", false, 0},
+	}
+	if len(deltas) != len(want) {
+		t.Fatalf("Compare() returned %d deltas, want %d", len(deltas), len(want))
+	}
+	for i, w := range want {
+		d := deltas[i]
+		if d.Name != w.name || d.Regressed != w.regressed || d.TimePct != w.timePct {
+			t.Errorf("delta %d = {%s regressed=%v time=%+.1f%% note=%q}, want {%s regressed=%v time=%+.1f%%}",
+				i, d.Name, d.Regressed, d.TimePct, d.Note, w.name, w.regressed, w.timePct)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"nonogram-solver/internal/cache"
+	network "nonogram-solver/internal/network"
+	"nonogram-solver/internal/puzzle"
+)
+
+func TestCacheExpiresAndPrunes(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := cache.New(t.TempDir(), time.Hour)
+	c.Now = func() time.Time { return now }
+
+	if err := c.Put("site/old.html", []byte("old")); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(50 * time.Minute)
+	if err := c.Put("site/new.html", []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	if data, ok := c.Get("site/old.html"); !ok || string(data) != "old" {
+		t.Errorf("Get before expiry = %q, %v", data, ok)
+	}
+
+	now = now.Add(20 * time.Minute)
+	if _, ok := c.Get("site/old.html"); ok {
+		t.Error("Get returned an entry past its TTL")
+	}
+	if _, ok := c.Get("site/new.html"); !ok {
+		t.Error("Get missed a fresh entry")
+	}
+	if _, ok := c.Get("site/missing.html"); ok {
+		t.Error("Get returned a missing entry")
+	}
+	if err := c.Put("../escape", nil); err == nil {
+		t.Error("Put accepted a key outside the cache directory")
+	}
+
+	if removed, err := c.Prune(false); err != nil || removed != 1 {
+		t.Errorf("Prune(false) = %d, %v, want 1 expired entry removed", removed, err)
+	}
+	if removed, err := c.Prune(true); err != nil || removed != 1 {
+		t.Errorf("Prune(true) = %d, %v, want the remaining entry removed", removed, err)
+	}
+	if removed, err := cache.New(t.TempDir()+"/missing", 0).Prune(true); err != nil || removed != 0 {
+		t.Errorf("Prune of a missing directory = %d, %v", removed, err)
+	}
+}
+
+func TestSourceUsesCache(t *testing.T) {
+	c := cache.New(t.TempDir(), 0)
+	cached := readPuzzle(t, monochromePuzzle)
+	cached.ID = "42"
+	var buf bytes.Buffer
+	if err := puzzle.Write(&buf, cached, puzzle.FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Put("nonograms.org/42.json", buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+
+	stub := &stubTransport{respond: func(*http.Request, int) (int, error) {
+		return http.StatusServiceUnavailable, nil
+	}}
+	src := network.Source{Options: network.FetchOptions{Transport: stub}, Cache: c}
+	p, err := src.Fetch(context.Background(), "42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.ID != "42" || p.Width != 3 || stub.count() != 0 {
+		t.Errorf("fetched %+v with %d requests, want the cached puzzle and no requests", p, stub.count())
+	}
+
+	src.Refresh = true
+	if _, err := src.Fetch(context.Background(), "42"); err == nil || stub.count() != 1 {
+		t.Errorf("refresh = %v after %d attempts, want the site's error after one attempt", err, stub.count())
+	}
+}
@@ -0,0 +1,65 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"nonogram-solver/internal/cli"
+)
+
+func TestCLIExitCodes(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, text string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	solvable := write("solvable.txt", monochromePuzzle)
+	ambiguous := write("ambiguous.txt", "size 2x2\nrows\n1\n1\ncolumns\n1\n1\n")
+	broken := write("broken.txt", "size 2x2\nrows\n-\n2\ncolumns\n-\n2\n")
+
+	tests := []struct {
+		name   string
+		args   []string
+		stdin  string
+		want   int
+		stdout string
+	}{
+		{name: "solve", args: []string{"solve", solvable}, want: cli.ExitOK, stdout: "###\n.#.\n#.#\n"},
+		{name: "flags after file", args: []string{"solve", solvable, "--format", "json"}, want: cli.ExitOK, stdout: `"status": "solved"`},
+		{name: "stdin", args: []string{"solve", "--in-format", "text", "-"}, stdin: monochromePuzzle, want: cli.ExitOK, stdout: "#.#"},
+		{name: "contradiction", args: []string{"solve", broken}, want: cli.ExitUnsolvable},
+		{name: "stalled without search", args: []string{"solve", "--no-search", ambiguous}, want: cli.ExitUnsolvable},
+		{name: "check unique", args: []string{"check", solvable}, want: cli.ExitOK, stdout: "unique"},
+		{name: "check multiple", args: []string{"check", ambiguous}, want: cli.ExitUnsolvable, stdout: "multiple"},
+		{name: "convert", args: []string{"convert", "--format", "json", solvable}, want: cli.ExitOK, stdout: `"width": 3`},
+		{name: "generate", args: []string{"generate", "--width", "6", "--height", "5", "--seed", "9", "--format", "json"}, want: cli.ExitOK, stdout: `"id": "generated-6x5-9"`},
+		{name: "file URI", args: []string{"solve", "file:" + solvable}, want: cli.ExitOK, stdout: "###\n.#.\n#.#\n"},
+		{name: "stdin URI", args: []string{"convert", "--in-format", "text", "--format", "json", "file:-"}, stdin: monochromePuzzle, want: cli.ExitOK, stdout: `"width": 3`},
+		{name: "fetch file URI", args: []string{"fetch", solvable}, want: cli.ExitError},
+		{name: "cache prune", args: []string{"cache", "prune", "--all", "--cache-dir", filepath.Join(dir, "cache")}, want: cli.ExitOK, stdout: "removed 0 cached file(s)"},
+		{name: "cache without subcommand", args: []string{"cache"}, want: cli.ExitError},
+		{name: "mirror without range", args: []string{"mirror", "--out", dir}, want: cli.ExitError},
+		{name: "missing file", args: []string{"solve", filepath.Join(dir, "missing.txt")}, want: cli.ExitError},
+		{name: "unknown command", args: []string{"frobnicate"}, want: cli.ExitError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := cli.Run(context.Background(), tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+			if code != tt.want {
+				t.Errorf("exit code = %d, want %d\nstderr: %s", code, tt.want, stderr.String())
+			}
+			if !strings.Contains(stdout.String(), tt.stdout) {
+				t.Errorf("stdout = %q, want it to contain %q", stdout.String(), tt.stdout)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"nonogram-solver/internal/combinatorics"
+	"nonogram-solver/internal/factory"
+	"nonogram-solver/internal/grid"
+	"nonogram-solver/internal/types"
+)
+
+func TestCombinationsProviderErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		clues   []types.ClueItem
+		size    int
+		color   int
+		opts    factory.ProviderOptions
+		wantErr error
+	}{
+		{
+			name:    "zero-length clue",
+			clues:   []types.ClueItem{{ColorID: 1, Clue: 0}},
+			size:    5,
+			color:   1,
+			wantErr: combinatorics.ErrInvalidClue,
+		},
+		{
+			name:    "negative size",
+			clues:   []types.ClueItem{{ColorID: 1, Clue: 1}},
+			size:    -1,
+			color:   1,
+			wantErr: combinatorics.ErrInvalidClue,
+		},
+		{
+			name:    "unknown color requested",
+			clues:   []types.ClueItem{{ColorID: 1, Clue: 1}},
+			size:    5,
+			color:   0,
+			wantErr: combinatorics.ErrInvalidClue,
+		},
+		{
+			name:    "color outside the palette",
+			clues:   []types.ClueItem{{ColorID: 1, Clue: 1}, {ColorID: 3, Clue: 1}},
+			size:    5,
+			color:   4,
+			opts:    factory.ProviderOptions{Palette: map[int]string{1: "#000000", 2: "#00ff00", 3: "#ff0000"}},
+			wantErr: combinatorics.ErrInvalidClue,
+		},
+		{
+			name:    "clues longer than line",
+			clues:   []types.ClueItem{{ColorID: 1, Clue: 3}, {ColorID: 1, Clue: 2}},
+			size:    5,
+			color:   1,
+			wantErr: combinatorics.ErrInfeasibleLine,
+		},
+		{
+			name:    "enumeration limit",
+			clues:   []types.ClueItem{{ColorID: 1, Clue: 1}, {ColorID: 2, Clue: 1}},
+			size:    10,
+			color:   1,
+			opts:    factory.ProviderOptions{MaxCombinations: 5},
+			wantErr: combinatorics.ErrEnumerationLimit,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providers := []combinatorics.CombinationsProvider{
+				factory.NewCombinationsProviderWithOptions(tt.clues, tt.size, tt.opts),
+				factory.NewArrangementsProviderWithOptions(tt.clues, tt.size, tt.opts),
+			}
+			for _, provider := range providers {
+				if _, err := provider.Get(tt.color); !errors.Is(err, tt.wantErr) {
+					t.Errorf("%T.Get() error = %v, want %v", provider, err, tt.wantErr)
+				}
+			}
+		})
+	}
+}
+
+func TestCombinationsProviderFactsContradiction(t *testing.T) {
+	clues := []types.ClueItem{{ColorID: 1, Clue: 3}}
+	provider := factory.NewCombinationsProvider(clues, 4)
+	if _, err := provider.Get(1); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The middle cells are empty, so a run of 3 no longer fits
+	empty := types.NewBitset(big.NewInt(6))
+	changed, err := provider.CrossReference(context.Background(), map[int]*types.Bitset{}, empty)
+	if !changed || !errors.Is(err, combinatorics.ErrInfeasibleLine) {
+		t.Errorf("CrossReference() = %v, %v; want true, ErrInfeasibleLine", changed, err)
+	}
+}
+
+func TestValidateCluesReportsLines(t *testing.T) {
+	clues := map[types.LineID][]types.ClueItem{
+		{Direction: types.Row, Index: 0}:    {{ColorID: 1, Clue: 3}},
+		{Direction: types.Row, Index: 1}:    {{ColorID: 2, Clue: 1}},
+		{Direction: types.Column, Index: 0}: {{ColorID: 1, Clue: 1}},
+		{Direction: types.Column, Index: 1}: {{ColorID: 1, Clue: 1}},
+	}
+	g := factory.CreateGridFromClues(clues, 2, 2, map[int]string{1: "#000000"})
+
+	err := grid.NewGridOperations(&g).ValidateClues(map[int]string{1: "#000000"})
+
+	var lineErr *types.LineError
+	if !errors.As(err, &lineErr) {
+		t.Fatalf("Expected a LineError, got %v", err)
+	}
+	if lineErr.ID != (types.LineID{Direction: types.Row, Index: 0}) {
+		t.Errorf("Expected row 0 to be reported first, got %v", lineErr.ID)
+	}
+	if !errors.Is(err, combinatorics.ErrInfeasibleLine) || !errors.Is(err, combinatorics.ErrInvalidClue) {
+		t.Errorf("Expected both infeasible and invalid clue errors, got %v", err)
+	}
+}
+
+func TestCombinationsProviderUnusedColor(t *testing.T) {
+	// Multi-color cross-referencing asks every line for every palette color
+	clues := []types.ClueItem{{ColorID: 1, Clue: 1}, {ColorID: 3, Clue: 1}}
+	opts := factory.ProviderOptions{Palette: map[int]string{1: "#000000", 2: "#00ff00", 3: "#ff0000"}}
+	providers := []combinatorics.CombinationsProvider{
+		factory.NewCombinationsProviderWithOptions(clues, 5, opts),
+		factory.NewArrangementsProviderWithOptions(clues, 5, opts),
+	}
+	for _, provider := range providers {
+		combos, err := provider.Get(2)
+		if err != nil {
+			t.Fatalf("%T.Get(unused color) = %v", provider, err)
+		}
+		if len(combos) != 1 || combos[0].Sign() != 0 {
+			t.Errorf("%T.Get(unused color) = %v, want one all-empty mask", provider, combos)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package test
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"nonogram-solver/internal/factory"
+	"nonogram-solver/internal/types"
+)
+
+func TestCombinationsCacheHitsAndMisses(t *testing.T) {
+	cache := factory.NewCombinationsCache("", nil)
+	clues := []types.ClueItem{
+		{ColorID: 1, Clue: 1},
+		{ColorID: 3, Clue: 4},
+		{ColorID: 1, Clue: 2},
+	}
+
+	ctx := context.Background()
+	first, _ := cache.ColorCombinations(ctx, clues, 8, 1, nil)
+	second, _ := cache.ColorCombinations(ctx, clues, 8, 1, nil)
+	cache.ColorCombinations(ctx, clues, 8, 3, nil)
+
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("cached combinations differ: %v vs %v", first, second)
+	}
+	stats := cache.Stats()
+	if stats.Hits != 1 || stats.Misses != 2 {
+		t.Errorf("Expected 1 hit and 2 misses, got %+v", stats)
+	}
+}
+
+func TestCombinationsCacheDiskRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	clues := []types.ClueItem{
+		{ColorID: 3, Clue: 1},
+		{ColorID: 4, Clue: 1},
+		{ColorID: 3, Clue: 2},
+		{ColorID: 1, Clue: 1},
+		{ColorID: 2, Clue: 1},
+	}
+
+	ctx := context.Background()
+	writer := factory.NewCombinationsCache(dir, nil)
+	combos, _ := writer.ColorCombinations(ctx, clues, 8, 3, nil)
+	arrangements, _ := writer.LineArrangements(ctx, clues, 8)
+	if stats := writer.Stats(); stats.DiskWrites != 2 || stats.DiskErrors != 0 {
+		t.Fatalf("Expected 2 disk writes, got %+v", stats)
+	}
+
+	reader := factory.NewCombinationsCache(dir, nil)
+	if got, _ := reader.ColorCombinations(ctx, clues, 8, 3, nil); !reflect.DeepEqual(got, combos) {
+		t.Errorf("disk combinations = %v, want %v", got, combos)
+	}
+	if got, _ := reader.LineArrangements(ctx, clues, 8); !reflect.DeepEqual(got, arrangements) {
+		t.Errorf("disk arrangements = %v, want %v", got, arrangements)
+	}
+	if stats := reader.Stats(); stats.DiskHits != 2 || stats.Misses != 0 {
+		t.Errorf("Expected 2 disk hits and no misses, got %+v", stats)
+	}
+}
+
+func TestCombinationsCacheRejectsHugeCounts(t *testing.T) {
+	dir := t.TempDir()
+	clues := []types.ClueItem{{ColorID: 1, Clue: 2}, {ColorID: 2, Clue: 1}}
+
+	ctx := context.Background()
+	writer := factory.NewCombinationsCache(dir, nil)
+	combos, _ := writer.ColorCombinations(ctx, clues, 8, 1, nil)
+	arrangements, _ := writer.LineArrangements(ctx, clues, 8)
+
+	// Keep the magic, kind and first field, then claim 2^62 items
+	const header = len("NGC1") + 1
+	files, _ := filepath.Glob(filepath.Join(dir, "*.bin"))
+	if len(files) != 2 {
+		t.Fatalf("found %d cache files, want 2", len(files))
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, n := binary.Uvarint(data[header:])
+		corrupt := binary.AppendUvarint(data[:header+n:header+n], 1<<62)
+		if err := os.WriteFile(file, corrupt, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reader := factory.NewCombinationsCache(dir, nil)
+	if got, _ := reader.ColorCombinations(ctx, clues, 8, 1, nil); !reflect.DeepEqual(got, combos) {
+		t.Errorf("combinations after a corrupt entry = %v, want %v", got, combos)
+	}
+	if got, _ := reader.LineArrangements(ctx, clues, 8); !reflect.DeepEqual(got, arrangements) {
+		t.Errorf("arrangements after a corrupt entry = %v, want %v", got, arrangements)
+	}
+	if stats := reader.Stats(); stats.DiskErrors != 2 || stats.DiskHits != 0 {
+		t.Errorf("Expected 2 disk errors and no disk hits, got %+v", stats)
+	}
+}
@@ -1,10 +1,14 @@
 package test
 
 import (
+	"context"
+	"errors"
 	"math/big"
 	"reflect"
 	"testing"
+	"time"
 
+	"nonogram-solver/internal/combinatorics"
 	"nonogram-solver/internal/factory"
 	"nonogram-solver/internal/types"
 )
@@ -196,6 +200,14 @@ func TestGenerateColorCombinations(t *testing.T) {
 				big.NewInt(16),
 			},
 		},
+		{
+			// An empty line has exactly one placement: every cell empty
+			name:     "empty line, Size: 5, ColorID: 1",
+			clues:    nil,
+			size:     5,
+			colorID:  1,
+			expected: []*big.Int{big.NewInt(0)},
+		},
 	}
 
 	for _, tt := range tests {
@@ -208,3 +220,48 @@ func TestGenerateColorCombinations(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateColorCombinationsContextCancelled(t *testing.T) {
+	// 20 single-cell blocks on 60 cells: far too many masks to finish quickly
+	clues := make([]types.ClueItem, 20)
+	for i := range clues {
+		clues[i] = types.ClueItem{ColorID: 1, Clue: 1}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	result, err := factory.GenerateColorCombinationsContext(ctx, clues, 60, 1, combinatorics.NewSemaphore(2))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context.DeadlineExceeded, got %v (%d masks)", err, len(result))
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected prompt cancellation, took %v", elapsed)
+	}
+}
+
+func TestGenerateColorCombinationsContextSaturatedSemaphore(t *testing.T) {
+	clues := []types.ClueItem{
+		{ColorID: 1, Clue: 2},
+		{ColorID: 1, Clue: 1},
+		{ColorID: 1, Clue: 1},
+		{ColorID: 1, Clue: 1},
+	}
+
+	// With every slot taken, generation must run inline instead of blocking
+	sem := combinatorics.NewSemaphore(1)
+	if !sem.TryAcquire() {
+		t.Fatal("Expected to acquire the only slot")
+	}
+	defer sem.Release()
+
+	result, err := factory.GenerateColorCombinationsContext(context.Background(), clues, 10, 1, sem)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := factory.GenerateColorCombinations(clues, 10, 1)
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("GenerateColorCombinationsContext() = %v, want %v", result, expected)
+	}
+}
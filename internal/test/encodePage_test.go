@@ -0,0 +1,123 @@
+package test
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	network "nonogram-solver/internal/network"
+	"nonogram-solver/internal/puzzle"
+)
+
+// encodePalette uses components at both ends of 0-255
+var encodePalette = []string{"#00FF7F", "#E03C31", "#3FA34D", "#1F4E9C", "#FFFFFF"}
+
+// randomPuzzle fills a width x height grid with colors 0..colors
+func randomPuzzle(seed int64, width, height, colors int) *puzzle.Puzzle {
+	rng := rand.New(rand.NewSource(seed))
+	cells := make([][]int, height)
+	for r := range cells {
+		cells[r] = make([]int, width)
+		for c := range cells[r] {
+			cells[r][c] = rng.Intn(colors + 1)
+		}
+	}
+	palette := map[int]string{1: "#000000"}
+	if colors > 1 {
+		for i := 1; i <= colors; i++ {
+			palette[i] = encodePalette[i-1]
+		}
+	}
+	return puzzle.FromCells("random", cells, palette)
+}
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	var puzzles []*puzzle.Puzzle
+	for name := range fixturePictures {
+		p, err := network.DecodePage(name, readFixture(t, name+".html"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		puzzles = append(puzzles, p)
+	}
+	puzzles = append(puzzles, randomPuzzle(1, 1, 1, 1), randomPuzzle(2, 15, 9, 1), randomPuzzle(3, 20, 20, 5))
+
+	for _, want := range puzzles {
+		for seed := int64(0); seed < 5; seed++ {
+			page, err := network.EncodePage(want, seed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Strict decoding proves no run was clamped or overlapped
+			got, err := network.DecodePageStrict(want.ID, page)
+			if err != nil {
+				t.Fatalf("%s seed %d: %v", want.ID, seed, err)
+			}
+			if !reflect.DeepEqual(got.Solution, want.Solution) {
+				t.Errorf("%s seed %d: decoded cells:\n%s\nwant:\n%s", want.ID, seed, strings.Join(picture(got.Solution), "\n"), strings.Join(picture(want.Solution), "\n"))
+			}
+			if len(want.Colors) > 1 && !reflect.DeepEqual(got.Colors, want.Colors) {
+				t.Errorf("%s seed %d: colors = %v, want %v", want.ID, seed, got.Colors, want.Colors)
+			}
+			if !reflect.DeepEqual(got.Rows, want.Rows) || !reflect.DeepEqual(got.Cols, want.Cols) {
+				t.Errorf("%s seed %d: clues differ", want.ID, seed)
+			}
+			if !reflect.DeepEqual(got.Meta, want.Meta) {
+				t.Errorf("%s seed %d: Meta = %+v, want %+v", want.ID, seed, got.Meta, want.Meta)
+			}
+		}
+	}
+}
+
+func TestEncodeDataObfuscates(t *testing.T) {
+	p := randomPuzzle(4, 10, 10, 3)
+	a, _ := network.EncodeData(p, 1)
+	b, _ := network.EncodeData(p, 1)
+	c, _ := network.EncodeData(p, 2)
+	if !reflect.DeepEqual(a, b) {
+		t.Error("the same seed gave different arrays")
+	}
+	if reflect.DeepEqual(a, c) {
+		t.Error("different seeds gave the same array")
+	}
+
+	p.Solution = nil
+	if _, err := network.EncodeData(p, 1); err == nil || !strings.Contains(err.Error(), "no solution") {
+		t.Errorf("EncodeData without a solution = %v", err)
+	}
+	p = randomPuzzle(4, 3, 3, 2)
+	p.Colors[2] = "teal"
+	if _, err := network.EncodeData(p, 1); err == nil || !strings.Contains(err.Error(), "invalid hex color") {
+		t.Errorf("EncodeData with a named color = %v", err)
+	}
+}
+
+// Encoded pages stand in for the site in a local mock
+func TestFetchEncodedPage(t *testing.T) {
+	want := randomPuzzle(5, 12, 8, 2)
+	want.Meta = &puzzle.Meta{Title: "Mock", Size: "12x8"}
+	page, err := network.EncodePage(want, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/nonograms2/i/99" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(page)
+	}))
+	defer ts.Close()
+
+	got, err := network.FetchPuzzleContext(context.Background(), "99", network.FetchOptions{BaseURL: ts.URL, Client: ts.Client()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Solution, want.Solution) || got.Meta.Title != "Mock" || got.Meta.Pattern != network.PatternColor {
+		t.Errorf("fetched %+v with Meta %+v, want the encoded puzzle", got, got.Meta)
+	}
+}
@@ -0,0 +1,138 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"nonogram-solver/internal/explain"
+	"nonogram-solver/internal/factory"
+	"nonogram-solver/internal/line"
+	"nonogram-solver/internal/puzzle"
+	"nonogram-solver/internal/solver"
+	"nonogram-solver/internal/types"
+)
+
+func TestLineCount(t *testing.T) {
+	tests := []struct {
+		name   string
+		clues  []types.ClueItem
+		length int
+		filled []int
+		want   int64
+	}{
+		{name: "single run", clues: []types.ClueItem{{ColorID: 1, Clue: 1}}, length: 3, want: 3},
+		{name: "two runs", clues: []types.ClueItem{{ColorID: 1, Clue: 1}, {ColorID: 1, Clue: 1}}, length: 5, want: 6},
+		{name: "adjacent colors", clues: []types.ClueItem{{ColorID: 1, Clue: 1}, {ColorID: 2, Clue: 1}}, length: 3, want: 3},
+		{name: "known cell", clues: []types.ClueItem{{ColorID: 1, Clue: 2}}, length: 5, filled: []int{0}, want: 1},
+		{name: "infeasible", clues: []types.ClueItem{{ColorID: 1, Clue: 4}}, length: 3, want: 0},
+		{name: "blank", length: 4, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &types.Line{Length: tt.length, Clues: tt.clues, Facts: types.NewFacts()}
+			for _, pos := range tt.filled {
+				line.Mark(l.Facts, pos, 1)
+			}
+			if got := line.Count(l); got.Int64() != tt.want {
+				t.Errorf("Count() = %v, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExplainRecordsEveryCell(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join(corpusDir, "heart-10x10.txt"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	p := readPuzzle(t, string(data))
+	recorder := explain.NewRecorder(p)
+	g := p.Grid(factory.ProviderOptions{})
+	result, err := solver.Solve(context.Background(), &g, solver.Options{Events: recorder.Event})
+	if err != nil || result.Status != solver.Solved {
+		t.Fatalf("Solve() = %v, %v; want solved", result.Status, err)
+	}
+
+	entries := recorder.Entries()
+	if len(entries) != p.Width*p.Height {
+		t.Fatalf("Expected one entry per cell, got %d", len(entries))
+	}
+	cells := make([][]int, p.Height)
+	for r := range cells {
+		cells[r] = make([]int, p.Width)
+	}
+	counts := make(map[string]int)
+	for i, entry := range entries {
+		cells[entry.Row][entry.Col] = entry.Color
+		counts[entry.Operation]++
+		if i > 0 && entry.Step < entries[i-1].Step {
+			t.Errorf("entry %d: step %d after step %d", i, entry.Step, entries[i-1].Step)
+		}
+		if entry.Combinations == nil || entry.Combinations.Sign() <= 0 {
+			t.Errorf("entry %d: combinations = %v, want a positive count", i, entry.Combinations)
+		}
+		// Row 3 is full, so its clue alone forces it
+		if entry.Line == (types.LineID{Direction: types.Row, Index: 2}) && entry.Operation != explain.Overlap {
+			t.Errorf("entry %d: row 3 cell recorded as %s, want overlap", i, entry.Operation)
+		}
+	}
+	solution, _ := puzzle.Cells(&g)
+	if !reflect.DeepEqual(cells, solution) {
+		t.Errorf("Entries give %v, want %v", cells, solution)
+	}
+	if counts[explain.Overlap] == 0 || counts[explain.CrossReference] == 0 {
+		t.Errorf("Expected both overlap and cross-reference entries, got %v", counts)
+	}
+
+	var out bytes.Buffer
+	if err := explain.Write(&out, p, entries); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(out.String(), "row 3: overlap forces columns 1–10 to be filled (1 combination left)") {
+		t.Errorf("Write() output missing the row 3 overlap:\n%s", out.String())
+	}
+}
+
+func TestExplainDropsAbandonedBranches(t *testing.T) {
+	p := readPuzzle(t, "size 2x2\nrows\n1\n1\ncolumns\n1\n1\n")
+	row0 := types.LineID{Direction: types.Row, Index: 0}
+	col1 := types.LineID{Direction: types.Column, Index: 1}
+	recorder := explain.NewRecorder(p)
+	events := []solver.Event{
+		{Kind: solver.EventGuess, Depth: 1, Cells: []solver.Cell{{Row: 0, Col: 0, Color: 1}}, Candidates: 1},
+		{Kind: solver.EventDeduction, Depth: 1, Line: row0, Cells: []solver.Cell{{Row: 0, Col: 1, Color: 0}}},
+		{Kind: solver.EventBacktrack, Depth: 1, Cells: []solver.Cell{{Row: 0, Col: 0, Color: 1}}},
+		{Kind: solver.EventGuess, Depth: 1, Cells: []solver.Cell{{Row: 0, Col: 0, Color: 0}}, Candidates: 0},
+		{Kind: solver.EventDeduction, Depth: 1, Line: row0, Cells: []solver.Cell{{Row: 0, Col: 1, Color: 1}}},
+		{Kind: solver.EventGuess, Depth: 2, Cells: []solver.Cell{{Row: 1, Col: 0, Color: 1}}, Candidates: 1},
+		{Kind: solver.EventDeduction, Depth: 2, Line: col1, Cells: []solver.Cell{{Row: 1, Col: 1, Color: 0}}},
+	}
+	for _, e := range events {
+		recorder.Event(e)
+	}
+
+	var ops []string
+	for _, entry := range recorder.Entries() {
+		ops = append(ops, entry.Operation)
+	}
+	want := []string{explain.Probe, explain.CrossReference, explain.Guess, explain.CrossReference}
+	if !reflect.DeepEqual(ops, want) {
+		t.Errorf("Operations = %v, want %v", ops, want)
+	}
+
+	var out bytes.Buffer
+	if err := explain.Write(&out, p, recorder.Entries()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for _, text := range []string{"probe: row 1 column 1 must be empty", "guess: row 2 column 1 is filled (depth 2"} {
+		if !strings.Contains(out.String(), text) {
+			t.Errorf("Write() output missing %q:\n%s", text, out.String())
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	network "nonogram-solver/internal/network"
+)
+
+// stubTransport answers every request with respond, called with the 1-based
+// attempt number: how many times the request's URL has been asked for
+type stubTransport struct {
+	mu       sync.Mutex
+	requests []*http.Request
+	attempts map[string]int
+	respond  func(r *http.Request, attempt int) (int, error)
+}
+
+func (s *stubTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if err := r.Context().Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	if s.attempts == nil {
+		s.attempts = make(map[string]int)
+	}
+	s.requests = append(s.requests, r)
+	s.attempts[r.URL.Path]++
+	attempt := s.attempts[r.URL.Path]
+	s.mu.Unlock()
+
+	status, err := s.respond(r, attempt)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode: status,
+		Body:       io.NopCloser(strings.NewReader("page " + r.URL.Path)),
+		Header:     make(http.Header),
+		Request:    r,
+	}, nil
+}
+
+// count returns how many attempts reached the second URL pattern, which is
+// requested on every attempt
+func (s *stubTransport) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.attempts["/nonograms2/i/42"]
+}
+
+func TestFetchPageRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		retries  int
+		respond  func(r *http.Request, attempt int) (int, error)
+		attempts int
+		wantErr  bool
+	}{
+		{
+			name:    "recovers from 5xx",
+			retries: 2,
+			respond: func(r *http.Request, attempt int) (int, error) {
+				if attempt == 1 || strings.HasPrefix(r.URL.Path, "/nonograms/") {
+					return http.StatusServiceUnavailable, nil
+				}
+				return http.StatusOK, nil
+			},
+			attempts: 2,
+		},
+		{
+			name:    "404 is not retried",
+			retries: 3,
+			respond: func(*http.Request, int) (int, error) {
+				return http.StatusNotFound, nil
+			},
+			attempts: 1,
+			wantErr:  true,
+		},
+		{
+			name:    "gives up after retries",
+			retries: 2,
+			respond: func(*http.Request, int) (int, error) {
+				return http.StatusInternalServerError, nil
+			},
+			attempts: 3,
+			wantErr:  true,
+		},
+		{
+			name:    "network errors are transient",
+			retries: 1,
+			respond: func(r *http.Request, attempt int) (int, error) {
+				if attempt == 1 {
+					return 0, errors.New("connection reset")
+				}
+				if strings.HasPrefix(r.URL.Path, "/nonograms/") {
+					return http.StatusNotFound, nil
+				}
+				return http.StatusOK, nil
+			},
+			attempts: 2,
+		},
+		{
+			name: "no retries by default",
+			respond: func(*http.Request, int) (int, error) {
+				return http.StatusBadGateway, nil
+			},
+			attempts: 1,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &stubTransport{respond: tt.respond}
+			opts := network.FetchOptions{Transport: stub, Retries: tt.retries, Backoff: time.Millisecond, UserAgent: "test-agent"}
+			body, err := network.FetchPageContext(context.Background(), "42", opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FetchPageContext error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && string(body) != "page /nonograms2/i/42" {
+				t.Errorf("body = %q, want the page from the second pattern", body)
+			}
+			if got := stub.count(); got != tt.attempts {
+				t.Errorf("made %d attempts, want %d", got, tt.attempts)
+			}
+			for _, r := range stub.requests {
+				if ua := r.Header.Get("User-Agent"); ua != "test-agent" {
+					t.Errorf("User-Agent = %q, want test-agent", ua)
+				}
+			}
+		})
+	}
+}
+
+func TestFetchPageTimeouts(t *testing.T) {
+	// The first attempt hangs until its timeout; the retry succeeds
+	stub := &stubTransport{respond: func(r *http.Request, attempt int) (int, error) {
+		if attempt == 1 {
+			<-r.Context().Done()
+			return 0, r.Context().Err()
+		}
+		return http.StatusOK, nil
+	}}
+	opts := network.FetchOptions{Transport: stub, Timeout: 20 * time.Millisecond, Retries: 1, Backoff: time.Millisecond}
+	if _, err := network.FetchPageContext(context.Background(), "42", opts); err != nil {
+		t.Errorf("FetchPageContext after a timed out attempt = %v, want success", err)
+	}
+
+	// A cancelled caller context stops retrying
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	opts.Retries = 5
+	_, err := network.FetchPageContext(ctx, "42", opts)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("FetchPageContext with a cancelled context = %v, want context.Canceled", err)
+	}
+}
+
+func TestFetchPageErrorWording(t *testing.T) {
+	tests := []struct {
+		name    string
+		respond func(r *http.Request, attempt int) (int, error)
+		want    string
+		notWant string
+	}{
+		{
+			name: "404 from both patterns",
+			respond: func(*http.Request, int) (int, error) {
+				return http.StatusNotFound, nil
+			},
+			want: "not found on either URL pattern",
+		},
+		{
+			name: "403 is not reported as missing",
+			respond: func(*http.Request, int) (int, error) {
+				return http.StatusForbidden, nil
+			},
+			want:    "fetching nonogram 42: HTTP 403",
+			notWant: "not found",
+		},
+		{
+			name: "404 and 410 are not reported as missing",
+			respond: func(r *http.Request, _ int) (int, error) {
+				if strings.HasPrefix(r.URL.Path, "/nonograms/") {
+					return http.StatusGone, nil
+				}
+				return http.StatusNotFound, nil
+			},
+			want:    "HTTP 410",
+			notWant: "not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := network.FetchOptions{Transport: &stubTransport{respond: tt.respond}}
+			_, err := network.FetchPageContext(context.Background(), "42", opts)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("FetchPageContext error = %v, want %q", err, tt.want)
+			}
+			if tt.notWant != "" && strings.Contains(err.Error(), tt.notWant) {
+				t.Errorf("FetchPageContext error = %v, should not say %q", err, tt.notWant)
+			}
+		})
+	}
+}
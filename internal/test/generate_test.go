@@ -0,0 +1,84 @@
+package test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"nonogram-solver/internal/factory"
+	"nonogram-solver/internal/generate"
+	"nonogram-solver/internal/puzzle"
+	"nonogram-solver/internal/solver"
+	"nonogram-solver/internal/types"
+)
+
+func TestLineClues(t *testing.T) {
+	got := puzzle.LineClues([]int{0, 1, 1, 2, 0, 0, 2, 2, 0, 1})
+	want := []types.ClueItem{{ColorID: 1, Clue: 2}, {ColorID: 2, Clue: 1}, {ColorID: 2, Clue: 2}, {ColorID: 1, Clue: 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LineClues() = %v, want %v", got, want)
+	}
+	if got := puzzle.LineClues([]int{0, 0}); got != nil {
+		t.Errorf("LineClues() of an empty line = %v, want none", got)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name string
+		opts generate.Options
+	}{
+		{name: "monochrome", opts: generate.Options{Width: 12, Height: 10, Colors: 1, Density: 0.5, Seed: 1}},
+		{name: "colors", opts: generate.Options{Width: 8, Height: 8, Colors: 3, Density: 0.6, Seed: 2}},
+		{name: "overlap only", opts: generate.Options{Width: 6, Height: 6, Colors: 1, Density: 0.6, Seed: 3, Technique: solver.TechniqueOverlap}},
+		{name: "probe", opts: generate.Options{Width: 8, Height: 8, Colors: 2, Density: 0.5, Seed: 4, Technique: solver.TechniqueProbe}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := generate.Generate(context.Background(), tt.opts)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			p := result.Puzzle
+			if p.Width != tt.opts.Width || p.Height != tt.opts.Height || len(p.Colors) != tt.opts.Colors {
+				t.Errorf("Generated %dx%d with %d colors", p.Width, p.Height, len(p.Colors))
+			}
+			if !reflect.DeepEqual(puzzle.FromCells(p.ID, p.Solution, p.Colors), p) {
+				t.Error("Clues do not match the solution")
+			}
+
+			want := tt.opts.Technique
+			if want == solver.TechniqueNone {
+				want = solver.TechniqueLine
+			}
+			g := p.Grid(factory.ProviderOptions{})
+			rating, err := solver.Rate(context.Background(), &g, solver.Options{})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if rating.Status != solver.Solved || !rating.Unique || rating.Technique > want {
+				t.Errorf("Rate() = %+v, want a unique solve within %s", rating, want)
+			}
+
+			// The same options give the same puzzle
+			again, err := generate.Generate(context.Background(), tt.opts)
+			if err != nil || !reflect.DeepEqual(again.Puzzle, p) {
+				t.Errorf("Second run gave a different puzzle (error %v)", err)
+			}
+		})
+	}
+}
+
+func TestGenerateRejectsOptions(t *testing.T) {
+	for _, opts := range []generate.Options{
+		{Width: 0, Height: 5, Colors: 1, Density: 0.5},
+		{Width: 5, Height: 5, Colors: 0, Density: 0.5},
+		{Width: 5, Height: 5, Colors: len(generate.Palette) + 1, Density: 0.5},
+		{Width: 5, Height: 5, Colors: 1, Density: 1},
+	} {
+		if _, err := generate.Generate(context.Background(), opts); err == nil {
+			t.Errorf("Generate(%+v) succeeded, want an error", opts)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"nonogram-solver/internal/factory"
+	"nonogram-solver/internal/line"
+	"nonogram-solver/internal/types"
+)
+
+func TestGridAtCandidates(t *testing.T) {
+	g := readPuzzle(t, colorPuzzle).Grid(factory.ProviderOptions{})
+	tests := []struct {
+		row, col int
+		want     []int
+	}{
+		{row: 0, col: 0, want: []int{0, 1}},
+		{row: 0, col: 1, want: []int{0, 2}},
+		{row: 1, col: 0, want: []int{0}},
+	}
+	for _, tt := range tests {
+		cell, err := g.At(tt.row, tt.col)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cell.Known() || cell.Value() != -1 || !reflect.DeepEqual(cell.Candidates, tt.want) {
+			t.Errorf("At(%d, %d) = %+v, want unknown with candidates %v", tt.row, tt.col, cell, tt.want)
+		}
+	}
+}
+
+func TestGridSetMarksBothLines(t *testing.T) {
+	g := readPuzzle(t, colorPuzzle).Grid(factory.ProviderOptions{})
+	if err := g.Set(0, 1, types.ColorCell(2)); err != nil {
+		t.Fatal(err)
+	}
+	if color, ok := g.Rows[0].Facts.ColorAt(1); !ok || color != 2 {
+		t.Errorf("row facts at column 1 = %d, %v, want color 2", color, ok)
+	}
+	if color, ok := g.Cols[1].Facts.ColorAt(0); !ok || color != 2 {
+		t.Errorf("column facts at row 0 = %d, %v, want color 2", color, ok)
+	}
+	if cell, err := g.At(0, 1); err != nil || !reflect.DeepEqual(cell, types.ColorCell(2)) {
+		t.Errorf("At(0, 1) = %+v, %v, want color 2", cell, err)
+	}
+	if err := g.Set(0, 1, types.ColorCell(2)); err != nil {
+		t.Errorf("setting a cell to its own state = %v", err)
+	}
+
+	// A conflict in the column leaves the row untouched
+	g.Cols[0].Facts.MarkEmpty(1)
+	err := g.Set(1, 0, types.ColorCell(1))
+	var lineErr *types.LineError
+	if !errors.Is(err, line.ErrConflict) || !errors.As(err, &lineErr) || lineErr.ID != g.Cols[0].ID {
+		t.Errorf("conflicting Set = %v, want a column 0 conflict", err)
+	}
+	if !strings.Contains(err.Error(), "Column 1: ") || !strings.Contains(err.Error(), "position 2 is empty") {
+		t.Errorf("conflict message %q, want 1-based column 1, position 2", err)
+	}
+	if g.Rows[1].Facts.IsKnown(0) {
+		t.Error("a failed Set changed the row facts")
+	}
+
+	// A state known to only one line is known
+	if cell, err := g.At(1, 0); err != nil || !reflect.DeepEqual(cell, types.EmptyCell()) {
+		t.Errorf("At(1, 0) = %+v, %v, want empty from the column", cell, err)
+	}
+	g.Rows[1].Facts.MarkFilled(0, 1)
+	if _, err := g.At(1, 0); !errors.Is(err, types.ErrConflict) {
+		t.Errorf("At on disagreeing facts = %v, want ErrConflict", err)
+	}
+
+	if err := g.Set(0, 0, types.Cell{}); err == nil {
+		t.Error("setting a cell to unknown succeeded")
+	}
+	if err := g.Set(2, 0, types.EmptyCell()); err == nil {
+		t.Error("setting a cell outside the grid succeeded")
+	}
+	for _, cell := range [][2]int{{2, 0}, {0, -1}} {
+		if _, err := g.At(cell[0], cell[1]); err == nil {
+			t.Errorf("At%v outside the grid succeeded", cell)
+		}
+	}
+
+	// Line positions count from 1, like rows and columns
+	facts := types.NewFacts()
+	facts.MarkEmpty(0)
+	if err := line.Mark(facts, 0, 1); err == nil || !strings.Contains(err.Error(), "position 1 is") {
+		t.Errorf("Mark conflict = %v, want position 1", err)
+	}
+}
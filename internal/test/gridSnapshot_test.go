@@ -0,0 +1,175 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"nonogram-solver/internal/combinatorics"
+	"nonogram-solver/internal/factory"
+	"nonogram-solver/internal/line"
+	"nonogram-solver/internal/puzzle"
+	"nonogram-solver/internal/types"
+)
+
+// snapshotGrid has a single-color first row and a two-color second row, so it
+// covers both provider implementations
+func snapshotGrid(opts factory.ProviderOptions) *types.Grid {
+	p := puzzle.FromCells("snapshot", [][]int{
+		{1, 0, 0, 0, 1, 0},
+		{1, 2, 0, 0, 0, 0},
+	}, map[int]string{1: "#000000", 2: "#ff0000"})
+	g := p.Grid(opts)
+	return &g
+}
+
+// narrow records a cell in g and filters its row's combinations
+func narrow(t *testing.T, g *types.Grid, row, col int, cell types.Cell) {
+	t.Helper()
+	if err := g.Set(row, col, cell); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := line.CrossReference(context.Background(), g.Rows[row]); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func combinations(t *testing.T, g *types.Grid, row, color int) int {
+	t.Helper()
+	combos, err := g.Rows[row].Combinations.Get(color)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(combos)
+}
+
+func TestGridCloneIsIndependent(t *testing.T) {
+	g := snapshotGrid(factory.ProviderOptions{})
+	narrow(t, g, 0, 0, types.EmptyCell())
+	narrow(t, g, 1, 0, types.ColorCell(1))
+	if n := combinations(t, g, 0, 1); n != 6 {
+		t.Fatalf("row 1 has %d combinations after the first cell, want 6", n)
+	}
+	if n := combinations(t, g, 1, 2); n != 5 {
+		t.Fatalf("row 2 has %d color 2 placements after the first cell, want 5", n)
+	}
+
+	c := g.Clone()
+	if n := combinations(t, c, 0, 1); n != 6 {
+		t.Errorf("clone lost the filtered combinations: %d, want 6", n)
+	}
+	narrow(t, c, 0, 1, types.EmptyCell())
+	narrow(t, c, 1, 1, types.EmptyCell())
+
+	if n := combinations(t, c, 0, 1); n != 3 {
+		t.Errorf("clone row 1 has %d combinations, want 3", n)
+	}
+	if n := combinations(t, c, 1, 2); n != 4 {
+		t.Errorf("clone row 2 has %d color 2 placements, want 4", n)
+	}
+	if n := combinations(t, g, 0, 1); n != 6 {
+		t.Errorf("filtering the clone changed the original row 1 to %d combinations", n)
+	}
+	if n := combinations(t, g, 1, 2); n != 5 {
+		t.Errorf("filtering the clone changed the original row 2 to %d color 2 placements", n)
+	}
+	if cell, _ := g.At(0, 1); cell.Known() {
+		t.Errorf("setting a cell in the clone changed the original to %s", cell)
+	}
+}
+
+func TestGridSnapshotRestore(t *testing.T) {
+	g := snapshotGrid(factory.ProviderOptions{})
+	narrow(t, g, 0, 0, types.EmptyCell())
+	s := g.Snapshot()
+
+	for i := 0; i < 2; i++ {
+		narrow(t, g, 0, 1, types.EmptyCell())
+		narrow(t, g, 1, 0, types.ColorCell(1))
+		if n := combinations(t, g, 0, 1); n != 3 {
+			t.Fatalf("row 1 has %d combinations before restoring, want 3", n)
+		}
+		if err := g.Restore(s); err != nil {
+			t.Fatal(err)
+		}
+		if n := combinations(t, g, 0, 1); n != 6 {
+			t.Errorf("restore %d: row 1 has %d combinations, want 6", i+1, n)
+		}
+		if n := combinations(t, g, 1, 2); n != 5 {
+			t.Errorf("restore %d: row 2 has %d color 2 placements, want all 5", i+1, n)
+		}
+		for _, cell := range [][2]int{{0, 1}, {1, 0}} {
+			if got, _ := g.At(cell[0], cell[1]); got.Known() {
+				t.Errorf("restore %d: cell %v is still %s", i+1, cell, got)
+			}
+		}
+		if got, _ := g.At(0, 0); got.State != types.CellEmpty {
+			t.Errorf("restore %d: cell (0, 0) = %s, want empty from before the snapshot", i+1, got)
+		}
+	}
+
+	other := readPuzzle(t, monochromePuzzle).Grid(factory.ProviderOptions{})
+	if err := other.Restore(s); err == nil {
+		t.Error("restoring a 6x2 snapshot into a 3x3 grid succeeded")
+	}
+}
+
+func TestGridReleaseReturnsBudget(t *testing.T) {
+	budget := combinatorics.NewMemoryBudget(0)
+	g := snapshotGrid(factory.ProviderOptions{Budget: budget})
+	narrow(t, g, 0, 0, types.EmptyCell())
+	narrow(t, g, 1, 0, types.ColorCell(1))
+	before := budget.Used()
+
+	c := g.Clone()
+	if budget.Used() <= before {
+		t.Fatalf("cloning reserved nothing: %d bytes used, %d before", budget.Used(), before)
+	}
+	c.Release()
+	if budget.Used() != before {
+		t.Errorf("released clone left %d bytes used, want %d", budget.Used(), before)
+	}
+	if n := combinations(t, c, 0, 1); n != 6 {
+		t.Errorf("released clone regenerated %d combinations, want the 6 matching its facts", n)
+	}
+	c.Release()
+
+	s := g.Snapshot()
+	if err := g.Restore(s); err != nil {
+		t.Fatal(err)
+	}
+	restored := budget.Used()
+	for i := 0; i < 3; i++ {
+		if err := g.Restore(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if budget.Used() != restored {
+		t.Errorf("repeated restores grew the budget from %d to %d bytes", restored, budget.Used())
+	}
+}
+
+func TestSnapshotReleaseReturnsBudget(t *testing.T) {
+	budget := combinatorics.NewMemoryBudget(0)
+	g := snapshotGrid(factory.ProviderOptions{Budget: budget})
+	narrow(t, g, 0, 0, types.EmptyCell())
+	narrow(t, g, 1, 0, types.ColorCell(1))
+	before := budget.Used()
+
+	s := g.Snapshot()
+	if budget.Used() <= before {
+		t.Fatalf("snapshot reserved nothing: %d bytes used, %d before", budget.Used(), before)
+	}
+	s.Release()
+	if budget.Used() != before {
+		t.Errorf("released snapshot left %d bytes used, want %d", budget.Used(), before)
+	}
+
+	// A released snapshot still restores, regenerating what it dropped
+	narrow(t, g, 0, 1, types.EmptyCell())
+	if err := g.Restore(s); err != nil {
+		t.Fatal(err)
+	}
+	if n := combinations(t, g, 0, 1); n != 6 {
+		t.Errorf("restored row 1 has %d combinations, want 6", n)
+	}
+}
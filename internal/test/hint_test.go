@@ -0,0 +1,134 @@
+package test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"nonogram-solver/internal/combinatorics"
+	"nonogram-solver/internal/factory"
+	"nonogram-solver/internal/solver"
+	"nonogram-solver/internal/types"
+)
+
+// probePuzzle stalls under line propagation; trying both states of the top-left
+// cell shows it must be empty
+const probePuzzle = `size 5x5
+rows
+2
+3
+2
+1 1
+3
+columns
+1
+1 1
+2 1
+2 1
+2 1
+`
+
+func unknownState(width, height int) [][]int {
+	state := make([][]int, height)
+	for r := range state {
+		state[r] = make([]int, width)
+		for c := range state[r] {
+			state[r][c] = -1
+		}
+	}
+	return state
+}
+
+func nextHint(t *testing.T, text string, state [][]int) (solver.Hint, bool) {
+	t.Helper()
+	g := readPuzzle(t, text).Grid(factory.ProviderOptions{})
+	hint, ok, err := solver.NextHint(context.Background(), &g, state, solver.Options{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return hint, ok
+}
+
+func TestNextHintPrefersOverlap(t *testing.T) {
+	hint, ok := nextHint(t, colorPuzzle, unknownState(3, 2))
+	want := []solver.Cell{{Row: 0, Col: 0, Color: 1}, {Row: 0, Col: 1, Color: 2}, {Row: 0, Col: 2, Color: 2}}
+	if !ok || hint.Operation != solver.HintOverlap || hint.Line != (types.LineID{Direction: types.Row, Index: 0}) {
+		t.Fatalf("NextHint() = %+v, want row 1's overlap", hint)
+	}
+	if !reflect.DeepEqual(hint.Cells, want) || hint.Combinations.Int64() != 1 {
+		t.Errorf("NextHint() cells = %v with %v combinations, want %v with 1", hint.Cells, hint.Combinations, want)
+	}
+
+	// With the overlaps filled, only cross-referencing is left
+	state := [][]int{{1, 2, 2}, {-1, -1, 2}}
+	hint, ok = nextHint(t, colorPuzzle, state)
+	if !ok || hint.Operation != solver.HintCrossReference {
+		t.Errorf("NextHint() = %+v, want a cross-reference", hint)
+	}
+}
+
+func TestNextHintMistakes(t *testing.T) {
+	// Row 2 of the solution is empty in its first cell
+	state := unknownState(3, 3)
+	state[1][0], state[2][1] = 1, 1
+	hint, ok := nextHint(t, monochromePuzzle, state)
+	want := []solver.Cell{{Row: 1, Col: 0, Color: 0}, {Row: 2, Col: 1, Color: 0}}
+	if !ok || hint.Operation != solver.HintMistake || !reflect.DeepEqual(hint.Cells, want) {
+		t.Errorf("NextHint() = %+v, want mistakes %v", hint, want)
+	}
+
+	// The ambiguous puzzle has no reference cells, but a full first row breaks its clue
+	hint, ok = nextHint(t, "size 2x2\nrows\n1\n1\ncolumns\n1\n1\n", [][]int{{1, 1}, {-1, -1}})
+	if !ok || hint.Operation != solver.HintMistake || hint.Cells != nil || hint.Line != (types.LineID{Direction: types.Row, Index: 0}) {
+		t.Errorf("NextHint() = %+v, want a mistake in row 1", hint)
+	}
+}
+
+func TestNextHintProbes(t *testing.T) {
+	state := unknownState(5, 5)
+	// Fill in what propagation already forces so only a probe is left
+	for c, color := range []int{0, 0, 1, 1, 1} {
+		state[1][c] = color
+	}
+	state[3][2], state[4][2] = 0, 1
+
+	hint, ok := nextHint(t, probePuzzle, state)
+	want := []solver.Cell{{Row: 0, Col: 0, Color: 0}}
+	if !ok || hint.Operation != solver.HintProbe || !reflect.DeepEqual(hint.Cells, want) {
+		t.Errorf("NextHint() = %+v, want a probe of %v", hint, want)
+	}
+
+	// An ambiguous puzzle has nothing to deduce
+	if hint, ok := nextHint(t, "size 2x2\nrows\n1\n1\ncolumns\n1\n1\n", unknownState(2, 2)); ok {
+		t.Errorf("NextHint() = %+v, want none", hint)
+	}
+}
+
+func TestNextHintRejectsBadState(t *testing.T) {
+	g := readPuzzle(t, monochromePuzzle).Grid(factory.ProviderOptions{})
+	for _, state := range [][][]int{unknownState(3, 2), unknownState(2, 3), {{-1, -1, 7}, {-1, -1, -1}, {-1, -1, -1}}} {
+		if _, _, err := solver.NextHint(context.Background(), &g, state, solver.Options{}); err == nil {
+			t.Errorf("NextHint(%v) succeeded, want an error", state)
+		}
+	}
+}
+
+func TestNextHintReleasesBudget(t *testing.T) {
+	budget := combinatorics.NewMemoryBudget(0)
+	g := readPuzzle(t, probePuzzle).Grid(factory.ProviderOptions{Budget: budget})
+	opts := solver.Options{Budget: budget}
+	state := unknownState(5, 5)
+
+	if _, _, err := solver.NextHint(context.Background(), &g, state, opts); err != nil {
+		t.Fatal(err)
+	}
+	before := budget.Used()
+	for i := 0; i < 3; i++ {
+		if _, _, err := solver.NextHint(context.Background(), &g, state, opts); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if budget.Used() != before {
+		t.Errorf("repeated hints grew the budget from %d to %d bytes", before, budget.Used())
+	}
+}
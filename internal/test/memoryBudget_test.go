@@ -0,0 +1,73 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"nonogram-solver/internal/combinatorics"
+	"nonogram-solver/internal/factory"
+	"nonogram-solver/internal/types"
+)
+
+func TestMemoryBudgetEvictsLeastRecentlyUsed(t *testing.T) {
+	clues := []types.ClueItem{
+		{ColorID: 1, Clue: 4},
+		{ColorID: 1, Clue: 3},
+	}
+	perLine := 6 * combinatorics.BitsetBytes(10)
+	budget := combinatorics.NewMemoryBudget(perLine + perLine/2)
+
+	first := factory.NewCombinationsProviderWithOptions(clues, 10, factory.ProviderOptions{Budget: budget})
+	second := factory.NewCombinationsProviderWithOptions(clues, 10, factory.ProviderOptions{Budget: budget})
+
+	if _, err := first.Get(1); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if budget.Used() != perLine {
+		t.Fatalf("Expected %d bytes used, got %d", perLine, budget.Used())
+	}
+
+	if _, err := second.Get(1); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if budget.Used() != perLine {
+		t.Fatalf("Expected first line to be evicted, %d bytes used", budget.Used())
+	}
+
+	// The evicted line regenerates transparently
+	combos, err := first.Get(1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(combos) != 6 {
+		t.Fatalf("Expected 6 regenerated combinations, got %d", len(combos))
+	}
+}
+
+func TestMemoryBudgetExceeded(t *testing.T) {
+	var requested int64
+	budget := combinatorics.NewMemoryBudget(1024)
+	budget.OnExceeded = func(bytes int64) { requested = bytes }
+
+	clues := []types.ClueItem{
+		{ColorID: 1, Clue: 1},
+		{ColorID: 2, Clue: 1},
+		{ColorID: 1, Clue: 1},
+	}
+
+	for _, provider := range []combinatorics.CombinationsProvider{
+		factory.NewCombinationsProviderWithOptions(clues, 40, factory.ProviderOptions{Budget: budget}),
+		factory.NewArrangementsProviderWithOptions(clues, 40, factory.ProviderOptions{Budget: budget}),
+	} {
+		requested = 0
+		if _, err := provider.Get(1); !errors.Is(err, combinatorics.ErrBudgetExceeded) {
+			t.Fatalf("Expected ErrBudgetExceeded, got %v", err)
+		}
+		if requested <= budget.Limit() {
+			t.Errorf("Expected OnExceeded with more than %d bytes, got %d", budget.Limit(), requested)
+		}
+	}
+	if budget.Used() != 0 {
+		t.Errorf("Expected nothing reserved, got %d bytes", budget.Used())
+	}
+}
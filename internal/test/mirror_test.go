@@ -0,0 +1,130 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"nonogram-solver/internal/bench"
+	"nonogram-solver/internal/mirror"
+	"nonogram-solver/internal/puzzle"
+)
+
+// fakeSite serves the monochrome test puzzle for every ID except those in
+// missing, recording which IDs were requested
+type fakeSite struct {
+	mu        sync.Mutex
+	missing   map[string]bool
+	requested []string
+}
+
+func (s *fakeSite) fetch(t *testing.T) mirror.FetchFunc {
+	return func(ctx context.Context, id string) (*puzzle.Puzzle, error) {
+		s.mu.Lock()
+		s.requested = append(s.requested, id)
+		missing := s.missing[id]
+		s.mu.Unlock()
+		if missing {
+			return nil, errors.New("not found")
+		}
+		p := readPuzzle(t, monochromePuzzle)
+		p.ID = id
+		p.Meta = &puzzle.Meta{URL: "https://www.nonograms.org/nonograms/i/" + id, Pattern: "nonograms"}
+		return p, nil
+	}
+}
+
+func (s *fakeSite) take() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := s.requested
+	s.requested = nil
+	sort.Strings(ids)
+	return ids
+}
+
+func TestMirrorResumes(t *testing.T) {
+	dir := t.TempDir()
+	site := &fakeSite{missing: map[string]bool{"3": true, "5": true}}
+	opts := mirror.Options{From: 1, To: 6, Dir: dir, Workers: 3, Fetch: site.fetch(t)}
+
+	summary, err := mirror.Run(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary != (mirror.Summary{Mirrored: 4, Failed: 2}) {
+		t.Errorf("first run = %+v, want 4 mirrored and 2 failed", summary)
+	}
+	if ids := site.take(); len(ids) != 6 {
+		t.Errorf("first run requested %v, want all 6 IDs", ids)
+	}
+	entries, err := bench.LoadCorpus(dir)
+	if err != nil || len(entries) != 4 {
+		t.Fatalf("mirror is not a corpus of 4 puzzles: %d entries, %v", len(entries), err)
+	}
+
+	manifest, err := os.ReadFile(filepath.Join(dir, mirror.ManifestFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"id":"1"`, `"file":"1.txt"`, `"pattern":"nonograms"`, `"width":3`, `"colors":1`} {
+		if !strings.Contains(string(manifest), want) {
+			t.Errorf("manifest lacks %s:\n%s", want, manifest)
+		}
+	}
+
+	// Resuming only retries the failures; 5 has appeared since
+	delete(site.missing, "5")
+	summary, err = mirror.Run(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary != (mirror.Summary{Mirrored: 1, Skipped: 4, Failed: 1}) {
+		t.Errorf("resumed run = %+v, want 1 mirrored, 4 skipped and 1 failed", summary)
+	}
+	if ids := site.take(); !reflect.DeepEqual(ids, []string{"3", "5"}) {
+		t.Errorf("resumed run requested %v, want only the failed IDs", ids)
+	}
+	failures, err := os.ReadFile(filepath.Join(dir, mirror.FailuresFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(failures)), "\n"); len(lines) != 1 || !strings.Contains(lines[0], `"id":"3"`) {
+		t.Errorf("failures = %q, want only ID 3", failures)
+	}
+
+	opts.SkipFailed = true
+	if summary, err = mirror.Run(context.Background(), opts); err != nil || summary.Skipped != 6 || len(site.take()) != 0 {
+		t.Errorf("run skipping failures = %+v, %v, want every ID skipped", summary, err)
+	}
+}
+
+func TestLimiterSpacesRequests(t *testing.T) {
+	const rate, burst = 100.0, 2
+	l := mirror.NewLimiter(rate, burst)
+	start := time.Now()
+	for i := 0; i < burst+4; i++ {
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The burst is free; the other 4 tokens take 10ms each
+	if elapsed := time.Since(start); elapsed < 35*time.Millisecond {
+		t.Errorf("%d waits took %v, want at least 40ms at %v/s", burst+4, elapsed, rate)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	slow := mirror.NewLimiter(0.001, 1)
+	slow.Wait(context.Background())
+	if err := slow.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait with a cancelled context = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,225 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"nonogram-solver/internal/cache"
+	network "nonogram-solver/internal/network"
+	"nonogram-solver/internal/puzzle"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+// monochrome.html and color.html are hand-made stand-ins for nonograms.org
+// pages: the puzzle is only in the obfuscated "var d=[...]" array, with its
+// runs shuffled. Pages saved from the site can be added next to them as
+// <id>.html; TestDecodePageGolden picks them up and -update writes their
+// golden files.
+var pageFixtures = filepath.Join("..", "..", "testdata", "nonograms.org")
+
+var fixturePictures = map[string][]string{
+	"monochrome": {
+		"...1...",
+		"..111..",
+		".11111.",
+		"1111111",
+		".11.11.",
+		".11.11.",
+	},
+	"color": {
+		"11..22",
+		"113322",
+		"..33..",
+		"22..11",
+		"223311",
+	},
+}
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(pageFixtures, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// picture renders solution cells with '.' for empty and digits for colors
+func picture(cells [][]int) []string {
+	var out []string
+	for _, row := range cells {
+		var b strings.Builder
+		for _, c := range row {
+			if c == 0 {
+				b.WriteByte('.')
+			} else {
+				b.WriteByte(byte('0' + c))
+			}
+		}
+		out = append(out, b.String())
+	}
+	return out
+}
+
+func TestDecodePageGolden(t *testing.T) {
+	pages, err := filepath.Glob(filepath.Join(pageFixtures, "*.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name := range fixturePictures {
+		if !slices.Contains(pages, filepath.Join(pageFixtures, name+".html")) {
+			t.Errorf("fixture %s.html is missing", name)
+		}
+	}
+
+	for _, page := range pages {
+		name := strings.TrimSuffix(filepath.Base(page), ".html")
+		t.Run(name, func(t *testing.T) {
+			p, err := network.DecodePage(name, readFixture(t, name+".html"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Saved pages are only checked against their golden files
+			if want, ok := fixturePictures[name]; ok {
+				if got := picture(p.Solution); !reflect.DeepEqual(got, want) {
+					t.Errorf("decoded cells:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+				}
+			}
+
+			var buf bytes.Buffer
+			if err := puzzle.Write(&buf, p, puzzle.FormatJSON); err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join(pageFixtures, name+".golden.json")
+			if *update {
+				if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			wantJSON, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Colors are a map, so compare decoded documents rather than bytes
+			got, _ := puzzle.Read(bytes.NewReader(buf.Bytes()), puzzle.FormatJSON)
+			expected, err := puzzle.Read(bytes.NewReader(wantJSON), puzzle.FormatJSON)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("decoded puzzle differs from %s (run with -update to accept):\n%s", golden, buf.String())
+			}
+		})
+	}
+}
+
+func TestDecodePageErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{name: "empty page", data: "", want: "empty"},
+		{name: "no data", data: "<html></html>", want: "could not find"},
+		{name: "bad JSON", data: "var d=[[1,2],[x]];", want: "failed to parse"},
+		{name: "too few arrays", data: "var d=[[1,2,3,4],[5,6,7,8]];", want: "data[3] (dimensions)"},
+		{name: "short width", data: "var d=[[0],[1,2],[1,0,0,9],[1,0,0,9]];", want: "data[1] (width): expected at least 4 values, got 2"},
+		{name: "zero size", data: "var d=[[0],[0,0,0,9],[1,0,0,9],[1,0,0,9]];", want: "invalid grid dimensions"},
+		{name: "no colors", data: "var d=[[0],[1,0,0,9],[1,0,0,9],[0,0,0,9]];", want: "invalid number of colors"},
+		{name: "no arrays after the dimensions", data: "var d=[[0],[1,0,0,9],[1,0,0,9],[1,0,0,9]];", want: "data[6] (grid metadata)"},
+		{name: "missing colors", data: "var d=[[0],[1,0,0,9],[1,0,0,9],[2,0,0,9],[0,0,0,0]];", want: "data[7] (grid metadata)"},
+		{name: "short color base", data: "var d=[[0],[1,0,0,9],[1,0,0,9],[2,0,0,9],[0],[1,2,3,4],[1,2,3,4]];", want: "data[7] (grid metadata)"},
+		{name: "missing grid", data: "var d=[[0],[1,0,0,9],[1,0,0,9],[1,0,0,9],[0,0,0,0],[0,0,0,0]];", want: "data[6] (grid metadata)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := network.DecodePage("1", []byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("DecodePage error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// pageServer serves the monochrome fixture only under the first URL pattern
+// and the color fixture only under the second, answering the other pattern
+// with a slow 404. The counter tracks pages served.
+func pageServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	pages := map[string][]byte{
+		"/nonograms/i/1":  readFixture(t, "monochrome.html"),
+		"/nonograms2/i/2": readFixture(t, "color.html"),
+	}
+	var requests atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if page, ok := pages[r.URL.Path]; ok {
+			requests.Add(1)
+			w.Write(page)
+			return
+		}
+		select {
+		case <-time.After(10 * time.Millisecond):
+		case <-r.Context().Done():
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(ts.Close)
+	return ts, &requests
+}
+
+func TestFetchPuzzleFromEitherPattern(t *testing.T) {
+	ts, _ := pageServer(t)
+	opts := network.FetchOptions{BaseURL: ts.URL, Client: ts.Client()}
+
+	for id, name := range map[string]string{"1": "monochrome", "2": "color"} {
+		p, err := network.FetchPuzzleContext(context.Background(), id, opts)
+		if err != nil {
+			t.Fatalf("FetchPuzzleContext(%s) = %v", id, err)
+		}
+		if p.ID != id || len(p.Solution) != len(fixturePictures[name]) {
+			t.Errorf("FetchPuzzleContext(%s) = %s %dx%d, want the %s fixture", id, p.ID, p.Width, p.Height, name)
+		}
+	}
+
+	if _, err := network.FetchPuzzleContext(context.Background(), "3", opts); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("FetchPuzzleContext of a missing ID = %v, want not found", err)
+	}
+}
+
+func TestSourceCachesPages(t *testing.T) {
+	ts, requests := pageServer(t)
+	c := cache.New(t.TempDir(), 0)
+	src := network.Source{Options: network.FetchOptions{BaseURL: ts.URL, Client: ts.Client()}, Cache: c}
+
+	first, err := src.Fetch(context.Background(), "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetched := requests.Load()
+	if page, ok := c.Get("nonograms.org/2.html"); !ok || !bytes.Contains(page, []byte("var d=")) {
+		t.Error("the page HTML was not cached")
+	}
+
+	second, err := src.Fetch(context.Background(), "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests.Load() != fetched {
+		t.Errorf("second fetch downloaded %d more pages, want none", requests.Load()-fetched)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("cached puzzle %+v differs from the fetched %+v", second, first)
+	}
+}
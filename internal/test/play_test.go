@@ -0,0 +1,107 @@
+package test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"nonogram-solver/internal/factory"
+	"nonogram-solver/internal/play"
+	"nonogram-solver/internal/solver"
+)
+
+func newGame(t *testing.T, text string) *play.Game {
+	t.Helper()
+	p := readPuzzle(t, text)
+	facts, err := play.SolverFacts(context.Background(), p, solver.Options{}, factory.ProviderOptions{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return play.NewGame(p, facts)
+}
+
+func TestParseKeys(t *testing.T) {
+	keys := play.ParseKeys([]byte("\x1b[A\x1b[Cjx 3\x1b[3~?q"))
+	want := []play.Key{
+		{Action: play.ActionUp},
+		{Action: play.ActionRight},
+		{Action: play.ActionDown},
+		{Action: play.ActionEmpty},
+		{Action: play.ActionFill},
+		{Action: play.ActionSelectColor, Color: 3},
+		{Action: play.ActionClear},
+		{Action: play.ActionHint},
+		{Action: play.ActionQuit},
+	}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("ParseKeys() = %v, want %v", keys, want)
+	}
+}
+
+func TestGameMistakesAndSolve(t *testing.T) {
+	g := newGame(t, monochromePuzzle)
+
+	// Fill (1,0), which the solution leaves empty
+	for _, k := range play.ParseKeys([]byte("j ")) {
+		g.Handle(k)
+	}
+	if mistakes := g.Mistakes(); !reflect.DeepEqual(mistakes, [][2]int{{1, 0}}) {
+		t.Fatalf("Mistakes() = %v, want [[1 0]]", mistakes)
+	}
+	g.Handle(play.Key{Action: play.ActionCheck})
+	if !g.ShowMistakes || !strings.Contains(g.Message, "row 2 column 1") {
+		t.Errorf("Check() message = %q", g.Message)
+	}
+
+	// A hint points at the mistake before anything else
+	hint, ok := g.NextHint()
+	if !ok || hint.Operation != play.HintMistake {
+		t.Errorf("NextHint() = %+v, want a mistake", hint)
+	}
+
+	g.Handle(play.Key{Action: play.ActionFill})
+	for _, cell := range [][2]int{{0, 0}, {0, 1}, {0, 2}, {1, 1}, {2, 0}, {2, 2}} {
+		g.Row, g.Col = cell[0], cell[1]
+		g.Handle(play.Key{Action: play.ActionFill})
+	}
+	if !g.Solved() || g.Message != "Solved!" {
+		t.Errorf("Solved() = %v, message %q", g.Solved(), g.Message)
+	}
+}
+
+func TestGameHints(t *testing.T) {
+	g := newGame(t, colorPuzzle)
+
+	hint, ok := g.NextHint()
+	if !ok || hint.Operation != play.HintOverlap {
+		t.Fatalf("first hint = %+v, want an overlap", hint)
+	}
+	if want := "row 1: overlap forces column 1 to be color 1 (#ff0000) and columns 2–3 to be color 2 (#0000ff)"; hint.Text != want {
+		t.Errorf("hint text = %q, want %q", hint.Text, want)
+	}
+
+	// Column 3's clue still forces its second cell, which beats cross-referencing
+	for _, cell := range hint.Cells {
+		g.Cells[cell.Row][cell.Col] = cell.Color
+	}
+	hint, ok = g.NextHint()
+	if !ok || hint.Operation != play.HintOverlap || hint.Text != "column 3: overlap forces row 2 to be color 2 (#0000ff)" {
+		t.Fatalf("second hint = %+v, want column 3's overlap", hint)
+	}
+
+	// Then column 1's known cell plus its clue force the empty below
+	for _, cell := range hint.Cells {
+		g.Cells[cell.Row][cell.Col] = cell.Color
+	}
+	hint, ok = g.NextHint()
+	if !ok || hint.Operation != play.HintCrossReference {
+		t.Errorf("third hint = %+v, want a cross-reference", hint)
+	}
+
+	// An ambiguous puzzle has nothing a single line can force
+	g = newGame(t, "size 2x2\nrows\n1\n1\ncolumns\n1\n1\n")
+	if _, ok := g.NextHint(); ok {
+		t.Error("Expected no hint for an ambiguous puzzle with unknown facts")
+	}
+}
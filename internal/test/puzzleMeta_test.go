@@ -0,0 +1,84 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	network "nonogram-solver/internal/network"
+	"nonogram-solver/internal/puzzle"
+)
+
+func TestDecodePageMeta(t *testing.T) {
+	tests := map[string]puzzle.Meta{
+		"monochrome": {Title: "House", Author: "Test Fixtures", Size: "7x6", Difficulty: "1"},
+		"color":      {Title: "Checks", Author: "Test Fixtures", Size: "6x5", Difficulty: "2"},
+	}
+	for name, want := range tests {
+		p, err := network.DecodePage(name, readFixture(t, name+".html"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Meta == nil || *p.Meta != want {
+			t.Errorf("%s: Meta = %+v, want %+v", name, p.Meta, want)
+		}
+	}
+
+	// Pages without a heading or labels carry no metadata
+	p, err := network.DecodePage("2", page(fixtureData(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Meta != nil {
+		t.Errorf("bare data Meta = %+v, want nil", p.Meta)
+	}
+}
+
+func TestFetchPuzzleMetaPattern(t *testing.T) {
+	ts, _ := pageServer(t)
+	opts := network.FetchOptions{BaseURL: ts.URL, Client: ts.Client()}
+
+	for id, pattern := range map[string]string{"1": network.PatternMonochrome, "2": network.PatternColor} {
+		p, err := network.FetchPuzzleContext(context.Background(), id, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := ts.URL + "/" + pattern + "/i/" + id; p.Meta == nil || p.Meta.URL != want || p.Meta.Pattern != pattern {
+			t.Errorf("FetchPuzzleContext(%s).Meta = %+v, want URL %s and pattern %s", id, p.Meta, want, pattern)
+		}
+	}
+}
+
+func TestMetaRoundTrip(t *testing.T) {
+	p := readPuzzle(t, monochromePuzzle)
+	p.Meta = &puzzle.Meta{URL: "https://example.test/nonograms/i/7", Pattern: "nonograms", Title: "Two Words", Difficulty: "3"}
+
+	for _, format := range []puzzle.Format{puzzle.FormatText, puzzle.FormatJSON} {
+		var buf bytes.Buffer
+		if err := puzzle.Write(&buf, p, format); err != nil {
+			t.Fatal(err)
+		}
+		got, err := puzzle.Read(&buf, format)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if got.Meta == nil || *got.Meta != *p.Meta {
+			t.Errorf("%s round trip Meta = %+v, want %+v", format, got.Meta, p.Meta)
+		}
+	}
+
+	// Puzzles without metadata write none
+	p.Meta = &puzzle.Meta{}
+	var buf bytes.Buffer
+	if err := puzzle.Write(&buf, p, puzzle.FormatText); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "meta") {
+		t.Errorf("empty Meta was written:\n%s", buf.String())
+	}
+
+	if _, err := puzzle.Read(strings.NewReader("size 1x1\nmeta colour red\nrows\n1\ncolumns\n1\n"), puzzle.FormatText); err == nil || !strings.Contains(err.Error(), "unknown meta key") {
+		t.Errorf("unknown meta key error = %v", err)
+	}
+}
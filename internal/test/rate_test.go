@@ -0,0 +1,134 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"nonogram-solver/internal/combinatorics"
+	"nonogram-solver/internal/factory"
+	"nonogram-solver/internal/line"
+	"nonogram-solver/internal/solver"
+	"nonogram-solver/internal/types"
+)
+
+// linePuzzle needs complete line solving: simple boxes and spaces stall on it
+const linePuzzle = `size 5x5
+rows
+1 1
+4
+1 2
+3
+1 1
+columns
+1 1
+1 1
+2 2
+3
+3
+`
+
+// crossPuzzle needs a cell's row and column candidates intersected: each
+// line alone allows two colors where the other allows only one of them
+const crossPuzzle = `size 5x5
+color 1 #ff0000
+color 2 #0000ff
+rows
+1:1 1:2
+1:2 1:1
+2:2
+1:1 1:1
+1:2 1:2
+columns
+1:1 2:2
+1:2 1:1
+1:2 1:2
+1:1 1:1
+1:2
+`
+
+func TestRate(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		technique solver.Technique
+		status    solver.Status
+		unique    bool
+		probes    bool
+		depth     int
+	}{
+		{name: "overlap", text: monochromePuzzle, technique: solver.TechniqueOverlap, status: solver.Solved, unique: true},
+		{name: "line", text: linePuzzle, technique: solver.TechniqueLine, status: solver.Solved, unique: true},
+		{name: "cross-reference", text: crossPuzzle, technique: solver.TechniqueCrossReference, status: solver.Solved, unique: true},
+		{name: "probe", text: probePuzzle, technique: solver.TechniqueProbe, status: solver.Solved, unique: true, probes: true, depth: 1},
+		{name: "backtrack", text: "size 2x2\nrows\n1\n1\ncolumns\n1\n1\n", technique: solver.TechniqueBacktrack, status: solver.Solved, depth: 1},
+		{name: "contradiction", text: "size 2x2\nrows\n-\n2\ncolumns\n-\n2\n", technique: solver.TechniqueNone, status: solver.Contradiction},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := readPuzzle(t, tt.text).Grid(factory.ProviderOptions{})
+			rating, err := solver.Rate(context.Background(), &g, solver.Options{})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if rating.Technique != tt.technique || rating.Status != tt.status {
+				t.Errorf("Rate() = %s (%s), want %s (%s)", rating.Technique, rating.Status, tt.technique, tt.status)
+			}
+			if rating.Status == solver.Solved && (rating.Unique != tt.unique || rating.Depth != tt.depth || (rating.Probes > 0) != tt.probes) {
+				t.Errorf("Rate() = %+v, want unique %v, depth %d, probes %v", rating, tt.unique, tt.depth, tt.probes)
+			}
+			if rating.Status == solver.Solved && rating.Passes == 0 {
+				t.Error("Expected propagation passes to be counted")
+			}
+		})
+	}
+}
+
+func TestSimpleIsWeakerThanSettle(t *testing.T) {
+	// "1 1" in 5 cells with the middle filled: either clue may take the
+	// middle, so the extremes force nothing, but every placement leaves the
+	// cells beside it empty
+	l := &types.Line{Length: 5, Clues: []types.ClueItem{{ColorID: 1, Clue: 1}, {ColorID: 1, Clue: 1}}, Facts: types.NewFacts()}
+	line.Mark(l.Facts, 2, 1)
+
+	simple, err := line.Simple(l)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	settled, err := line.Settle(l)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(simple) >= len(settled) {
+		t.Errorf("Simple() = %v, Settle() = %v; want Simple to find less", simple, settled)
+	}
+	for _, change := range simple {
+		found := false
+		for _, other := range settled {
+			found = found || other == change
+		}
+		if !found {
+			t.Errorf("Simple() deduced %v, which Settle() does not", change)
+		}
+	}
+}
+
+func TestRateProbeReleasesBudget(t *testing.T) {
+	budget := combinatorics.NewMemoryBudget(0)
+	g := readPuzzle(t, probePuzzle).Grid(factory.ProviderOptions{Budget: budget})
+	if result, err := solver.Solve(context.Background(), &g, solver.Options{}); err != nil || result.Status != solver.Stalled {
+		t.Fatalf("Solve without search = %+v, %v, want stalled", result, err)
+	}
+	before := budget.Used()
+
+	rating, err := solver.RateWithin(context.Background(), &g, solver.TechniqueProbe, solver.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rating.Technique != solver.TechniqueProbe || rating.Probes == 0 {
+		t.Fatalf("rating = %+v, want a probe solve", rating)
+	}
+	if budget.Used() != before {
+		t.Errorf("probing left %d bytes reserved, want the grid's own %d", budget.Used(), before)
+	}
+}
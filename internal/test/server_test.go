@@ -0,0 +1,291 @@
+package test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"nonogram-solver/internal/puzzle"
+	"nonogram-solver/internal/server"
+)
+
+func puzzleJSON(t *testing.T, text string) string {
+	t.Helper()
+	var sb strings.Builder
+	if err := puzzle.Write(&sb, readPuzzle(t, text), puzzle.FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	return sb.String()
+}
+
+func newTestServer(t *testing.T, opts server.Options) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(server.New(opts).Handler())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func postJSON(t *testing.T, url, body string, out any) int {
+	t.Helper()
+	resp, err := http.Post(url, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		t.Fatalf("Expected a JSON body, got %v", err)
+	}
+	return resp.StatusCode
+}
+
+func TestServerSolve(t *testing.T) {
+	ts := newTestServer(t, server.Options{Search: true})
+
+	var report struct {
+		Status string  `json:"status"`
+		Width  int     `json:"width"`
+		Cells  [][]int `json:"cells"`
+	}
+	code := postJSON(t, ts.URL+"/solve", puzzleJSON(t, colorPuzzle), &report)
+	if code != http.StatusOK || report.Status != "solved" || report.Width != 3 {
+		t.Fatalf("POST /solve = %d %+v", code, report)
+	}
+	if report.Cells[0][1] != 2 || report.Cells[1][0] != 0 {
+		t.Errorf("cells = %v", report.Cells)
+	}
+
+	var failure map[string]string
+	if code := postJSON(t, ts.URL+"/solve", `{"width": 0}`, &failure); code != http.StatusBadRequest || failure["error"] == "" {
+		t.Errorf("POST /solve with a bad puzzle = %d %v, want 400 with an error", code, failure)
+	}
+	if code := postJSON(t, ts.URL+"/solve?timeout=soon", puzzleJSON(t, colorPuzzle), &failure); code != http.StatusBadRequest {
+		t.Errorf("POST /solve with a bad timeout = %d, want 400", code)
+	}
+}
+
+func TestServerCheck(t *testing.T) {
+	ts := newTestServer(t, server.Options{})
+
+	tests := []struct {
+		name   string
+		text   string
+		unique bool
+		count  int
+	}{
+		{"unique", monochromePuzzle, true, 1},
+		{"ambiguous", "size 2x2\nrows\n1\n1\ncolumns\n1\n1\n", false, 2},
+		{"none", "size 2x2\nrows\n-\n2\ncolumns\n-\n2\n", false, 0},
+	}
+	for _, tt := range tests {
+		var resp struct {
+			Unique    bool `json:"unique"`
+			Solutions int  `json:"solutions"`
+		}
+		code := postJSON(t, ts.URL+"/check", puzzleJSON(t, tt.text), &resp)
+		if code != http.StatusOK || resp.Unique != tt.unique || resp.Solutions != tt.count {
+			t.Errorf("%s: POST /check = %d %+v, want unique=%v solutions=%d", tt.name, code, resp, tt.unique, tt.count)
+		}
+	}
+}
+
+func TestServerFetchHidesSolution(t *testing.T) {
+	fetch := func(ctx context.Context, id string) (*puzzle.Puzzle, error) {
+		if id != "42" {
+			return nil, errors.New("not found")
+		}
+		p := readPuzzle(t, monochromePuzzle)
+		p.ID = id
+		p.Solution = [][]int{{1, 1, 1}, {0, 1, 0}, {1, 0, 1}}
+		return p, nil
+	}
+	ts := newTestServer(t, server.Options{Fetch: fetch})
+
+	resp, err := http.Get(ts.URL + "/puzzles/42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	p, err := puzzle.Read(resp.Body, puzzle.FormatJSON)
+	if err != nil {
+		t.Fatalf("Expected puzzle JSON, got %v", err)
+	}
+	if p.ID != "42" || p.Width != 3 || p.Solution != nil {
+		t.Errorf("GET /puzzles/42 = %+v, want clues without a solution", p)
+	}
+
+	for path, want := range map[string]int{"/puzzles/7": http.StatusBadGateway, "/puzzles/abc": http.StatusBadRequest} {
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("GET %s = %d, want %d", path, resp.StatusCode, want)
+		}
+	}
+}
+
+func TestServerFetchHonorsTimeout(t *testing.T) {
+	cancelled := make(chan error, 1)
+	fetch := func(ctx context.Context, id string) (*puzzle.Puzzle, error) {
+		<-ctx.Done()
+		cancelled <- ctx.Err()
+		return nil, ctx.Err()
+	}
+	ts := newTestServer(t, server.Options{Fetch: fetch})
+
+	resp, err := http.Get(ts.URL + "/puzzles/42?timeout=20ms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Errorf("GET /puzzles/42 past its timeout = %d, want %d", resp.StatusCode, http.StatusBadGateway)
+	}
+	select {
+	case err := <-cancelled:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("fetch context ended with %v, want the request deadline", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("the fetch never saw its context end")
+	}
+}
+
+func TestServerTimeout(t *testing.T) {
+	// The deadline expires either while waiting for a slot (503) or during the
+	// solve, which then reports a timeout status
+	ts := newTestServer(t, server.Options{Timeout: time.Nanosecond, Search: true})
+
+	var body map[string]any
+	code := postJSON(t, ts.URL+"/solve", puzzleJSON(t, monochromePuzzle), &body)
+	switch code {
+	case http.StatusOK:
+		if body["status"] != "timeout" {
+			t.Errorf("POST /solve status = %v, want timeout", body["status"])
+		}
+	case http.StatusServiceUnavailable:
+		if body["error"] == nil {
+			t.Error("Expected an error message with 503")
+		}
+	default:
+		t.Errorf("POST /solve = %d, want 200 with a timeout status or 503", code)
+	}
+}
+
+// readSSE parses an event stream into (event name, data) pairs
+func readSSE(t *testing.T, body io.Reader) (names, data []string) {
+	t.Helper()
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 1<<20), 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			names = append(names, strings.TrimPrefix(line, "event: "))
+		case strings.HasPrefix(line, "data: "):
+			data = append(data, strings.TrimPrefix(line, "data: "))
+		}
+	}
+	return names, data
+}
+
+func TestServerJobEvents(t *testing.T) {
+	ts := newTestServer(t, server.Options{Search: true})
+
+	var created struct {
+		ID    string `json:"id"`
+		State string `json:"state"`
+	}
+	code := postJSON(t, ts.URL+"/solve/jobs", puzzleJSON(t, "size 2x2\nrows\n1\n1\ncolumns\n1\n1\n"), &created)
+	if code != http.StatusAccepted || created.ID == "" {
+		t.Fatalf("POST /solve/jobs = %d %+v", code, created)
+	}
+
+	resp, err := http.Get(ts.URL + "/solve/" + created.ID + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	names, data := readSSE(t, resp.Body)
+	resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	if len(names) < 3 || names[len(names)-2] != "done" || names[len(names)-1] != "end" {
+		t.Fatalf("events = %v, want a stream ending with done and end", names)
+	}
+	if !strings.Contains(strings.Join(names, " "), "guess") {
+		t.Errorf("events = %v, want a guess for the ambiguous puzzle", names)
+	}
+	var end struct {
+		State  string `json:"state"`
+		Report struct {
+			Status string `json:"status"`
+		} `json:"report"`
+	}
+	if err := json.Unmarshal([]byte(data[len(data)-1]), &end); err != nil || end.State != "done" || end.Report.Status != "solved" {
+		t.Errorf("end event = %s (%v)", data[len(data)-1], err)
+	}
+
+	// Reconnecting after the first event replays the rest
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/solve/"+created.ID+"/events", nil)
+	req.Header.Set("Last-Event-ID", "0")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resumed, _ := readSSE(t, resp.Body)
+	resp.Body.Close()
+	if len(resumed) != len(names)-1 {
+		t.Errorf("resumed %d events, want %d", len(resumed), len(names)-1)
+	}
+}
+
+func TestServerCancelJob(t *testing.T) {
+	ts := newTestServer(t, server.Options{Search: true, MaxConcurrent: 1})
+	entries := loadCorpus(t)
+	slowest := entries[len(entries)-1].Puzzle
+	var body strings.Builder
+	puzzle.Write(&body, slowest, puzzle.FormatJSON)
+
+	var created struct {
+		ID string `json:"id"`
+	}
+	if code := postJSON(t, ts.URL+"/solve/jobs", body.String(), &created); code != http.StatusAccepted {
+		t.Fatalf("POST /solve/jobs = %d", code)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, ts.URL+"/solve/"+created.ID, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	resp, err = http.Get(ts.URL + "/solve/" + created.ID + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, data := readSSE(t, resp.Body)
+	resp.Body.Close()
+	if last := data[len(data)-1]; !strings.Contains(last, `"state":"cancelled"`) || !strings.Contains(last, `"status":"timeout"`) {
+		t.Errorf("end event = %s, want a cancelled job with a timeout report", last)
+	}
+
+	resp, err = http.Get(ts.URL + "/solve/unknown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET /solve/unknown = %d, want 404", resp.StatusCode)
+	}
+}
@@ -0,0 +1,116 @@
+package test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"nonogram-solver/internal/factory"
+	"nonogram-solver/internal/puzzle"
+	"nonogram-solver/internal/solver"
+)
+
+// replayEvents rebuilds the cells a UI would show by applying deductions and
+// guesses and undoing branches on backtrack
+func replayEvents(t *testing.T, events []solver.Event, width, height int) [][]int {
+	t.Helper()
+	cells := make([][]int, height)
+	for r := range cells {
+		cells[r] = make([]int, width)
+		for c := range cells[r] {
+			cells[r][c] = -1
+		}
+	}
+	snapshot := func() [][]int {
+		out := make([][]int, height)
+		for r := range cells {
+			out[r] = append([]int(nil), cells[r]...)
+		}
+		return out
+	}
+
+	var branches [][][]int // state before each open guess, indexed by depth-1
+	for i, e := range events {
+		switch e.Kind {
+		case solver.EventGuess:
+			if e.Depth != len(branches)+1 {
+				t.Fatalf("event %d: guess at depth %d with %d open branches", i, e.Depth, len(branches))
+			}
+			branches = append(branches, snapshot())
+		case solver.EventBacktrack:
+			if e.Depth != len(branches) {
+				t.Fatalf("event %d: backtrack at depth %d with %d open branches", i, e.Depth, len(branches))
+			}
+			cells = branches[len(branches)-1]
+			branches = branches[:len(branches)-1]
+			continue
+		case solver.EventDeduction:
+			if e.Depth != len(branches) {
+				t.Fatalf("event %d: deduction at depth %d with %d open branches", i, e.Depth, len(branches))
+			}
+		}
+		for _, cell := range e.Cells {
+			cells[cell.Row][cell.Col] = cell.Color
+		}
+	}
+	return cells
+}
+
+func TestSolveEvents(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"propagation only", colorPuzzle},
+		{"with search", "size 2x2\nrows\n1\n1\ncolumns\n1\n1\n"},
+		{"fallback", monochromePuzzle},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := readPuzzle(t, tt.text)
+			providers := factory.ProviderOptions{}
+			if tt.name == "fallback" {
+				providers.MaxCombinations = 1
+			}
+			g := p.Grid(providers)
+
+			var events []solver.Event
+			result, err := solver.Solve(context.Background(), &g, solver.Options{
+				Search: true,
+				Events: func(e solver.Event) { events = append(events, e) },
+			})
+			if err != nil || result.Status != solver.Solved {
+				t.Fatalf("Solve() = %v, %v", result.Status, err)
+			}
+
+			last := events[len(events)-1]
+			if last.Kind != solver.EventDone || last.Status != solver.Solved || last.Depth != 0 {
+				t.Errorf("last event = %+v, want done/solved at depth 0", last)
+			}
+			progress, settled := 0, 0
+			for _, e := range events {
+				if e.Kind == solver.EventProgress {
+					progress++
+					if e.Total != p.Width*p.Height || e.Known > e.Total {
+						t.Errorf("progress event = %+v", e)
+					}
+				}
+				if e.Kind == solver.EventDeduction && e.Operation == solver.OpSettle {
+					settled++
+				}
+			}
+			if tt.name == "fallback" && settled == 0 {
+				t.Errorf("no %q deductions over the enumeration limit", solver.OpSettle)
+			}
+			if progress != result.Passes {
+				t.Errorf("%d progress events for %d passes", progress, result.Passes)
+			}
+
+			cells, _ := puzzle.Cells(&g)
+			if got := replayEvents(t, events, p.Width, p.Height); !reflect.DeepEqual(got, cells) {
+				t.Errorf("replayed cells = %v, want %v", got, cells)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"nonogram-solver/internal/combinatorics"
+	"nonogram-solver/internal/factory"
+	"nonogram-solver/internal/puzzle"
+	"nonogram-solver/internal/solver"
+)
+
+const monochromePuzzle = `size 3x3
+rows
+3
+1
+1 1
+columns
+1 1
+2
+1 1
+`
+
+const colorPuzzle = `size 3x2
+color 1 #ff0000
+color 2 #0000ff
+rows
+1:1 2:2
+1:2
+columns
+1:1
+1:2
+2:2
+`
+
+func readPuzzle(t *testing.T, text string) *puzzle.Puzzle {
+	t.Helper()
+	p, err := puzzle.Read(strings.NewReader(text), puzzle.FormatText)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return p
+}
+
+func TestSolve(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		cells [][]int
+	}{
+		{
+			name:  "monochrome",
+			text:  monochromePuzzle,
+			cells: [][]int{{1, 1, 1}, {0, 1, 0}, {1, 0, 1}},
+		},
+		{
+			name:  "multi-color",
+			text:  colorPuzzle,
+			cells: [][]int{{1, 2, 2}, {0, 0, 2}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := readPuzzle(t, tt.text).Grid(factory.ProviderOptions{})
+			result, err := solver.Solve(context.Background(), &g, solver.Options{})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if result.Status != solver.Solved {
+				t.Fatalf("Status = %v (%v), want solved", result.Status, result.Reason)
+			}
+			cells, complete := puzzle.Cells(&g)
+			if !complete || !reflect.DeepEqual(cells, tt.cells) {
+				t.Errorf("cells = %v, want %v", cells, tt.cells)
+			}
+		})
+	}
+}
+
+func TestSolveFallsBackOverEnumerationLimit(t *testing.T) {
+	// Every line has more combinations than the limit, so all of them must be
+	// solved without enumeration
+	g := readPuzzle(t, monochromePuzzle).Grid(factory.ProviderOptions{MaxCombinations: 1})
+	result, err := solver.Solve(context.Background(), &g, solver.Options{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Status != solver.Solved {
+		t.Errorf("Status = %v (%v), want solved", result.Status, result.Reason)
+	}
+}
+
+func TestSolveContradiction(t *testing.T) {
+	// The totals match, but the full bottom row needs a cell in the empty first column
+	p := readPuzzle(t, "size 2x2\nrows\n-\n2\ncolumns\n-\n2\n")
+	g := p.Grid(factory.ProviderOptions{})
+
+	result, err := solver.Solve(context.Background(), &g, solver.Options{Search: true})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Status != solver.Contradiction || result.Reason == nil {
+		t.Errorf("Status = %v (%v), want contradiction with a reason", result.Status, result.Reason)
+	}
+}
+
+func TestSolveSearchesWhenStalled(t *testing.T) {
+	// Two diagonal solutions: propagation alone deduces nothing
+	p := readPuzzle(t, "size 2x2\nrows\n1\n1\ncolumns\n1\n1\n")
+
+	g := p.Grid(factory.ProviderOptions{})
+	result, err := solver.Solve(context.Background(), &g, solver.Options{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Status != solver.Stalled {
+		t.Errorf("Status without search = %v, want stalled", result.Status)
+	}
+
+	g = p.Grid(factory.ProviderOptions{})
+	result, err = solver.Solve(context.Background(), &g, solver.Options{Search: true})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Status != solver.Solved || result.Guesses == 0 {
+		t.Errorf("Status with search = %v after %d guesses, want solved by guessing", result.Status, result.Guesses)
+	}
+}
+
+func TestSearchReleasesBranches(t *testing.T) {
+	// Branches are clones of the grid, sharing its memory budget
+	budget := combinatorics.NewMemoryBudget(0)
+	g := readPuzzle(t, "size 2x2\nrows\n1\n1\ncolumns\n1\n1\n").Grid(factory.ProviderOptions{Budget: budget})
+	if result, err := solver.Solve(context.Background(), &g, solver.Options{}); err != nil || result.Status != solver.Stalled {
+		t.Fatalf("Solve without search = %+v, %v, want stalled", result, err)
+	}
+	before := budget.Used()
+
+	if count, _, err := solver.CountSolutions(context.Background(), &g, 2, solver.Options{}); err != nil || count != 2 {
+		t.Fatalf("CountSolutions = %d, %v, want 2", count, err)
+	}
+	if _, err := solver.Rate(context.Background(), &g, solver.Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if budget.Used() != before {
+		t.Errorf("abandoned branches left %d bytes reserved, want the grid's own %d", budget.Used(), before)
+	}
+}
+
+func TestCountSolutions(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{name: "unique", text: monochromePuzzle, want: 1},
+		{name: "ambiguous", text: "size 2x2\nrows\n1\n1\ncolumns\n1\n1\n", want: 2},
+		{name: "none", text: "size 2x2\nrows\n-\n2\ncolumns\n-\n2\n", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := readPuzzle(t, tt.text).Grid(factory.ProviderOptions{})
+			count, _, err := solver.CountSolutions(context.Background(), &g, 2, solver.Options{})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if count != tt.want {
+				t.Errorf("CountSolutions() = %d, want %d", count, tt.want)
+			}
+			if _, complete := puzzle.Cells(&g); complete {
+				t.Error("CountSolutions modified the grid")
+			}
+		})
+	}
+}
+
+func TestSolveTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	g := readPuzzle(t, monochromePuzzle).Grid(factory.ProviderOptions{})
+	result, err := solver.Solve(ctx, &g, solver.Options{Search: true})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Status != solver.Timeout {
+		t.Errorf("Status = %v, want timeout", result.Status)
+	}
+}
+
+func TestPuzzleFormatRoundTrip(t *testing.T) {
+	p := readPuzzle(t, colorPuzzle)
+	p.Solution = [][]int{{1, 2, 2}, {0, 0, 2}}
+
+	for _, format := range []puzzle.Format{puzzle.FormatJSON, puzzle.FormatText} {
+		var sb strings.Builder
+		if err := puzzle.Write(&sb, p, format); err != nil {
+			t.Fatalf("Write(%s) error = %v", format, err)
+		}
+		got, err := puzzle.Read(strings.NewReader(sb.String()), format)
+		if err != nil {
+			t.Fatalf("Read(%s) error = %v\n%s", format, err, sb.String())
+		}
+		if !reflect.DeepEqual(got, p) {
+			t.Errorf("%s round trip = %+v, want %+v", format, got, p)
+		}
+	}
+}
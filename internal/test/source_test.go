@@ -0,0 +1,188 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	network "nonogram-solver/internal/network"
+	"nonogram-solver/internal/puzzle"
+	"nonogram-solver/internal/source"
+	"nonogram-solver/internal/types"
+)
+
+const pbnPuzzle = `<?xml version="1.0"?>
+<!DOCTYPE pbn SYSTEM "https://webpbn.com/pbn-0.3.dtd">
+<puzzleset>
+<puzzle type="grid" defaultcolor="black">
+<id>#7</id>
+<color name="white" char=".">fff</color>
+<color name="black" char="X">000</color>
+<color name="red" char="r">F00</color>
+<clues type="columns">
+<line><count>1</count></line>
+<line><count color="red">1</count><count>1</count></line>
+<line></line>
+</clues>
+<clues type="rows">
+<line><count>1</count><count color="red">1</count></line>
+<line><count>1</count></line>
+</clues>
+<solution type="goal">
+<image>
+|Xr.|
+|.X.|
+</image>
+</solution>
+</puzzle>
+</puzzleset>
+`
+
+func TestRegistryDispatchesByScheme(t *testing.T) {
+	r := source.NewRegistry()
+	var got []string
+	r.Register("Fake", source.Func(func(ctx context.Context, id string) (*puzzle.Puzzle, error) {
+		got = append(got, id)
+		return &puzzle.Puzzle{ID: id}, nil
+	}))
+
+	p, err := r.Fetch(context.Background(), "fake:a:b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.ID != "a:b" || !reflect.DeepEqual(got, []string{"a:b"}) {
+		t.Errorf("fetched %q via %v, want the text after the first colon", p.ID, got)
+	}
+
+	for _, uri := range []string{"12345", `C:\puzzles\p.json`, "webpbn:1"} {
+		if _, _, ok := r.Split(uri); ok {
+			t.Errorf("Split(%q) accepted an unregistered scheme", uri)
+		}
+		if _, err := r.Fetch(context.Background(), uri); err == nil {
+			t.Errorf("Fetch(%q) succeeded, want an error", uri)
+		}
+	}
+}
+
+func TestFileSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "p.txt")
+	if err := os.WriteFile(path, []byte(monochromePuzzle), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := source.File{}.Fetch(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Width != 3 || p.Height != 3 {
+		t.Errorf("file puzzle is %dx%d, want 3x3", p.Width, p.Height)
+	}
+
+	stdin := source.File{Format: puzzle.FormatText, Stdin: strings.NewReader(monochromePuzzle)}
+	if p, err := stdin.Fetch(context.Background(), "-"); err != nil || p.Width != 3 {
+		t.Errorf("stdin puzzle = %+v, %v", p, err)
+	}
+	if _, err := (source.File{}).Fetch(context.Background(), "-"); err == nil {
+		t.Error("reading - without stdin succeeded, want an error")
+	}
+}
+
+func TestDecodePBN(t *testing.T) {
+	p, err := source.DecodePBN(strings.NewReader(pbnPuzzle))
+	if err != nil {
+		t.Fatal(err)
+	}
+	black, red := types.ClueItem{ColorID: 1, Clue: 1}, types.ClueItem{ColorID: 2, Clue: 1}
+	want := &puzzle.Puzzle{
+		ID:       "7",
+		Width:    3,
+		Height:   2,
+		Colors:   map[int]string{1: "#000000", 2: "#ff0000"},
+		Rows:     [][]types.ClueItem{{black, red}, {black}},
+		Cols:     [][]types.ClueItem{{black}, {red, black}, nil},
+		Solution: [][]int{{1, 2, 0}, {0, 1, 0}},
+	}
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("DecodePBN = %+v, want %+v", p, want)
+	}
+
+	for name, doc := range map[string]string{
+		"not xml":       "<puzzleset",
+		"no puzzle":     "<puzzleset></puzzleset>",
+		"missing rows":  `<puzzleset><puzzle><clues type="columns"><line/></clues></puzzle></puzzleset>`,
+		"unknown color": `<puzzleset><puzzle><clues type="columns"><line><count color="blue">1</count></line></clues><clues type="rows"><line/></clues></puzzle></puzzleset>`,
+	} {
+		if _, err := source.DecodePBN(strings.NewReader(doc)); err == nil {
+			t.Errorf("%s: DecodePBN succeeded, want an error", name)
+		}
+	}
+}
+
+func TestWebPBNSource(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.FormValue("fmt") != "xml" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if r.FormValue("id") != "7" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(pbnPuzzle))
+	}))
+	defer ts.Close()
+
+	src := source.WebPBN{BaseURL: ts.URL, Options: network.FetchOptions{Client: ts.Client()}}
+	p, err := src.Fetch(context.Background(), "7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.ID != "webpbn-7" || p.Width != 3 || p.Height != 2 {
+		t.Errorf("fetched %+v, want the 3x2 puzzle webpbn-7", p)
+	}
+
+	for _, id := range []string{"8", "abc"} {
+		if _, err := src.Fetch(context.Background(), id); err == nil {
+			t.Errorf("Fetch(%q) succeeded, want an error", id)
+		}
+	}
+}
+
+func TestWebPBNSourceUsesFetchOptions(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.UserAgent() != "test-agent" {
+			http.Error(w, "bad user agent", http.StatusBadRequest)
+			return
+		}
+		if calls.Add(1) == 1 {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(pbnPuzzle))
+	}))
+	defer ts.Close()
+
+	opts := network.FetchOptions{Retries: 1, Backoff: time.Millisecond, UserAgent: "test-agent"}
+	src := source.WebPBN{BaseURL: ts.URL, Options: opts}
+	if _, err := src.Fetch(context.Background(), "7"); err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("server saw %d requests, want 2 (one retry after the 503)", calls.Load())
+	}
+
+	calls.Store(0)
+	opts.Retries = 0
+	src.Options = opts
+	if _, err := src.Fetch(context.Background(), "7"); err == nil {
+		t.Error("Fetch succeeded without retries, want the 503")
+	}
+}
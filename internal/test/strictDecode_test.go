@@ -0,0 +1,129 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"testing"
+
+	network "nonogram-solver/internal/network"
+)
+
+// fixtureData returns the color fixture's "var d" arrays. The fixture has 3
+// colors, so its cell runs start at index 10.
+func fixtureData(t *testing.T) [][]int {
+	t.Helper()
+	m := regexp.MustCompile(`var d=(\[.*?\]);`).FindSubmatch(readFixture(t, "color.html"))
+	var data [][]int
+	if err := json.Unmarshal(m[1], &data); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func page(data [][]int) []byte {
+	encoded, _ := json.Marshal(data)
+	return []byte(fmt.Sprintf("<script>var d=%s;</script>", encoded))
+}
+
+func TestDecodePageStrict(t *testing.T) {
+	const firstCell = 10
+
+	valid := fixtureData(t)
+	lenient, err := network.DecodePage("2", page(valid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	strict, err := network.DecodePageStrict("2", page(valid))
+	if err != nil || !reflect.DeepEqual(strict, lenient) {
+		t.Fatalf("DecodePageStrict of a valid page = %+v, %v, want the lenient result", strict, err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(d [][]int) [][]int
+		index  int
+		field  string
+	}{
+		{name: "short cell", mutate: func(d [][]int) [][]int { d[firstCell] = d[firstCell][:2]; return d }, index: firstCell, field: "cell"},
+		{name: "row outside grid", mutate: func(d [][]int) [][]int { d[firstCell][3] += 40; return d }, index: firstCell, field: "cell row"},
+		{name: "columns outside grid", mutate: func(d [][]int) [][]int { d[firstCell+1][0] += 5; return d }, index: firstCell + 1, field: "cell columns"},
+		{name: "unknown color", mutate: func(d [][]int) [][]int { d[firstCell+2][2] += 7; return d }, index: firstCell + 2, field: "cell color"},
+		{name: "overlapping runs", mutate: func(d [][]int) [][]int { d[firstCell+1] = d[firstCell]; return d }, index: firstCell + 1, field: "cell"},
+		{name: "missing runs", mutate: func(d [][]int) [][]int { return d[:len(d)-1] }, index: len(valid) - 1, field: "cells"},
+		{name: "color out of range", mutate: func(d [][]int) [][]int { d[6][1] += 300; return d }, index: 6, field: "color 2 green"},
+		{name: "short color", mutate: func(d [][]int) [][]int { d[7] = d[7][:3]; return d }, index: 7, field: "color 3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := tt.mutate(fixtureData(t))
+			if _, err := network.DecodePage("2", page(data)); err != nil {
+				t.Errorf("lenient DecodePage = %v, want today's best-effort result", err)
+			}
+
+			_, err := network.DecodePageStrict("2", page(data))
+			var de *network.DecodeError
+			if !errors.As(err, &de) {
+				t.Fatalf("DecodePageStrict error = %v, want a *DecodeError", err)
+			}
+			if de.Index != tt.index || de.Field != tt.field {
+				t.Errorf("DecodeError at data[%d] (%s), want data[%d] (%s): %v", de.Index, de.Field, tt.index, tt.field, err)
+			}
+		})
+	}
+}
+
+func TestDecodePageRejectsBadSizes(t *testing.T) {
+	const gridMetadata = 8
+
+	tests := []struct {
+		name   string
+		mutate func(d [][]int) [][]int
+		index  int
+		field  string
+	}{
+		{name: "zero width modulus", mutate: func(d [][]int) [][]int { d[1][3] = 0; return d }, index: 1, field: "width modulus"},
+		{name: "negative colors modulus", mutate: func(d [][]int) [][]int { d[3][3] = -4; return d }, index: 3, field: "colors modulus"},
+		{name: "zero grid metadata modulus", mutate: func(d [][]int) [][]int { d[gridMetadata][3] = 0; return d }, index: gridMetadata, field: "grid metadata modulus"},
+		{name: "huge width", mutate: func(d [][]int) [][]int { d[1] = []int{2000, 0, 0, 5000}; return d }, index: 1, field: "width"},
+		{name: "huge height", mutate: func(d [][]int) [][]int { d[2] = []int{1001, 0, 0, 5000}; return d }, index: 2, field: "height"},
+		{name: "huge run count", mutate: func(d [][]int) [][]int { d[gridMetadata] = []int{1000, 0, 0, 5000}; return d }, index: gridMetadata, field: "grid metadata"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, decode := range map[string]func(string, []byte) (any, error){
+				"DecodePage":       func(id string, b []byte) (any, error) { return network.DecodePage(id, b) },
+				"DecodePageStrict": func(id string, b []byte) (any, error) { return network.DecodePageStrict(id, b) },
+			} {
+				_, err := decode("2", page(tt.mutate(fixtureData(t))))
+				var de *network.DecodeError
+				if !errors.As(err, &de) {
+					t.Fatalf("%s error = %v, want a *DecodeError", name, err)
+				}
+				if de.Index != tt.index || de.Field != tt.field {
+					t.Errorf("%s: DecodeError at data[%d] (%s), want data[%d] (%s): %v", name, de.Index, de.Field, tt.index, tt.field, err)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodePageShortColorSection(t *testing.T) {
+	// The fixture's colors are data[5] to data[7]; stop after the first two
+	data := fixtureData(t)[:7]
+
+	// Lenient decoding skips the missing color and stops at the grid
+	// metadata, which would follow the colors
+	_, err := network.DecodePage("2", page(data))
+	var de *network.DecodeError
+	if !errors.As(err, &de) || de.Field != "grid metadata" {
+		t.Errorf("DecodePage error = %v, want the missing grid metadata", err)
+	}
+
+	_, err = network.DecodePageStrict("2", page(data))
+	if !errors.As(err, &de) || de.Field != "colors" || de.Index != 7 {
+		t.Errorf("DecodePageStrict error = %v, want data[7] (colors)", err)
+	}
+}
@@ -0,0 +1,69 @@
+package types
+
+import (
+	"fmt"
+
+	"nonogram-solver/internal/combinatorics"
+)
+
+// ErrConflict is returned when a fact contradicts an existing one
+var ErrConflict = fmt.Errorf("%w: conflicting facts", combinatorics.ErrInfeasibleLine)
+
+// CellState is what is known about a cell
+type CellState int
+
+const (
+	CellUnknown CellState = iota
+	CellEmpty
+	CellFilled
+)
+
+// Cell is one grid cell as seen by both its row and its column
+type Cell struct {
+	State CellState
+	Color int // color ID when State is CellFilled
+	// Candidates are the states an unknown cell may take according to its
+	// row and column clues: 0 for empty, then color IDs in ascending order.
+	// Known cells have none.
+	Candidates []int
+}
+
+// EmptyCell returns a cell known to be empty
+func EmptyCell() Cell {
+	return Cell{State: CellEmpty}
+}
+
+// ColorCell returns a cell known to hold the given color; color 0 is empty
+func ColorCell(color int) Cell {
+	if color == 0 {
+		return EmptyCell()
+	}
+	return Cell{State: CellFilled, Color: color}
+}
+
+// Known reports whether the cell is empty or filled
+func (c Cell) Known() bool {
+	return c.State != CellUnknown
+}
+
+// Value returns -1 for unknown, 0 for empty or the color ID, as in
+// puzzle.Cells
+func (c Cell) Value() int {
+	switch c.State {
+	case CellEmpty:
+		return 0
+	case CellFilled:
+		return c.Color
+	}
+	return -1
+}
+
+func (c Cell) String() string {
+	switch c.State {
+	case CellEmpty:
+		return "empty"
+	case CellFilled:
+		return fmt.Sprintf("color %d", c.Color)
+	}
+	return "unknown"
+}
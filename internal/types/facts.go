@@ -8,6 +8,26 @@ type Facts struct {
 	EmptyMask     *Bitset         // bits 1 = must be empty
 }
 
+// NewFacts returns facts with nothing known
+func NewFacts() *Facts {
+	return &Facts{
+		FilledByColor: make(map[int]*Bitset),
+		EmptyMask:     NewBitset(big.NewInt(0)),
+	}
+}
+
+// Clone returns a copy of the facts that shares no bitsets
+func (f *Facts) Clone() *Facts {
+	out := &Facts{
+		FilledByColor: make(map[int]*Bitset, len(f.FilledByColor)),
+		EmptyMask:     NewBitset(new(big.Int).Set(f.EmptyMask.Int)),
+	}
+	for color, bitset := range f.FilledByColor {
+		out.FilledByColor[color] = NewBitset(new(big.Int).Set(bitset.Int))
+	}
+	return out
+}
+
 // IsKnown returns true if the position is known (either filled or empty)
 func (f *Facts) IsKnown(i int) bool {
 	if f.EmptyMask.Bit(i) == 1 {
@@ -21,9 +41,23 @@ func (f *Facts) IsKnown(i int) bool {
 	return false
 }
 
+// ColorAt returns the known state of position i: 0 for empty or the color ID.
+// known is false when nothing is known about the position yet.
+func (f *Facts) ColorAt(i int) (color int, known bool) {
+	if f.EmptyMask.Bit(i) == 1 {
+		return 0, true
+	}
+	for c, bitset := range f.FilledByColor {
+		if bitset.Bit(i) == 1 {
+			return c, true
+		}
+	}
+	return 0, false
+}
+
 // MarkEmpty marks position i as empty
 func (f *Facts) MarkEmpty(i int) {
-	f.EmptyMask.SetBit(nil, i, 1)
+	f.EmptyMask.SetBit(f.EmptyMask.Int, i, 1)
 }
 
 // MarkFilled marks position i as filled with the given color
@@ -31,5 +65,28 @@ func (f *Facts) MarkFilled(i int, color int) {
 	if f.FilledByColor[color] == nil {
 		f.FilledByColor[color] = NewBitset(big.NewInt(0))
 	}
-	f.FilledByColor[color].SetBit(nil, i, 1)
+	f.FilledByColor[color].SetBit(f.FilledByColor[color].Int, i, 1)
+}
+
+// Masks converts the facts into the combination bit layout used by
+// CombinationsProvider, where position i maps to bit size-1-i.
+func (f *Facts) Masks(size int) (map[int]*Bitset, *Bitset) {
+	filled := make(map[int]*Bitset, len(f.FilledByColor))
+	for color, bitset := range f.FilledByColor {
+		filled[color] = NewBitset(toMaskLayout(bitset, size))
+	}
+	return filled, NewBitset(toMaskLayout(f.EmptyMask, size))
+}
+
+func toMaskLayout(bitset *Bitset, size int) *big.Int {
+	mask := big.NewInt(0)
+	if bitset == nil {
+		return mask
+	}
+	for i := 0; i < size; i++ {
+		if bitset.Bit(i) == 1 {
+			mask.SetBit(mask, size-1-i, 1)
+		}
+	}
+	return mask
 }
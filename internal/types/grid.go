@@ -1,11 +1,15 @@
 package types
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+)
 
 // Grid represents a nonogram grid with rows and columns of Lines
 type Grid struct {
-	Rows []*Line
-	Cols []*Line
+	Rows   []*Line
+	Cols   []*Line
+	Colors map[int]string // color ID -> hex color
 }
 
 // Orthogonal returns the orthogonal line and index for the given line at the specified position.
@@ -32,6 +36,79 @@ func (g *Grid) Height() int {
 	return len(g.Rows)
 }
 
+// At returns the cell at (row, col). The row and column facts are read
+// together: a state known to either line is known, and states they disagree on
+// are a *LineError wrapping ErrConflict. Indices outside the grid are an error,
+// as for Set.
+func (g *Grid) At(row, col int) (Cell, error) {
+	if err := g.checkCell(row, col); err != nil {
+		return Cell{}, err
+	}
+	r, c := g.Rows[row], g.Cols[col]
+	rowColor, rowKnown := r.Facts.ColorAt(col)
+	colColor, colKnown := c.Facts.ColorAt(row)
+	switch {
+	case rowKnown && colKnown && rowColor != colColor:
+		return Cell{}, &LineError{ID: c.ID, Err: fmt.Errorf("%w: row %d is %s, column %d is %s", ErrConflict,
+			row+1, ColorCell(rowColor), col+1, ColorCell(colColor))}
+	case rowKnown:
+		return ColorCell(rowColor), nil
+	case colKnown:
+		return ColorCell(colColor), nil
+	}
+	return Cell{Candidates: sharedStates(r.Clues, c.Clues)}, nil
+}
+
+// Set records a known cell in both its row and column facts. Nothing is
+// changed unless both accept it: a line already holding another state fails
+// with a *LineError wrapping ErrConflict. Setting a cell to its own state is a
+// no-op.
+func (g *Grid) Set(row, col int, cell Cell) error {
+	if !cell.Known() {
+		return fmt.Errorf("row %d column %d: cannot set a cell back to unknown", row+1, col+1)
+	}
+	if err := g.checkCell(row, col); err != nil {
+		return err
+	}
+	lines := [2]*Line{g.Rows[row], g.Cols[col]}
+	positions := [2]int{col, row}
+	value := cell.Value()
+	for i, l := range lines {
+		if known, ok := l.Facts.ColorAt(positions[i]); ok && known != value {
+			return &LineError{ID: l.ID, Err: fmt.Errorf("%w: position %d is %s, set %s", ErrConflict, positions[i]+1, ColorCell(known), cell)}
+		}
+	}
+	for i, l := range lines {
+		if l.Facts.IsKnown(positions[i]) {
+			continue
+		}
+		if value == 0 {
+			l.Facts.MarkEmpty(positions[i])
+		} else {
+			l.Facts.MarkFilled(positions[i], value)
+		}
+	}
+	return nil
+}
+
+// sharedStates returns empty and the colors both clue lists use
+func sharedStates(a, b []ClueItem) []int {
+	inA := make(map[int]bool, len(a))
+	for _, clue := range a {
+		inA[clue.ColorID] = true
+	}
+	states := []int{0}
+	seen := make(map[int]bool, len(b))
+	for _, clue := range b {
+		if inA[clue.ColorID] && !seen[clue.ColorID] {
+			seen[clue.ColorID] = true
+			states = append(states, clue.ColorID)
+		}
+	}
+	sort.Ints(states)
+	return states
+}
+
 // Print prints a simple representation of the grid
 func (g *Grid) Print() {
 	for _, row := range g.Rows {
@@ -46,3 +123,11 @@ func (g *Grid) Print() {
 		fmt.Println()
 	}
 }
+
+// checkCell rejects a cell outside the grid
+func (g *Grid) checkCell(row, col int) error {
+	if row < 0 || row >= len(g.Rows) || col < 0 || col >= len(g.Cols) {
+		return fmt.Errorf("cell (%d, %d) is outside the %dx%d grid", row+1, col+1, len(g.Cols), len(g.Rows))
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package types
+
+import "fmt"
+
+// LineError attributes an error to a specific row or column
+type LineError struct {
+	ID  LineID
+	Err error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("%s %d: %v", e.ID.Direction, e.ID.Index+1, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,123 @@
+package types
+
+import (
+	"fmt"
+
+	"nonogram-solver/internal/combinatorics"
+)
+
+// Snapshot is a saved copy of a grid's mutable state: each line's facts and
+// the filtered state of its combinations provider. Clues and line IDs are not
+// copied, since they never change.
+type Snapshot struct {
+	rows, cols []lineState
+}
+
+type lineState struct {
+	facts        *Facts
+	combinations combinatorics.CombinationsProvider
+}
+
+// Clone returns an independent copy of the grid. Facts are copied and each
+// provider is cloned with its filtered combinations, so the copy can be
+// branched and solved without touching g. Clues and colors are shared.
+func (g *Grid) Clone() *Grid {
+	out := &Grid{
+		Rows:   make([]*Line, len(g.Rows)),
+		Cols:   make([]*Line, len(g.Cols)),
+		Colors: g.Colors,
+	}
+	for i, row := range g.Rows {
+		out.Rows[i] = row.clone()
+	}
+	for i, col := range g.Cols {
+		out.Cols[i] = col.clone()
+	}
+	return out
+}
+
+// Release returns every line's cached combinations to the memory budget, for
+// a grid about to be dropped. The grid stays usable.
+func (g *Grid) Release() {
+	for _, l := range g.Rows {
+		l.release()
+	}
+	for _, l := range g.Cols {
+		l.release()
+	}
+}
+
+// Snapshot saves the grid's facts and provider state for Restore. Later
+// changes to g do not affect the snapshot.
+func (g *Grid) Snapshot() *Snapshot {
+	s := &Snapshot{
+		rows: make([]lineState, len(g.Rows)),
+		cols: make([]lineState, len(g.Cols)),
+	}
+	for i, row := range g.Rows {
+		s.rows[i] = row.state()
+	}
+	for i, col := range g.Cols {
+		s.cols[i] = col.state()
+	}
+	return s
+}
+
+// Restore rolls the grid back to a snapshot taken from a grid of the same
+// size. The snapshot stays valid, so it can be restored again. The replaced
+// providers release their budget reservations.
+func (g *Grid) Restore(s *Snapshot) error {
+	if len(s.rows) != len(g.Rows) || len(s.cols) != len(g.Cols) {
+		return fmt.Errorf("snapshot of a %dx%d grid cannot restore a %dx%d grid", len(s.cols), len(s.rows), len(g.Cols), len(g.Rows))
+	}
+	for i, row := range g.Rows {
+		row.restore(s.rows[i])
+	}
+	for i, col := range g.Cols {
+		col.restore(s.cols[i])
+	}
+	return nil
+}
+
+// Release returns the snapshot's cached combinations to the memory budget,
+// for a snapshot about to be dropped. Restoring it afterwards still works;
+// the restored lines regenerate their combinations.
+func (s *Snapshot) Release() {
+	for _, states := range [][]lineState{s.rows, s.cols} {
+		for _, state := range states {
+			if state.combinations != nil {
+				state.combinations.Release()
+			}
+		}
+	}
+}
+
+func (l *Line) clone() *Line {
+	out := *l
+	state := l.state()
+	out.Facts, out.Combinations = state.facts, state.combinations
+	return &out
+}
+
+func (l *Line) state() lineState {
+	state := lineState{facts: l.Facts.Clone()}
+	if l.Combinations != nil {
+		state.combinations = l.Combinations.Clone()
+	}
+	return state
+}
+
+func (l *Line) restore(state lineState) {
+	l.release()
+	l.Facts = state.facts.Clone()
+	l.Combinations = nil
+	if state.combinations != nil {
+		l.Combinations = state.combinations.Clone()
+	}
+}
+
+func (l *Line) release() {
+	if l.Combinations != nil {
+		l.Combinations.Release()
+	}
+}